@@ -0,0 +1,94 @@
+package muxer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// CertManager obtains and renews TLS certificates with ACME, e.g.
+// *autocert.Manager of golang.org/x/crypto/acme/autocert:
+//
+//	certs := &autocert.Manager{
+//		Prompt:     autocert.AcceptTOS,
+//		HostPolicy: muxer.AllowHosts("example.com", "www.example.com"),
+//		Cache:      autocert.DirCache("/var/lib/app/certs"),
+//	}
+//	muxer.Serve(ctx, m, muxer.ServeOptions{Endpoints: []muxer.Endpoint{
+//		{Addr: ":443", Certs: certs},
+//		{Addr: ":80"},
+//	}})
+type CertManager interface {
+	GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error)
+	// Returns a handler answering HTTP-01 challenges and passing other
+	// requests to fallback.
+	HTTPHandler(fallback http.Handler) http.Handler
+}
+
+// Path prefix of ACME HTTP-01 challenges, see RFC 8555, section 8.3.
+const acmeChallengePrefix = ".well-known/acme-challenge/"
+
+// Makes this mux answer ACME HTTP-01 challenges of mgr at
+// /.well-known/acme-challenge/ on plaintext endpoints. Called by Serve()
+// for endpoints with a CertManager. The challenge route is added once,
+// later calls replace the manager. Panics if the base path isn't "/" and
+// the mux isn't hooked up with an http.ServeMux, e.g. a clone.
+func (dm *defaultMux) ACMEChallenges(mgr CertManager) {
+	if dm.base != "/" {
+		dm.checkRootAccess("/" + acmeChallengePrefix)
+	}
+	first := dm.acme == nil
+	dm.acme = mgr
+	if !first {
+		return
+	}
+	h := func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		if r.TLS != nil {
+			http.NotFound(w, r)
+			return
+		}
+		dm.acme.HTTPHandler(http.NotFoundHandler()).ServeHTTP(w, r)
+	}
+	if dm.base == "/" {
+		dm.Add("GET", acmeChallengePrefix+"{token}", h)
+		return
+	}
+	dm.httpMux.HandleFunc("/"+acmeChallengePrefix, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r, nil)
+	})
+}
+
+// Returns TLS config of an endpoint getting certificates from mgr, based
+// on config c, which may be nil.
+func acmeTLSConfig(c *tls.Config, mgr CertManager) *tls.Config {
+	if c == nil {
+		c = &tls.Config{}
+	} else {
+		c = c.Clone()
+	}
+	c.GetCertificate = mgr.GetCertificate
+	if len(c.NextProtos) == 0 {
+		c.NextProtos = []string{"h2", "http/1.1"}
+	}
+	// Lets the manager answer TLS-ALPN-01 challenges too.
+	c.NextProtos = append(c.NextProtos, "acme-tls/1")
+	return c
+}
+
+// Returns a host policy allowing certificates only for hosts, e.g. for
+// autocert.Manager.HostPolicy. Hosts are compared case-insensitively.
+func AllowHosts(hosts ...string) func(ctx context.Context, host string) error {
+	allowed := make(map[string]bool, len(hosts))
+	for _, h := range hosts {
+		allowed[strings.ToLower(h)] = true
+	}
+	return func(ctx context.Context, host string) error {
+		if !allowed[strings.ToLower(host)] {
+			return fmt.Errorf("muxer: host %q not allowed", host)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,129 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeCertManager struct {
+	cert *tls.Certificate
+}
+
+func (m *fakeCertManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return m.cert, nil
+}
+
+func (m *fakeCertManager) HTTPHandler(fallback http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/.well-known/acme-challenge/") {
+			w.Write([]byte("key-auth"))
+			return
+		}
+		fallback.ServeHTTP(w, r)
+	})
+}
+
+func selfSignedCert(t *testing.T) *tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestACMEChallenges(t *testing.T) {
+	certs := &fakeCertManager{}
+	for _, base := range []string{"/", "/app"} {
+		sm := http.NewServeMux()
+		m := NewMux(base, sm)
+		m.ACMEChallenges(certs)
+		m.ACMEChallenges(certs)
+		w := serve(sm, "GET", "/.well-known/acme-challenge/abc")
+		assertEqual(t, w.Body.String(), "key-auth")
+		req, _ := http.NewRequest("GET", "/.well-known/acme-challenge/abc", nil)
+		req.TLS = &tls.ConnectionState{}
+		if w := serveRequest(sm, req); w.Code != 404 {
+			t.Errorf("%s: Expected 404 over TLS, got %d", base, w.Code)
+		}
+		if base != "/" {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("%s: ACMEChallenges() of a clone didn't panic", base)
+					}
+				}()
+				m.Clone().ACMEChallenges(certs)
+			}()
+		}
+	}
+
+	policy := AllowHosts("example.com")
+	if err := policy(context.Background(), "Example.com"); err != nil {
+		t.Error(err)
+	}
+	if err := policy(context.Background(), "evil.com"); err == nil {
+		t.Error("Expected evil.com to be rejected")
+	}
+}
+
+func TestServeWithCertManager(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "hello", dummy)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Serve(ctx, m, ServeOptions{Endpoints: []Endpoint{
+			{Listener: ln, Certs: &fakeCertManager{selfSignedCert(t)}},
+		}})
+	}()
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}
+	resp, err := client.Get("https://" + ln.Addr().String() + "/hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	client.CloseIdleConnections()
+	if resp.TLS == nil || resp.StatusCode != 200 {
+		t.Errorf("Expected 200 over TLS, got %d", resp.StatusCode)
+	}
+	assertEqual(t, string(b), "params:")
+	// The challenge route is registered on the mux.
+	assertEqual(t, serve(m, "GET", "/.well-known/acme-challenge/abc").Body.String(), "key-auth")
+	cancel()
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,134 @@
+package muxer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Alias maps a slug, e.g. short link "spring-sale", to a named route
+// and its params.
+type Alias struct {
+	// Name of the route, see Route.As().
+	Route string
+	// Params to build the route's path with, in order of the pattern.
+	Params []string
+	// Status code of the redirect. Defaults to 302 Found, so browsers don't
+	// cache links which can change at runtime.
+	Code int
+}
+
+// AliasStore is a backend of aliases, e.g. a database table managed
+// by a marketing tool.
+type AliasStore interface {
+	// Returns the alias of slug or nil if there's none.
+	Lookup(ctx context.Context, slug string) (*Alias, error)
+}
+
+// Makes this mux look up URL paths which don't match any route in store,
+// e.g. /spring-sale, and redirect to the path of the aliased route:
+//
+//	aliases := &muxer.AliasMap{}
+//	aliases.Set("spring-sale", muxer.Alias{Route: "product", Params: []string{"42"}})
+//	m.Aliases(aliases)
+//
+// Slugs are paths relative to the base path of the mux. Only GET and HEAD
+// requests are looked up. The query of the request is kept. A nil store
+// disables aliases.
+func (dm *defaultMux) Aliases(store AliasStore) {
+	dm.aliases = store
+}
+
+// Redirects req to the aliased route of URL path p, if any.
+// Reports whether req has been served.
+func (dm *defaultMux) serveAlias(w http.ResponseWriter, req *http.Request, p string) bool {
+	if dm.aliases == nil || p == "" || (req.Method != "GET" && req.Method != "HEAD") {
+		return false
+	}
+	a, err := dm.aliases.Lookup(req.Context(), p)
+	if err == nil && a == nil {
+		return false
+	}
+	if err == nil {
+		err = dm.checkAlias(a)
+	}
+	if err != nil {
+		Error(w, req, err)
+		return true
+	}
+	target := dm.BuildPathFor(req, a.Route, stringsToArgs(a.Params)...)
+	if req.URL.RawQuery != "" {
+		target += "?" + req.URL.RawQuery
+	}
+	code := a.Code
+	if code == 0 {
+		code = http.StatusFound
+	}
+	http.Redirect(w, req, target, code)
+	return true
+}
+
+// Returns an error if path of alias a can't be built.
+func (dm *defaultMux) checkAlias(a *Alias) error {
+	for _, r := range dm.snapshot() {
+		if r.Name != a.Route {
+			continue
+		}
+		vars := 0
+		for _, rp := range r.parts {
+			if rp.isVar {
+				vars++
+			}
+		}
+		if vars != len(a.Params) {
+			return fmt.Errorf("Alias of route '%s' has %d params, want %d",
+				a.Route, len(a.Params), vars)
+		}
+		return nil
+	}
+	return fmt.Errorf("Alias refers to unknown route '%s'", a.Route)
+}
+
+func stringsToArgs(s []string) []interface{} {
+	args := make([]interface{}, len(s))
+	for i, v := range s {
+		args[i] = v
+	}
+	return args
+}
+
+// AliasMap is an in-memory AliasStore which can be changed while serving.
+// The zero value is an empty map ready to use.
+type AliasMap struct {
+	mu      sync.RWMutex
+	aliases map[string]Alias
+}
+
+// Sets the alias of slug, replacing an existing one.
+func (m *AliasMap) Set(slug string, a Alias) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.aliases == nil {
+		m.aliases = make(map[string]Alias)
+	}
+	m.aliases[strings.Trim(slug, "/")] = a
+}
+
+// Removes the alias of slug.
+func (m *AliasMap) Delete(slug string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.aliases, strings.Trim(slug, "/"))
+}
+
+// Lookup implements AliasStore.
+func (m *AliasMap) Lookup(ctx context.Context, slug string) (*Alias, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if a, ok := m.aliases[slug]; ok {
+		return &a, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,60 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestAliases(t *testing.T) {
+	m := NewMux("/shop", http.NewServeMux())
+	m.Add("GET", "products/{id}", dummy).As("product")
+	m.Add("GET", "sale", dummy).As("sale")
+	aliases := &AliasMap{}
+	aliases.Set("/spring", Alias{Route: "product", Params: []string{"42"}})
+	aliases.Set("promo/summer", Alias{Route: "sale", Code: http.StatusMovedPermanently})
+	aliases.Set("broken", Alias{Route: "product"})
+	aliases.Set("gone", Alias{Route: "nope"})
+	m.Aliases(aliases)
+
+	tests := []struct {
+		method, path string
+		code         int
+		location     string
+	}{
+		{"GET", "/shop/spring?utm_source=mail", 302, "/shop/products/42?utm_source=mail"},
+		{"GET", "/shop/promo/summer", 301, "/shop/sale"},
+		{"POST", "/shop/spring", 404, ""},
+		{"GET", "/shop/winter", 404, ""},
+		{"GET", "/shop/broken", 500, ""},
+		{"GET", "/shop/gone", 500, ""},
+		{"GET", "/shop/sale", 200, ""},
+	}
+	for i, test := range tests {
+		w := serve(m, test.method, test.path)
+		if w.Code != test.code {
+			t.Errorf("%d: Expected %d, got %d", i, test.code, w.Code)
+		}
+		assertEqual(t, w.Header().Get("Location"), test.location)
+	}
+
+	aliases.Delete("spring")
+	if w := serve(m, "GET", "/shop/spring"); w.Code != 404 {
+		t.Errorf("Expected 404 for deleted alias, got %d", w.Code)
+	}
+	m.Aliases(aliasStoreFunc(func(ctx context.Context, slug string) (*Alias, error) {
+		return nil, errors.New("store is down")
+	}))
+	w := serve(m, "GET", "/shop/spring")
+	assertEqual(t, w.Body.String(), "store is down\n")
+}
+
+type aliasStoreFunc func(ctx context.Context, slug string) (*Alias, error)
+
+func (f aliasStoreFunc) Lookup(ctx context.Context, slug string) (*Alias, error) {
+	return f(ctx, slug)
+}
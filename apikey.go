@@ -0,0 +1,145 @@
+package muxer
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// KeyResolver looks up the principal of an API key. It returns nil principal
+// and nil error for unknown keys.
+type KeyResolver interface {
+	ResolveKey(ctx context.Context, key string) (*Principal, error)
+}
+
+// Function type implementing KeyResolver.
+type KeyResolverFunc func(ctx context.Context, key string) (*Principal, error)
+
+func (f KeyResolverFunc) ResolveKey(ctx context.Context, key string) (*Principal, error) {
+	return f(ctx, key)
+}
+
+// Options of APIKeyAuth middleware.
+type APIKeyOptions struct {
+	// Request header with the key. Defaults to "X-API-Key".
+	Header string
+	// Query param with the key, checked if the header is empty.
+	// Zero string disables query params.
+	Query string
+	// How long resolved keys, including unknown ones, are cached.
+	// Zero disables caching.
+	CacheTTL time.Duration
+	// Let requests without a key through, without a principal.
+	// Requests with an unknown key are always rejected.
+	Optional bool
+}
+
+// Maximum number of keys cached by APIKeyAuth, the least recently used
+// ones are evicted.
+const maxCachedKeys = 10000
+
+// Returns middleware authenticating requests with API keys. The principal of
+// a valid key is attached to the request context, see PrincipalFrom(),
+// and its QuotaKey is what rate limiting and quotas should account requests
+// to, see QuotaKey(). Requests without a key or with an unknown one are
+// rejected with 401 Unauthorized, resolver errors with 500, both passed
+// to Error().
+func APIKeyAuth(resolver KeyResolver, opts APIKeyOptions) Middleware {
+	if opts.Header == "" {
+		opts.Header = "X-API-Key"
+	}
+	cache := &keyCache{ttl: opts.CacheTTL, size: maxCachedKeys}
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+			key := r.Header.Get(opts.Header)
+			if key == "" && opts.Query != "" {
+				key = r.URL.Query().Get(opts.Query)
+			}
+			if key == "" {
+				if opts.Optional {
+					next(w, r, v)
+					return
+				}
+				Error(w, r, NewStatusError(http.StatusUnauthorized, "API key required"))
+				return
+			}
+			p, ok := cache.get(key)
+			if !ok {
+				var err error
+				if p, err = resolver.ResolveKey(r.Context(), key); err != nil {
+					Error(w, r, err)
+					return
+				}
+				cache.put(key, p)
+			}
+			if p == nil {
+				Error(w, r, NewStatusError(http.StatusUnauthorized, "Invalid API key"))
+				return
+			}
+			next(w, r.WithContext(WithPrincipal(r.Context(), p)), v)
+		}
+	}
+}
+
+// LRU cache of resolved keys.
+type keyCache struct {
+	ttl  time.Duration
+	size int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	ll      *list.List
+}
+
+type keyCacheEntry struct {
+	key       string
+	principal *Principal
+	expires   time.Time
+}
+
+func (c *keyCache) get(key string) (*Principal, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*keyCacheEntry)
+	if time.Now().After(e.expires) {
+		c.ll.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return e.principal, true
+}
+
+func (c *keyCache) put(key string, p *Principal) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := &keyCacheEntry{key, p, time.Now().Add(c.ttl)}
+	if el, ok := c.entries[key]; ok {
+		el.Value = e
+		c.ll.MoveToFront(el)
+		return
+	}
+	if c.entries == nil {
+		c.entries = make(map[string]*list.Element)
+		c.ll = list.New()
+	}
+	c.entries[key] = c.ll.PushFront(e)
+	if c.ll.Len() > c.size {
+		last := c.ll.Back()
+		c.ll.Remove(last)
+		delete(c.entries, last.Value.(*keyCacheEntry).key)
+	}
+}
@@ -0,0 +1,95 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestAPIKeyAuth(t *testing.T) {
+	lookups := 0
+	resolver := KeyResolverFunc(func(ctx context.Context, key string) (*Principal, error) {
+		lookups++
+		switch key {
+		case "secret":
+			return &Principal{Subject: "svc-1", QuotaKey: "team-a"}, nil
+		case "broken":
+			return nil, errors.New("db down")
+		}
+		return nil, nil
+	})
+	h := func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		p := PrincipalFrom(r.Context())
+		if p == nil {
+			fmt.Fprintf(w, "anonymous:%s", QuotaKey(r))
+			return
+		}
+		fmt.Fprintf(w, "%s:%s", p.Subject, QuotaKey(r))
+	}
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "strict", h).Use(APIKeyAuth(resolver, APIKeyOptions{
+		Query:    "api_key",
+		CacheTTL: time.Minute,
+	}))
+	m.Add("GET", "optional", h).Use(APIKeyAuth(resolver, APIKeyOptions{
+		Header:   "Authorization",
+		Optional: true,
+	}))
+
+	tests := []struct {
+		path, header, value string
+		code                int
+		body                string
+	}{
+		{"/strict", "", "", 401, "API key required\n"},
+		{"/strict", "X-API-Key", "secret", 200, "svc-1:team-a"},
+		{"/strict?api_key=secret", "", "", 200, "svc-1:team-a"},
+		{"/strict", "X-API-Key", "wrong", 401, "Invalid API key\n"},
+		{"/strict", "X-API-Key", "wrong", 401, "Invalid API key\n"},
+		{"/strict", "X-API-Key", "broken", 500, "db down\n"},
+		{"/optional", "", "", 200, "anonymous:192.0.2.1"},
+		{"/optional?api_key=secret", "", "", 200, "anonymous:192.0.2.1"},
+		{"/optional", "Authorization", "wrong", 401, "Invalid API key\n"},
+	}
+	for i, test := range tests {
+		req, _ := http.NewRequest("GET", test.path, nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		if test.header != "" {
+			req.Header.Set(test.header, test.value)
+		}
+		w := serveRequest(m, req)
+		if w.Code != test.code {
+			t.Errorf("%d: Expected %d, got %d", i, test.code, w.Code)
+		}
+		assertEqual(t, w.Body.String(), test.body)
+	}
+	// strict route resolves each key once thanks to the cache
+	if lookups != 4 {
+		t.Fatalf("Expected 4 lookups, got %d", lookups)
+	}
+}
+
+func TestKeyCacheEviction(t *testing.T) {
+	c := &keyCache{ttl: time.Hour, size: 2}
+	a, b := &Principal{Subject: "a"}, &Principal{Subject: "b"}
+	c.put("a", a)
+	c.put("b", b)
+	c.get("a")
+	c.put("c", nil)
+	if _, ok := c.get("b"); ok {
+		t.Error("least recently used key not evicted")
+	}
+	if p, ok := c.get("a"); !ok || p != a {
+		t.Error("recently used key evicted")
+	}
+	if _, ok := c.get("c"); !ok {
+		t.Error("new key not cached")
+	}
+}
@@ -0,0 +1,227 @@
+package muxer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Cache-Control of asset responses requested with a valid content hash.
+const immutableCacheControl = "public, max-age=31536000, immutable"
+
+// Adds a GET route serving static files of fsys with cache-busting names.
+// The last segment of pattern must be a param, e.g. "assets/{file}",
+// and files are looked up in fsys by its value.
+//
+// BuildPath() for such a route inserts a short hash of the file content
+// before its extension:
+//
+//	m.Assets("assets/{file}", os.DirFS("static")).As("asset")
+//	m.BuildPath("asset", "app.js") // "/assets/app.3fa9c2.js"
+//
+// Requests with the current hash are served with far-future caching headers.
+// The original name is served too, with "Cache-Control: no-cache".
+// Requests with an outdated hash get 404 Not Found.
+// Hashes are computed once per file and cached.
+func (dm *defaultMux) Assets(pattern string, fsys fs.FS) *Route {
+	route := dm.Add("GET", pattern, nil)
+	if last := route.parts[route.partsLen-1]; !last.isVar {
+		panic(fmt.Sprintf("Assets pattern '%s' must end with a param", pattern))
+	}
+	files := &assetFiles{fsys: fsys, param: route.parts[route.partsLen-1].name}
+	route.assets = files
+	route.Handler = files.serve
+	dm.handlersChanged()
+	return route
+}
+
+type assetFiles struct {
+	fsys  fs.FS
+	param string
+	// Set with Precompressed()
+	encodings []string
+
+	mu     sync.Mutex
+	hashes map[string]string
+}
+
+// Returns content hash of file name or zero string if it can't be read.
+// Files are read without holding a.mu, so that a slow one doesn't hold up
+// others.
+func (a *assetFiles) hash(name string) string {
+	a.mu.Lock()
+	h, ok := a.hashes[name]
+	a.mu.Unlock()
+	if ok {
+		return h
+	}
+	b, err := fs.ReadFile(a.fsys, name)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	h = hex.EncodeToString(sum[:3])
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.hashes == nil {
+		a.hashes = make(map[string]string)
+	}
+	a.hashes[name] = h
+	return h
+}
+
+// Returns name with its content hash inserted before the extension:
+// "app.js" becomes "app.3fa9c2.js". Returns name as is if the file
+// doesn't exist.
+func (a *assetFiles) hashedName(name string) string {
+	h := a.hash(name)
+	if h == "" {
+		return name
+	}
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + h + ext
+}
+
+// Splits a name built with hashedName() into the original name and hash.
+// Reports false if name doesn't look like a hashed one.
+func splitHashedName(name string) (string, string, bool) {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	i := strings.LastIndex(base, ".")
+	if i < 0 {
+		return "", "", false
+	}
+	return base[:i] + ext, base[i+1:], true
+}
+
+func (a *assetFiles) serve(w http.ResponseWriter, r *http.Request, v url.Values) {
+	name := v.Get(a.param)
+	cacheControl := "no-cache"
+	if a.hash(name) == "" {
+		orig, h, ok := splitHashedName(name)
+		if !ok || a.hash(orig) != h {
+			http.NotFound(w, r)
+			return
+		}
+		name, cacheControl = orig, immutableCacheControl
+	}
+	f, err := a.fsys.Open(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil || fi.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Cache-Control", cacheControl)
+	if vf, vfi := a.precompressed(w, r, name); vf != nil {
+		defer vf.Close()
+		f, fi = vf, vfi
+	}
+	if rs, ok := f.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, name, fi.ModTime(), rs)
+		return
+	}
+	b, err := io.ReadAll(f)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	http.ServeContent(w, r, name, fi.ModTime(), bytes.NewReader(b))
+}
+
+// File name extensions of precompressed variants by content coding.
+var precompressedExts = map[string]string{
+	"br":   ".br",
+	"gzip": ".gz",
+	"zstd": ".zst",
+}
+
+// Makes this route, which must have been added with Assets(), serve
+// precompressed variants of files, e.g. "app.js.br" or "app.js.gz" for
+// "app.js", to clients accepting their encoding. Encodings are tried
+// in order and default to "br" and "gzip"; "zstd" is supported too.
+// Responses are sent with Content-Encoding and "Vary: Accept-Encoding"
+// whenever a file has a variant.
+func (r *Route) Precompressed(encodings ...string) *Route {
+	if r.assets == nil {
+		panic(fmt.Sprintf("Route '%s %s' doesn't serve assets", r.Method, r.Pattern))
+	}
+	if len(encodings) == 0 {
+		encodings = []string{"br", "gzip"}
+	}
+	for _, enc := range encodings {
+		if _, ok := precompressedExts[enc]; !ok {
+			panic(fmt.Sprintf("Unsupported precompressed encoding '%s'", enc))
+		}
+	}
+	r.assets.encodings = encodings
+	return r
+}
+
+// Opens the first variant of file name accepted by r and sets its encoding
+// headers. Returns nil if there's none.
+func (a *assetFiles) precompressed(w http.ResponseWriter, r *http.Request, name string) (fs.File, fs.FileInfo) {
+	accept := r.Header.Get("Accept-Encoding")
+	vary := false
+	for _, enc := range a.encodings {
+		vf, err := a.fsys.Open(name + precompressedExts[enc])
+		if err != nil {
+			continue
+		}
+		vary = true
+		vfi, err := vf.Stat()
+		if err != nil || vfi.IsDir() || !acceptsEncoding(accept, enc) {
+			vf.Close()
+			continue
+		}
+		h := w.Header()
+		h.Add("Vary", "Accept-Encoding")
+		h.Set("Content-Encoding", enc)
+		// ServeContent would sniff the compressed bytes otherwise.
+		if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+			h.Set("Content-Type", ct)
+		} else {
+			h.Set("Content-Type", "application/octet-stream")
+		}
+		return vf, vfi
+	}
+	if vary {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+	return nil, nil
+}
+
+// Reports whether Accept-Encoding header value accept allows
+// content coding enc, either by name or with "*".
+func acceptsEncoding(accept, enc string) bool {
+	wildcard := false
+	for _, part := range strings.Split(accept, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.TrimSpace(coding)
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, _ = strconv.ParseFloat(v, 64)
+		}
+		if strings.EqualFold(coding, enc) {
+			return q > 0
+		}
+		if coding == "*" {
+			wildcard = q > 0
+		}
+	}
+	return wildcard
+}
@@ -0,0 +1,142 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"io/fs"
+	"net/http"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestAssets(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.js":      {Data: []byte("alert(1)")},
+		"app.min.css": {Data: []byte("body{}")},
+	}
+	m := NewMux("/", http.NewServeMux())
+	m.Assets("assets/{file}", fsys).As("asset")
+
+	js := m.BuildPath("asset", "app.js")
+	assertEqual(t, js, "/assets/app.6e11c7.js")
+	assertEqual(t, m.BuildPath("asset", "app.min.css"), "/assets/app.min.7c9804.css")
+	assertEqual(t, m.BuildPath("asset", "missing.js"), "/assets/missing.js")
+
+	tests := []struct {
+		path, cacheControl string
+		code               int
+	}{
+		{js, immutableCacheControl, 200},
+		{"/assets/app.js", "no-cache", 200},
+		{"/assets/app.000000.js", "", 404},
+		{"/assets/missing.js", "", 404},
+	}
+	for _, test := range tests {
+		w := serve(m, "GET", test.path)
+		if w.Code != test.code {
+			t.Fatalf("%s: Expected %d, got %d", test.path, test.code, w.Code)
+		}
+		assertEqual(t, w.Header().Get("Cache-Control"), test.cacheControl)
+		if test.code == 200 {
+			assertEqual(t, w.Body.String(), "alert(1)")
+			assertEqual(t, w.Header().Get("Content-Type"), "text/javascript; charset=utf-8")
+		}
+	}
+
+	defer func() {
+		if err := recover(); err == nil {
+			t.Fatalf("Expected panic, got no error instead")
+		}
+	}()
+	// should panic because the last segment isn't a param
+	m.Assets("static/{dir}/files", fsys)
+}
+
+func TestPrecompressedAssets(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.js":      {Data: []byte("alert(1)")},
+		"app.js.br":   {Data: []byte("br-bytes")},
+		"app.js.gz":   {Data: []byte("gz-bytes")},
+		"style.css":   {Data: []byte("body{}")},
+		"logo.svg":    {Data: []byte("<svg/>")},
+		"logo.svg.gz": {Data: []byte("svg-gz")},
+	}
+	m := NewMux("/", http.NewServeMux())
+	m.Assets("assets/{file}", fsys).As("asset").Precompressed()
+
+	tests := []struct {
+		path, accept, encoding, vary, body string
+	}{
+		{"/assets/app.js", "gzip, deflate, br", "br", "Accept-Encoding", "br-bytes"},
+		{"/assets/app.js", "gzip", "gzip", "Accept-Encoding", "gz-bytes"},
+		{"/assets/app.js", "br;q=0, *", "gzip", "Accept-Encoding", "gz-bytes"},
+		{"/assets/app.js", "identity", "", "Accept-Encoding", "alert(1)"},
+		{m.BuildPath("asset", "app.js"), "br", "br", "Accept-Encoding", "br-bytes"},
+		{"/assets/logo.svg", "br, gzip", "gzip", "Accept-Encoding", "svg-gz"},
+		{"/assets/style.css", "br, gzip", "", "", "body{}"},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", test.path, nil)
+		req.Header.Set("Accept-Encoding", test.accept)
+		w := serveRequest(m, req)
+		if w.Code != 200 {
+			t.Fatalf("%s: Expected 200 OK, got %d", test.path, w.Code)
+		}
+		assertEqual(t, w.Header().Get("Content-Encoding"), test.encoding)
+		assertEqual(t, w.Header().Get("Vary"), test.vary)
+		assertEqual(t, w.Body.String(), test.body)
+	}
+	req, _ := http.NewRequest("GET", "/assets/app.js", nil)
+	req.Header.Set("Accept-Encoding", "br")
+	assertEqual(t, serveRequest(m, req).Header().Get("Content-Type"), "text/javascript; charset=utf-8")
+
+	defer func() {
+		if err := recover(); err == nil {
+			t.Fatalf("Expected panic, got no error instead")
+		}
+	}()
+	// should panic because the route doesn't serve assets
+	m.Add("GET", "page", dummy).Precompressed()
+}
+
+// File system whose file slow.js can't be opened until release is closed.
+type slowFS struct {
+	files            fstest.MapFS
+	opening, release chan struct{}
+}
+
+func (f slowFS) Open(name string) (fs.File, error) {
+	if name == "slow.js" {
+		close(f.opening)
+		<-f.release
+	}
+	return f.files.Open(name)
+}
+
+func TestAssetsSlowFile(t *testing.T) {
+	fsys := slowFS{
+		files: fstest.MapFS{
+			"app.js":  {Data: []byte("alert(1)")},
+			"slow.js": {Data: []byte("alert(2)")},
+		},
+		opening: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	m := NewMux("/", http.NewServeMux())
+	m.Assets("assets/{file}", fsys).As("asset")
+	m.BuildPath("asset", "app.js")
+
+	go m.BuildPath("asset", "slow.js")
+	<-fsys.opening
+	done := make(chan string)
+	go func() { done <- m.BuildPath("asset", "app.js") }()
+	select {
+	case p := <-done:
+		assertEqual(t, p, "/assets/app.6e11c7.js")
+	case <-time.After(time.Second):
+		t.Error("BuildPath() of a hashed file waited for reading another one")
+	}
+	close(fsys.release)
+}
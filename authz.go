@@ -0,0 +1,58 @@
+package muxer
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Authorizer decides whether requests to routes with a policy are allowed.
+// The subject, e.g. an authenticated user, is taken from the request context
+// where authentication middleware has put it. Returning nil allows
+// the request. A *StatusError is responded to with its code, e.g. 401,
+// any other error with 403 Forbidden.
+type Authorizer interface {
+	Authorize(ctx context.Context, policy string, params url.Values) error
+}
+
+// Function type implementing Authorizer.
+type AuthorizerFunc func(ctx context.Context, policy string, params url.Values) error
+
+func (f AuthorizerFunc) Authorize(ctx context.Context, policy string, params url.Values) error {
+	return f(ctx, policy, params)
+}
+
+// Sets the authorizer enforcing route policies, see Route.Policy().
+func (dm *defaultMux) SetAuthorizer(a Authorizer) {
+	dm.authorizer = a
+}
+
+// Requires requests to this route to be allowed by the mux Authorizer
+// with policy, e.g. "orders:read". Authorization runs after all middleware,
+// right before the handler. Requests are denied with 403 Forbidden if
+// the mux has no Authorizer. Rejections are passed to Error() as
+// a *StatusError, so the error handler of the route's group renders them
+// the same way for all routes.
+func (r *Route) Policy(policy string) *Route {
+	r.policy = policy
+	r.group.mux.handlersChanged()
+	return r
+}
+
+func authorizeHandler(dm *defaultMux, policy string, next HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		if dm.authorizer == nil {
+			Error(w, r, NewStatusError(http.StatusForbidden, ""))
+			return
+		}
+		if err := dm.authorizer.Authorize(r.Context(), policy, v); err != nil {
+			se, ok := err.(*StatusError)
+			if !ok {
+				se = NewStatusError(http.StatusForbidden, "")
+			}
+			Error(w, r, se)
+			return
+		}
+		next(w, r, v)
+	}
+}
@@ -0,0 +1,95 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	m := NewMux("/api", http.NewServeMux())
+	g := m.Group("").Error(func(w http.ResponseWriter, r *http.Request, err error) {
+		code := 500
+		if se, ok := err.(*StatusError); ok {
+			code = se.Code
+		}
+		w.WriteHeader(code)
+		fmt.Fprintf(w, `{"error":%q}`, err)
+	})
+	orders := g.Add("GET", "orders/{id}", dummy).Policy("orders:read")
+	g.Add("GET", "public", dummy)
+
+	if w := serve(m, "GET", "/api/orders/1"); w.Code != 403 {
+		t.Fatalf("Expected 403 without authorizer, got %d", w.Code)
+	}
+
+	m.SetAuthorizer(AuthorizerFunc(func(ctx context.Context, policy string, v url.Values) error {
+		switch user, _ := ctx.Value(userKey{}).(string); {
+		case user == "":
+			return NewStatusError(http.StatusUnauthorized, "")
+		case policy == "orders:read" && v.Get("id") == "1":
+			return nil
+		}
+		return errors.New("nope")
+	}))
+	m.Use(func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+			if u := r.Header.Get("X-User"); u != "" {
+				r = r.WithContext(context.WithValue(r.Context(), userKey{}, u))
+			}
+			next(w, r, v)
+		}
+	})
+
+	tests := []struct {
+		path, user string
+		code       int
+		body       string
+	}{
+		{"/api/orders/1", "", 401, `{"error":"Unauthorized"}`},
+		{"/api/orders/1", "alex", 200, "params:id=1"},
+		{"/api/orders/2", "alex", 403, `{"error":"Forbidden"}`},
+		{"/api/public", "", 200, "params:"},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", test.path, nil)
+		req.Header.Set("X-User", test.user)
+		w := serveRequest(m, req)
+		if w.Code != test.code {
+			t.Errorf("%s: Expected %d, got %d", test.path, test.code, w.Code)
+		}
+		assertEqual(t, w.Body.String(), test.body)
+	}
+	assertEqual(t, RouteInfos(m)[0].Policy, orders.policy)
+}
+
+func TestPolicyAPIKeyAuth(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.SetAuthorizer(AuthorizerFunc(func(ctx context.Context, policy string, v url.Values) error {
+		if p := PrincipalFrom(ctx); p != nil && p.Subject == "svc" {
+			return nil
+		}
+		return errors.New("nope")
+	}))
+	m.Use(APIKeyAuth(KeyResolverFunc(func(ctx context.Context, key string) (*Principal, error) {
+		if key == "secret" {
+			return &Principal{Subject: "svc"}, nil
+		}
+		return nil, nil
+	}), APIKeyOptions{}))
+	m.Add("GET", "orders", dummy).Policy("orders:read")
+
+	for key, code := range map[string]int{"secret": 200, "wrong": 401, "": 401} {
+		req, _ := http.NewRequest("GET", "/orders", nil)
+		req.Header.Set("X-API-Key", key)
+		if w := serveRequest(m, req); w.Code != code {
+			t.Errorf("key %q: Expected %d, got %d", key, code, w.Code)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package muxer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Mounts this mux at basePath too, e.g. "/v1/" while clients migrate to
+// the new base path of an API, so the same route table serves both.
+// BuildPath() keeps building paths with the base path given to NewMux(),
+// the canonical one. Redirects of a request, e.g. to a path in canonical
+// case, keep the base path it came with. Panics if the mux is already
+// mounted at basePath.
+func (dm *defaultMux) AddBasePath(basePath string) {
+	if !strings.HasPrefix(basePath, "/") {
+		basePath = "/" + basePath
+	}
+	if !strings.HasSuffix(basePath, "/") {
+		basePath = basePath + "/"
+	}
+	if basePath == dm.base || containsString(dm.bases, basePath) {
+		panic(fmt.Sprintf("Mux is already mounted at '%s'", basePath))
+	}
+	dm.bases = append(dm.bases, basePath)
+	if dm.httpMux != nil {
+		dm.httpMux.Handle(basePath, dm)
+	}
+}
+
+// Returns all base paths of this mux, the canonical one first.
+func (dm *defaultMux) BasePaths() []string {
+	return append([]string{dm.base}, dm.bases...)
+}
+
+// Splits URL path p into the longest base path of this mux it starts with
+// and the rest, comparing case-insensitively if fold is set. Reports false
+// if p is outside of all base paths.
+func (dm *defaultMux) splitBase(p string, fold bool) (base, rel string, ok bool) {
+	hasPrefix := strings.HasPrefix
+	if fold {
+		hasPrefix = func(s, prefix string) bool {
+			return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+		}
+	}
+	if hasPrefix(p, dm.base) {
+		base, ok = dm.base, true
+	}
+	for _, b := range dm.bases {
+		if len(b) > len(base) && hasPrefix(p, b) {
+			base, ok = b, true
+		}
+	}
+	if !ok {
+		return "", "", false
+	}
+	return base, p[len(base):], true
+}
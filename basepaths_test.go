@@ -0,0 +1,33 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestAddBasePath(t *testing.T) {
+	hm := http.NewServeMux()
+	m := NewMux("/api", hm)
+	m.AddBasePath("v1")
+	m.AddBasePath("/api/legacy/")
+	m.Add("GET", "users/{id}", dummy).As("user")
+	m.Add("GET", "{a}/{b}/{c}", dummy)
+
+	assertEqual(t, serve(hm, "GET", "/api/users/1").Body.String(), "params:id=1")
+	assertEqual(t, serve(hm, "GET", "/v1/users/2").Body.String(), "params:id=2")
+	// The longest base path wins.
+	assertEqual(t, serve(hm, "GET", "/api/legacy/users/3").Body.String(), "params:id=3")
+	assertEqual(t, m.BuildPath("user", 4), "/api/users/4")
+	assertEqual(t, fmt.Sprint(m.BasePaths()), "[/api/ /v1/ /api/legacy/]")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("AddBasePath() of an existing base path didn't panic")
+		}
+	}()
+	m.AddBasePath("/v1/")
+}
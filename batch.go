@@ -0,0 +1,160 @@
+package muxer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Sub-request of a batch, see Mux.Batch().
+type BatchRequest struct {
+	// Echoed in the response to correlate it.
+	ID     string `json:"id,omitempty"`
+	Method string `json:"method"`
+	// Absolute URL path with an optional query, e.g. "/api/users/1?full=1".
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// Response to a BatchRequest.
+type BatchResponse struct {
+	ID      string            `json:"id,omitempty"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Response body: embedded as is if it's JSON, a JSON string otherwise.
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// Maximum size of a batch request body, larger ones get 413 Request Entity
+// Too Large.
+const maxBatchBytes = 1 << 20
+
+// Headers of a batch request passed on to its sub-requests unless they set
+// their own, so that they are authenticated the same way.
+var batchInheritedHeaders = []string{"Authorization", "Cookie", "Accept-Language"}
+
+// Headers sub-requests always get from the batch request, so that they
+// can't claim another client, scheme or host than a trusted proxy did.
+var batchForwardingHeaders = []string{"Forwarded", "X-Forwarded-For",
+	"X-Forwarded-Proto", "X-Forwarded-Host", "X-Forwarded-Prefix", "X-Real-Ip"}
+
+// Headers of sub-requests which are ignored: hop-by-hop ones and those
+// describing the connection or the body of the batch request.
+var batchIgnoredHeaders = []string{"Host", "Connection", "Keep-Alive",
+	"Proxy-Authenticate", "Proxy-Authorization", "Proxy-Connection", "Te",
+	"Trailer", "Transfer-Encoding", "Upgrade", "Content-Length"}
+
+// Adds a POST route serving batches of up to max sub-requests, sent as
+//
+//	{"requests": [{"id": "1", "method": "GET", "path": "/api/users/1"}, ...]}
+//
+// and answered with 200 OK and
+//
+//	{"responses": [{"id": "1", "status": 200, "body": {...}}, ...]}
+//
+// in the same order. Bodies larger than 1MiB are rejected. Each sub-request is served by this mux in memory,
+// one after another, going through the same middleware, hooks and
+// authorization as a regular request. Sub-requests inherit the context,
+// remote address, TLS state and Authorization, Cookie and Accept-Language
+// headers of the batch request. Batches can't be nested.
+func (dm *defaultMux) Batch(pattern string, max int) *Route {
+	var route *Route
+	route = dm.Add("POST", pattern, func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		var batch struct {
+			Requests []BatchRequest `json:"requests"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBytes)).Decode(&batch); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				Error(w, r, NewStatusError(http.StatusRequestEntityTooLarge,
+					fmt.Sprintf("Batch is larger than %d bytes", maxBatchBytes)))
+				return
+			}
+			Error(w, r, NewStatusError(http.StatusBadRequest, "Malformed batch: "+err.Error()))
+			return
+		}
+		if len(batch.Requests) > max {
+			Error(w, r, NewStatusError(http.StatusRequestEntityTooLarge,
+				fmt.Sprintf("Batch has more than %d requests", max)))
+			return
+		}
+		resps := make([]BatchResponse, len(batch.Requests))
+		for i, sub := range batch.Requests {
+			resps[i] = dm.serveBatched(r, route, sub)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			Responses []BatchResponse `json:"responses"`
+		}{resps})
+	})
+	return route
+}
+
+// Serves sub-request sub of batch request parent received by route batch.
+func (dm *defaultMux) serveBatched(parent *http.Request, batch *Route, sub BatchRequest) BatchResponse {
+	resp := BatchResponse{ID: sub.ID}
+	u, err := url.Parse(sub.Path)
+	if err != nil || !strings.HasPrefix(u.Path, "/") || u.Host != "" {
+		resp.Status = http.StatusBadRequest
+		return resp
+	}
+	if sub.Method == "" {
+		sub.Method = "GET"
+	}
+	req, err := http.NewRequestWithContext(parent.Context(), sub.Method, u.String(), bytes.NewReader(sub.Body))
+	if err != nil {
+		resp.Status = http.StatusBadRequest
+		return resp
+	}
+	req.Host = parent.Host
+	req.RemoteAddr = parent.RemoteAddr
+	req.TLS = parent.TLS
+	req.Proto, req.ProtoMajor, req.ProtoMinor = parent.Proto, parent.ProtoMajor, parent.ProtoMinor
+	for _, k := range batchInheritedHeaders {
+		if v := parent.Header.Values(k); len(v) > 0 {
+			req.Header[k] = v
+		}
+	}
+	for k, v := range sub.Headers {
+		k = http.CanonicalHeaderKey(k)
+		if !containsString(batchForwardingHeaders, k) && !containsString(batchIgnoredHeaders, k) {
+			req.Header.Set(k, v)
+		}
+	}
+	for _, k := range batchForwardingHeaders {
+		if v := parent.Header.Values(k); len(v) > 0 {
+			req.Header[k] = v
+		}
+	}
+	if len(sub.Body) > 0 && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if p, ok := dm.relPath(req); ok {
+		if r, _ := dm.match(req.Method, p, listenerOf(req)); r == batch {
+			resp.Status = http.StatusBadRequest
+			return resp
+		}
+	}
+
+	r := dm.serveInternal(req)
+	resp.Status = r.StatusCode
+	if len(r.Header) > 0 {
+		resp.Headers = make(map[string]string, len(r.Header))
+		for k := range r.Header {
+			resp.Headers[k] = r.Header.Get(k)
+		}
+	}
+	if len(r.Body) > 0 {
+		if json.Valid(r.Body) {
+			resp.Body = bytes.TrimSpace(r.Body)
+		} else {
+			resp.Body, _ = json.Marshal(string(r.Body))
+		}
+	}
+	return resp
+}
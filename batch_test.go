@@ -0,0 +1,94 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	m := NewMux("/api", http.NewServeMux())
+	m.Use(func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+			if r.Header.Get("Authorization") != "Bearer ok" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next(w, r, v)
+		}
+	})
+	m.Add("GET", "users/{id}", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"` + v.Get("id") + `","full":"` + r.URL.Query().Get("full") + `"}` + "\n"))
+	})
+	m.Add("POST", "echo", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		b, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(r.Header.Get("Content-Type") + " " + string(b)))
+	})
+	m.Batch("batch", 3)
+
+	batch := func(auth, body string) (int, string) {
+		req, _ := http.NewRequest("POST", "/api/batch", strings.NewReader(body))
+		req.Header.Set("Authorization", auth)
+		w := serveRequest(m, req)
+		return w.Code, strings.TrimSpace(w.Body.String())
+	}
+	code, body := batch("Bearer ok", `{"requests":[
+		{"id":"a","method":"GET","path":"/api/users/1?full=1"},
+		{"id":"b","method":"POST","path":"/api/echo","body":{"x":1}},
+		{"id":"c","path":"/api/users/2","headers":{"Authorization":"Bearer nope"}}
+	]}`)
+	assertEqual(t, body, `{"responses":[`+
+		`{"id":"a","status":200,"headers":{"Content-Type":"application/json"},"body":{"id":"1","full":"1"}},`+
+		`{"id":"b","status":201,"body":"application/json {\"x\":1}"},`+
+		`{"id":"c","status":401,"headers":{"Content-Type":"text/plain; charset=utf-8","X-Content-Type-Options":"nosniff"},"body":"unauthorized\n"}]}`)
+	if code != 200 {
+		t.Errorf("Expected 200 OK, got %d", code)
+	}
+
+	code, body = batch("Bearer ok", `{"requests":[
+		{"path":"/api/batch","method":"POST"},
+		{"path":"http://example.com/api/users/1"},
+		{"path":"/api/missing"}
+	]}`)
+	assertEqual(t, body, `{"responses":[{"status":400},{"status":400},`+
+		`{"status":404,"headers":{"Content-Type":"text/plain; charset=utf-8","X-Content-Type-Options":"nosniff"},"body":"404 page not found\n"}]}`)
+
+	code, _ = batch("Bearer ok", `{"requests":[{},{},{},{}]}`)
+	if code != 413 {
+		t.Errorf("Expected 413, got %d", code)
+	}
+	code, _ = batch("Bearer ok", `{"requests":[{"path":"/api/users/`+strings.Repeat("1", maxBatchBytes)+`"}]}`)
+	if code != 413 {
+		t.Errorf("Expected 413 for a large batch, got %d", code)
+	}
+	code, _ = batch("Bearer nope", `{"requests":[]}`)
+	if code != 401 {
+		t.Errorf("Expected the batch itself to be authorized, got %d", code)
+	}
+}
+
+func TestBatchForwardingHeaders(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.TrustProxies("10.0.0.0/8")
+	m.Add("GET", "whoami", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		dm := CurrentRoute(r).group.mux
+		w.Write([]byte(dm.ClientIP(r) + " " + dm.scheme(r) + " " + r.Header.Get("X-Forwarded-Host") + " " + r.Header.Get("Upgrade")))
+	})
+	m.Batch("batch", 3)
+
+	req, _ := http.NewRequest("POST", "/batch", strings.NewReader(`{"requests":[
+		{"path":"/whoami","headers":{"x-forwarded-for":"1.2.3.4","Forwarded":"for=1.2.3.4;proto=https",
+			"X-Forwarded-Proto":"https","X-Forwarded-Host":"evil.example.com","Upgrade":"websocket"}}
+	]}`))
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	w := serveRequest(m, req)
+	assertEqual(t, strings.TrimSpace(w.Body.String()), `{"responses":[{"status":200,"body":"203.0.113.7 http  "}]}`)
+}
@@ -0,0 +1,91 @@
+package muxer
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Canonical describes the canonical form of request URLs. Requests in any
+// other form are redirected before matching, see Mux.Canonicalize().
+type Canonical struct {
+	// Canonical host, e.g. "example.com" to redirect "www.example.com",
+	// or "www.example.com" to redirect "example.com". Other hosts are left
+	// as is. Zero string keeps any host.
+	Host string
+	// Redirect plaintext requests to https.
+	HTTPS bool
+	// Strip port from the host, e.g. "example.com:80".
+	StripPort bool
+	// What to do with hosts in other than lower case, e.g. "Example.com":
+	// CaseRedirect redirects to the lower case host, CaseRewrite changes
+	// Host of the request in place, CaseStrict leaves them as is.
+	HostCase CasePolicy
+	// Status code of redirects of GET and HEAD requests.
+	// Defaults to 301 Moved Permanently.
+	Code int
+	// Status code of redirects of requests with other methods.
+	// Defaults to 308 Permanent Redirect, which preserves the method.
+	OtherCode int
+}
+
+// Redirects requests which aren't in canonical form c before they are
+// matched, e.g. to serve example.com over https only:
+//
+//	m.Canonicalize(&muxer.Canonical{Host: "example.com", HTTPS: true, StripPort: true})
+//
+// A nil c disables redirects. PreRoute hooks run before, so they can
+// e.g. mark requests forwarded by a TLS terminating proxy.
+func (dm *defaultMux) Canonicalize(c *Canonical) {
+	dm.canonical = c
+}
+
+// Redirects req to dm if it isn't in canonical form as seen by the client
+// through trusted proxies. Reports whether it has.
+func (c *Canonical) redirect(w http.ResponseWriter, req *http.Request, dm *defaultMux) bool {
+	scheme := dm.scheme(req)
+	reqHost := dm.host(req)
+	host, port, err := net.SplitHostPort(reqHost)
+	if err != nil {
+		host, port = reqHost, ""
+	}
+	changed := false
+	if lower := strings.ToLower(host); c.HostCase != CaseStrict && lower != host {
+		if c.HostCase == CaseRedirect {
+			changed = true
+		} else {
+			req.Host = strings.ToLower(req.Host)
+		}
+		host = lower
+	}
+	if c.HTTPS && scheme == "http" {
+		scheme, changed = "https", true
+		// Plaintext port is wrong for https anyway.
+		port = ""
+	}
+	if c.StripPort && port != "" {
+		port, changed = "", true
+	}
+	if c.Host != "" && !strings.EqualFold(host, c.Host) &&
+		(strings.EqualFold("www."+host, c.Host) || strings.EqualFold(host, "www."+c.Host)) {
+		host, changed = c.Host, true
+	}
+	if !changed {
+		return false
+	}
+	if port != "" {
+		host = net.JoinHostPort(host, port)
+	}
+	code := c.Code
+	if code == 0 {
+		code = http.StatusMovedPermanently
+	}
+	if req.Method != "GET" && req.Method != "HEAD" {
+		code = c.OtherCode
+		if code == 0 {
+			code = http.StatusPermanentRedirect
+		}
+	}
+	http.Redirect(w, req, scheme+"://"+host+dm.externalPrefix(req)+req.URL.RequestURI(), code)
+	return true
+}
@@ -0,0 +1,50 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "docs/{id}", dummy)
+	m.Add("POST", "docs", dummy)
+	m.Canonicalize(&Canonical{Host: "example.com", HTTPS: true, StripPort: true})
+
+	tests := []struct {
+		method, url string
+		tls         bool
+		code        int
+		location    string
+	}{
+		{"GET", "http://www.example.com/docs/1?v=2", false, 301, "https://example.com/docs/1?v=2"},
+		{"GET", "https://www.example.com:8443/docs/1", true, 301, "https://example.com/docs/1"},
+		{"GET", "https://example.com:443/docs/1", true, 301, "https://example.com/docs/1"},
+		{"POST", "http://example.com/docs", false, 308, "https://example.com/docs"},
+		{"GET", "http://internal.local/nope", false, 301, "https://internal.local/nope"},
+		{"GET", "https://example.com/docs/1", true, 200, ""},
+		{"GET", "https://internal.local/docs/1", true, 200, ""},
+	}
+	for i, test := range tests {
+		req, _ := http.NewRequest(test.method, test.url, nil)
+		if test.tls {
+			req.TLS = &tls.ConnectionState{}
+		}
+		w := serveRequest(m, req)
+		if w.Code != test.code {
+			t.Errorf("%d: Expected %d, got %d", i, test.code, w.Code)
+		}
+		assertEqual(t, w.Header().Get("Location"), test.location)
+	}
+
+	m.Canonicalize(&Canonical{Host: "www.example.com", Code: http.StatusFound})
+	w := serve(m, "GET", "http://example.com:8080/docs/1")
+	if w.Code != 302 {
+		t.Errorf("Expected 302 Found, got %d", w.Code)
+	}
+	assertEqual(t, w.Header().Get("Location"), "http://www.example.com:8080/docs/1")
+}
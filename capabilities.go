@@ -0,0 +1,65 @@
+package muxer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// Route as described to a client by CapabilitiesHandler().
+type Capability struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Name   string `json:"name,omitempty"`
+	// Scopes required, see Route.RequireScope(), and policy, see
+	// Route.Policy().
+	Scopes []string `json:"scopes,omitempty"`
+	Policy string   `json:"policy,omitempty"`
+	// Reports whether the principal of the request has all scopes and is
+	// allowed by the policy regardless of path params.
+	Allowed bool `json:"allowed"`
+}
+
+// Returns a handler responding with the enabled routes of m served on the
+// listener of the request, their auth requirements and whether the
+// principal of the request meets them, as JSON. Clients discover what
+// they can do, e.g. with OPTIONS on the base path of the mux:
+//
+//	m.Add("OPTIONS", "", muxer.CapabilitiesHandler(m)).Use(authenticate)
+//
+// The principal is the one authentication middleware of the route has put
+// into the request context, see PrincipalFrom(). Policies are checked by
+// the mux Authorizer with no params, so ones depending on them may be
+// reported as not allowed.
+func CapabilitiesHandler(m Mux) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		dm, _ := m.(*defaultMux)
+		p := PrincipalFrom(r.Context())
+		listener := listenerOf(r)
+		caps := []Capability{}
+		for _, route := range m.Routes() {
+			if route.Disabled() || route.listener() != listener {
+				continue
+			}
+			c := Capability{
+				Method:  route.Method,
+				Path:    m.BasePath() + route.Pattern,
+				Name:    route.Name,
+				Scopes:  route.scopes,
+				Policy:  route.policy,
+				Allowed: len(MissingScopes(route, p)) == 0,
+			}
+			if c.Allowed && c.Policy != "" {
+				c.Allowed = dm != nil && dm.authorizer != nil &&
+					dm.authorizer.Authorize(r.Context(), c.Policy, url.Values{}) == nil
+			}
+			caps = append(caps, c)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "private, no-store")
+		w.Header().Add("Vary", "Authorization")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(caps)
+	}
+}
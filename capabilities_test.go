@@ -0,0 +1,54 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestCapabilitiesHandler(t *testing.T) {
+	m := NewMux("/api/", http.NewServeMux())
+	m.SetAuthorizer(AuthorizerFunc(func(ctx context.Context, policy string, v url.Values) error {
+		if policy == "orders:admin" {
+			return errors.New("denied")
+		}
+		return nil
+	}))
+	authenticate := func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+			p := &Principal{Subject: "u", Scopes: []string{"read"}}
+			next(w, r.WithContext(WithPrincipal(r.Context(), p)), v)
+		}
+	}
+	m.Add("OPTIONS", "", CapabilitiesHandler(m)).Use(authenticate)
+	m.Add("GET", "orders", dummy).RequireScope("read").As("orders")
+	m.Add("POST", "orders", dummy).RequireScope("read", "write")
+	m.Add("DELETE", "orders/{id}", dummy).Policy("orders:admin")
+	m.Add("GET", "orders/{id}", dummy).Policy("orders:read")
+	m.Add("GET", "old", dummy).Disable()
+
+	var caps []Capability
+	if err := json.Unmarshal(serve(m, "OPTIONS", "/api/").Body.Bytes(), &caps); err != nil {
+		t.Fatal(err)
+	}
+	got := ""
+	for _, c := range caps {
+		got += c.Method + " " + c.Path
+		if c.Allowed {
+			got += " allowed"
+		}
+		got += "\n"
+	}
+	assertEqual(t, got, `OPTIONS /api/ allowed
+GET /api/orders allowed
+POST /api/orders
+DELETE /api/orders/{id}
+GET /api/orders/{id} allowed
+`)
+}
@@ -0,0 +1,185 @@
+package muxer
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Options of request body capture, see Route.CaptureBodies().
+type CaptureOptions struct {
+	// Number of leading bytes of a body kept. Defaults to 4KiB.
+	MaxBytes int
+	// Media types of bodies to capture, e.g. "application/json".
+	// Bodies of any type are captured if empty.
+	ContentTypes []string
+	// Returns body with sensitive data removed, if not nil. Called before
+	// a capture is stored.
+	Redact func(contentType string, body []byte) []byte
+	// Number of latest captures kept. Defaults to 16.
+	Keep int
+}
+
+// Leading part of a request body read by a route handler.
+type Capture struct {
+	Time        time.Time `json:"time"`
+	URI         string    `json:"uri"`
+	ContentType string    `json:"contentType,omitempty"`
+	Body        []byte    `json:"body"`
+	// Reports whether the handler read more than MaxBytes.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Keeps the first bytes of request bodies read by the handler of this
+// route, see Captures(), e.g. to debug malformed payloads of a client:
+//
+//	m.Add("POST", "orders", createOrder).CaptureBodies(muxer.CaptureOptions{
+//		ContentTypes: []string{"application/json"},
+//		Redact:       removeCardNumbers,
+//	})
+//
+// Only bytes the handler reads are kept, so streaming isn't affected.
+// Sensitive params of the mux redaction policy, see Mux.Redact(), are
+// replaced in URIs and complete JSON bodies before Redact is called.
+// Captures of sampled requests are also passed in Sample.Body, see
+// Sample(), and all of them are served by CapturesHandler().
+func (r *Route) CaptureBodies(opts CaptureOptions) *Route {
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = 4 << 10
+	}
+	if opts.Keep <= 0 {
+		opts.Keep = 16
+	}
+	r.capture = &bodyCapture{opts: opts}
+	return r
+}
+
+// Returns latest captured request bodies of this route, oldest first.
+func (r *Route) Captures() []Capture {
+	if r.capture == nil {
+		return nil
+	}
+	c := r.capture
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	captures := make([]Capture, 0, len(c.ring))
+	captures = append(captures, c.ring[c.next:]...)
+	return append(captures, c.ring[:c.next]...)
+}
+
+type bodyCapture struct {
+	opts CaptureOptions
+
+	mu sync.Mutex
+	// Latest captures, the oldest at next once full.
+	ring []Capture
+	next int
+}
+
+// Returns a capture with the same options and no captures.
+func (c *bodyCapture) clone() *bodyCapture {
+	if c == nil {
+		return nil
+	}
+	return &bodyCapture{opts: c.opts}
+}
+
+// Reports whether bodies of media type ct are captured.
+func (c *bodyCapture) captures(ct string) bool {
+	if len(c.opts.ContentTypes) == 0 {
+		return true
+	}
+	mt, _, _ := mime.ParseMediaType(ct)
+	return containsString(c.opts.ContentTypes, mt)
+}
+
+func (c *bodyCapture) store(capture Capture) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.ring) < c.opts.Keep {
+		c.ring = append(c.ring, capture)
+		return
+	}
+	c.ring[c.next] = capture
+	c.next = (c.next + 1) % len(c.ring)
+}
+
+// Returns h capturing request bodies.
+func (c *bodyCapture) wrap(route *Route, h HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		ct := r.Header.Get("Content-Type")
+		if r.Body == nil || r.Body == http.NoBody || !c.captures(ct) {
+			h(w, r, v)
+			return
+		}
+		tee := &teeBody{ReadCloser: r.Body, max: c.opts.MaxBytes}
+		r.Body = tee
+		capture := Capture{Time: time.Now(), URI: route.RedactedURI(r), ContentType: ct}
+		defer func() {
+			capture.Body, capture.Truncated = tee.buf, tee.truncated
+			if rd := route.group.mux.redaction; rd != nil {
+				capture.Body = sanitizeBody(ct, capture.Body, rd.SensitiveParam, true)
+			}
+			if c.opts.Redact != nil {
+				capture.Body = c.opts.Redact(ct, capture.Body)
+			}
+			c.store(capture)
+			if rec, ok := r.Context().Value(sampleKey{}).(*sampleRecorder); ok {
+				rec.mu.Lock()
+				rec.sample.Body = &capture
+				rec.mu.Unlock()
+			}
+		}()
+		h(w, r, v)
+	}
+}
+
+// Request body keeping up to max bytes read.
+type teeBody struct {
+	io.ReadCloser
+	max       int
+	buf       []byte
+	truncated bool
+}
+
+func (b *teeBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := b.max - len(b.buf); room < n {
+		b.buf = append(b.buf, p[:max(room, 0)]...)
+		b.truncated = true
+	} else {
+		b.buf = append(b.buf, p[:n]...)
+	}
+	return n, err
+}
+
+// Captures of a route, see CapturesHandler().
+type routeCaptures struct {
+	Method   string    `json:"method"`
+	Pattern  string    `json:"pattern"`
+	Name     string    `json:"name,omitempty"`
+	Captures []Capture `json:"captures"`
+}
+
+// Returns a handler responding with captured request bodies of all
+// routes of m capturing them as JSON, see Route.CaptureBodies().
+// Bodies are base64-encoded.
+func CapturesHandler(m Mux) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		all := []routeCaptures{}
+		for _, route := range m.Routes() {
+			if route.capture != nil {
+				all = append(all, routeCaptures{route.Method, route.Pattern, route.Name, route.Captures()})
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(all)
+	}
+}
@@ -0,0 +1,90 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCaptureBodies(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	var sampled *Sample
+	r := m.Add("POST", "orders", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		io.Copy(io.Discard, r.Body)
+	}).CaptureBodies(CaptureOptions{
+		MaxBytes:     14,
+		ContentTypes: []string{"application/json"},
+		Keep:         2,
+		Redact: func(ct string, b []byte) []byte {
+			return bytes.ReplaceAll(b, []byte("4111"), []byte("****"))
+		},
+	}).Sample(1, func(s *Sample) { sampled = s })
+
+	post := func(ct, body string) {
+		req, _ := http.NewRequest("POST", "/orders?x=1", strings.NewReader(body))
+		req.Header.Set("Content-Type", ct)
+		serveRequest(m, req)
+	}
+	post("application/json; charset=utf-8", `{"a":1}`)
+	post("text/plain", "skipped")
+	post("application/json", `{"card":"4111 1111"}`)
+	post("application/json", `[]`)
+
+	captures := r.Captures()
+	if len(captures) != 2 {
+		t.Fatalf("got %d captures, want 2", len(captures))
+	}
+	c := captures[0]
+	assertEqual(t, string(c.Body), `{"card":"**** `)
+	assertEqual(t, c.URI, "/orders?x=1")
+	if !c.Truncated || captures[1].Truncated {
+		t.Errorf("Truncated = %v, %v", c.Truncated, captures[1].Truncated)
+	}
+	if sampled == nil || sampled.Body == nil || string(sampled.Body.Body) != "[]" {
+		t.Errorf("sampled = %+v", sampled)
+	}
+
+	m.Add("GET", "debug/captures", CapturesHandler(m))
+	var got []struct {
+		Pattern  string
+		Captures []Capture
+	}
+	if err := json.Unmarshal(serve(m, "GET", "/debug/captures").Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Pattern != "orders" || len(got[0].Captures) != 2 {
+		t.Errorf("CapturesHandler() = %+v", got)
+	}
+}
+
+func TestCaptureBodiesRedaction(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Redact(Redaction{Params: []string{"password"}})
+	r := m.Add("POST", "login", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		io.Copy(io.Discard, r.Body)
+	}).CaptureBodies(CaptureOptions{MaxBytes: 24, Keep: 3})
+
+	post := func(ct, body string) {
+		req, _ := http.NewRequest("POST", "/login", strings.NewReader(body))
+		req.Header.Set("Content-Type", ct)
+		serveRequest(m, req)
+	}
+	post("application/json", `{"user":"a","password":"hunter2"}`)
+	post("application/x-www-form-urlencoded", "password=hunter2&user=a")
+	post("application/json", `{"user":"a","password":"x"}`[:20])
+
+	captures := r.Captures()
+	if len(captures) != 3 {
+		t.Fatalf("got %d captures, want 3", len(captures))
+	}
+	assertEqual(t, string(captures[0].Body), "REDACTED (24 bytes not parsed)")
+	assertEqual(t, string(captures[1].Body), "password=REDACTED&user=a")
+	assertEqual(t, string(captures[2].Body), "REDACTED (20 bytes not parsed)")
+}
@@ -0,0 +1,169 @@
+package muxer
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// CasePolicy decides what happens to requests whose path matches a route
+// only when compared case-insensitively, e.g. "/Users/42" for "users/{id}".
+type CasePolicy int
+
+const (
+	// CaseStrict treats them as not matching, e.g. 404 for an API.
+	// This is the default.
+	CaseStrict CasePolicy = iota
+	// CaseRedirect redirects them to the path in the pattern's case,
+	// e.g. for public pages linked with any casing.
+	CaseRedirect
+	// CaseRewrite serves them as if the path was in the pattern's case.
+	CaseRewrite
+)
+
+func (p CasePolicy) String() string {
+	switch p {
+	case CaseStrict:
+		return "strict"
+	case CaseRedirect:
+		return "redirect"
+	case CaseRewrite:
+		return "rewrite"
+	}
+	return fmt.Sprintf("CasePolicy(%d)", int(p))
+}
+
+// Sets the case policy of all routes of this mux, see Group.CasePolicy().
+func (dm *defaultMux) CasePolicy(p CasePolicy) {
+	dm.root.CasePolicy(p)
+}
+
+// Sets how routes of this group and its nested groups treat requests
+// matching only case-insensitively, unless a nested group or a route sets
+// its own:
+//
+//	m.CasePolicy(muxer.CaseRedirect)
+//	m.Group("api").CasePolicy(muxer.CaseStrict)
+//
+// Literal segments of the pattern are compared case-insensitively, params
+// keep the request's case. Such a match is looked for only after no route
+// matches exactly. Redirects are 301 Moved Permanently for GET and HEAD
+// requests and 308 Permanent Redirect for others.
+func (g *Group) CasePolicy(p CasePolicy) *Group {
+	g.casePolicy = &p
+	if p != CaseStrict {
+		g.mux.foldCase = true
+	}
+	return g
+}
+
+// Sets how this route treats requests matching only case-insensitively,
+// overriding the policy of its group, see Group.CasePolicy():
+//
+//	m.Add("GET", "users/{id}", profile).CasePolicy(muxer.CaseRedirect)
+func (r *Route) CasePolicy(p CasePolicy) *Route {
+	r.casePolicy = &p
+	if p != CaseStrict {
+		r.group.mux.foldCase = true
+	}
+	return r
+}
+
+// Returns the case policy of this route, inherited from its group.
+func (r *Route) casePolicyOf() CasePolicy {
+	if r.casePolicy != nil {
+		return *r.casePolicy
+	}
+	return r.group.casePolicyOf()
+}
+
+// Returns the case policy of this group, inherited from its parents.
+func (g *Group) casePolicyOf() CasePolicy {
+	for ; g != nil; g = g.parent {
+		if g.casePolicy != nil {
+			return *g.casePolicy
+		}
+	}
+	return CaseStrict
+}
+
+// Serves req if it matches a route case-insensitively and the route's case
+// policy allows it. Reports whether it has.
+func (dm *defaultMux) serveFolded(w http.ResponseWriter, req *http.Request) bool {
+	base, rel, ok := dm.splitBase(req.URL.Path, true)
+	if !ok {
+		return false
+	}
+	r, canon := dm.matchFold(req.Method, rel, listenerOf(req))
+	if r == nil && req.Method == "HEAD" {
+		if r, canon = dm.matchFold("GET", rel, listenerOf(req)); r != nil && !r.servesHead() {
+			r = nil
+		}
+	}
+	if r == nil {
+		return false
+	}
+	u := *req.URL
+	u.Path, u.RawPath = base+canon, ""
+	if r.casePolicyOf() == CaseRedirect {
+		code := http.StatusMovedPermanently
+		if req.Method != "GET" && req.Method != "HEAD" {
+			code = http.StatusPermanentRedirect
+		}
+		location := dm.externalPrefix(req) + u.RequestURI()
+		if offsite(location) {
+			return false
+		}
+		http.Redirect(w, req, location, code)
+		return true
+	}
+	rewritten := *req
+	rewritten.URL = &u
+	return dm.serveRoute(w, &rewritten)
+}
+
+// Returns the first route matching path case-insensitively whose policy
+// isn't CaseStrict, and the path in the route's case.
+func (dm *defaultMux) matchFold(method, path, listener string) (*Route, string) {
+	n := countSegments(path)
+	for _, r := range dm.snapshot() {
+		if r.Method != method || !r.fits(n) || r.Disabled() || r.listener() != listener ||
+			r.casePolicyOf() == CaseStrict {
+			continue
+		}
+		if canon, ok := r.foldPath(path); ok {
+			return r, canon
+		}
+	}
+	return nil, ""
+}
+
+// Returns path with literal segments in the case of this route's pattern.
+// Reports false unless path matches the route case-insensitively.
+func (r *Route) foldPath(path string) (string, bool) {
+	var b strings.Builder
+	b.Grow(len(path))
+	segs := NewSegments(path)
+	for i, rp := range r.parts {
+		if i > 0 {
+			b.WriteByte('/')
+		}
+		if rp.rest {
+			b.WriteString(segs.rest())
+			break
+		}
+		seg, _ := segs.Next()
+		if rp.isVar {
+			if !rp.matches(seg) {
+				return "", false
+			}
+			b.WriteString(seg)
+			continue
+		}
+		if !strings.EqualFold(rp.name, seg) {
+			return "", false
+		}
+		b.WriteString(rp.name)
+	}
+	return b.String(), true
+}
@@ -0,0 +1,97 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestCasePolicy(t *testing.T) {
+	m := NewMux("/base", http.NewServeMux())
+	m.Add("GET", "Pages/{slug}", dummy)
+	api := m.Group("api")
+	api.Add("GET", "users/{id}", dummy)
+	api.Group("legacy").CasePolicy(CaseRewrite).Add("POST", "Orders/{id}", dummy)
+	m.CasePolicy(CaseRedirect)
+	api.CasePolicy(CaseStrict)
+
+	w := serve(m, "GET", "/BASE/pages/About?x=1")
+	if w.Code != 301 {
+		t.Fatalf("redirect: code = %d, want 301", w.Code)
+	}
+	assertEqual(t, w.Header().Get("Location"), "/base/Pages/About?x=1")
+
+	if w := serve(m, "GET", "/base/API/Users/1"); w.Code != 404 {
+		t.Errorf("strict: code = %d, want 404", w.Code)
+	}
+
+	w = serve(m, "POST", "/base/api/legacy/orders/X1")
+	if w.Code != 200 {
+		t.Fatalf("rewrite: code = %d, want 200", w.Code)
+	}
+	assertEqual(t, w.Body.String(), "params:id=X1")
+
+	if w := serve(m, "GET", "/base/Pages/About"); w.Code != 200 {
+		t.Errorf("exact: code = %d, want 200", w.Code)
+	}
+	if w := serve(m.Clone(), "GET", "/base/PAGES/a"); w.Code != 301 {
+		t.Errorf("clone: code = %d, want 301", w.Code)
+	}
+}
+
+func TestCanonicalHostCase(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	var host string
+	m.Add("GET", "a", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		host = r.Host
+	})
+	c := &Canonical{HostCase: CaseRedirect}
+	m.Canonicalize(c)
+	req := httptest.NewRequest("GET", "http://Example.COM/a", nil)
+	w := serveRequest(m, req)
+	if w.Code != 301 {
+		t.Fatalf("code = %d, want 301", w.Code)
+	}
+	assertEqual(t, w.Header().Get("Location"), "http://example.com/a")
+
+	c.HostCase = CaseRewrite
+	if w := serveRequest(m, httptest.NewRequest("GET", "http://Example.COM/a", nil)); w.Code != 200 {
+		t.Fatalf("rewrite: code = %d, want 200", w.Code)
+	}
+	assertEqual(t, host, "example.com")
+}
+
+func TestRouteCasePolicy(t *testing.T) {
+	m := NewMux("/api", http.NewServeMux())
+	m.Add("GET", "users/{id}", dummy).CasePolicy(CaseRedirect)
+	m.Add("GET", "orders/{id}", dummy)
+	legacy := m.Group("legacy").CasePolicy(CaseRewrite)
+	legacy.Add("GET", "items/{id}", dummy).CasePolicy(CaseStrict)
+
+	w := serve(m, "GET", "/API/Users/Bob")
+	if w.Code != 301 {
+		t.Fatalf("redirect: code = %d, want 301", w.Code)
+	}
+	assertEqual(t, w.Header().Get("Location"), "/api/users/Bob")
+	if w := serve(m, "HEAD", "/API/Users/Bob"); w.Code != 301 {
+		t.Errorf("redirect of HEAD: code = %d, want 301", w.Code)
+	}
+	if w := serve(m, "GET", "/api/Orders/1"); w.Code != 404 {
+		t.Errorf("strict: code = %d, want 404", w.Code)
+	}
+	if w := serve(m, "GET", "/api/legacy/Items/1"); w.Code != 404 {
+		t.Errorf("strict route in a rewrite group: code = %d, want 404", w.Code)
+	}
+
+	m = NewMux("/", http.NewServeMux())
+	m.Add("GET", "{a}/evil.com", dummy).CasePolicy(CaseRedirect)
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.URL = &url.URL{Path: "//EVIL.COM"}
+	if w := serveRequest(m, req); w.Header().Get("Location") != "" {
+		t.Errorf("redirect to another host: %d, %q", w.Code, w.Header().Get("Location"))
+	}
+}
@@ -0,0 +1,54 @@
+package muxer
+
+import "net/http"
+
+// Adds next to the list of muxers tried in order when a request doesn't
+// match any route of this mux. This allows independently built muxers,
+// e.g. contributed by plugins, to serve requests without merging their
+// route tables. The chained muxers don't need to be hooked up with
+// an http.ServeMux.
+//
+// Mux implementations other than the one returned by NewMux can't tell
+// whether they have a matching route, so they are always handed
+// the request and the rest of the chain is never tried.
+// Returns this mux so that calls can be chained.
+func (dm *defaultMux) Chain(next Mux) Mux {
+	if next == Mux(dm) || chains(next, dm) {
+		panic("Chaining muxers would create a cycle")
+	}
+	dm.chain = append(dm.chain, next)
+	return dm
+}
+
+// Serves the request with this mux or one of the chained muxers.
+// Reports whether the request has been served.
+func (dm *defaultMux) tryServe(w http.ResponseWriter, req *http.Request) bool {
+	if dm.serveRoute(w, req) {
+		return true
+	}
+	for _, next := range dm.chain {
+		if nextDM, ok := next.(*defaultMux); ok {
+			if nextDM.tryServe(w, req) {
+				return true
+			}
+			continue
+		}
+		next.ServeHTTP(w, req)
+		return true
+	}
+	return false
+}
+
+// Reports whether target is reachable through the chain of m.
+func chains(m Mux, target *defaultMux) bool {
+	dm, ok := m.(*defaultMux)
+	if !ok {
+		return false
+	}
+	for _, next := range dm.chain {
+		if next == Mux(target) || chains(next, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	reply := func(s string) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+			fmt.Fprint(w, s)
+		}
+	}
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "users/{id}", reply("main"))
+	plugin1 := NewMux("/", http.NewServeMux())
+	plugin1.Add("GET", "users/{id}", reply("plugin1"))
+	plugin1.Add("GET", "blog/{id}", reply("plugin1"))
+	plugin2 := NewMux("/files", http.NewServeMux())
+	plugin2.Add("GET", "", reply("plugin2 root"))
+	plugin2.Add("GET", "{name}", reply("plugin2"))
+	m.Chain(plugin1).Chain(plugin2)
+
+	tests := []struct{ path, body string }{
+		{"/users/1", "main"},
+		{"/blog/1", "plugin1"},
+		{"/files/a.txt", "plugin2"},
+		{"/files/", "plugin2 root"},
+		{"/files", "404 page not found\n"},
+	}
+	for _, test := range tests {
+		assertEqual(t, serve(m, "GET", test.path).Body.String(), test.body)
+	}
+
+	defer func() {
+		if err := recover(); err == nil {
+			t.Fatalf("Expected panic, got no error instead")
+		}
+	}()
+	// should panic because of the cycle m -> plugin1 -> m
+	plugin1.Chain(m)
+}
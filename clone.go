@@ -0,0 +1,127 @@
+package muxer
+
+import (
+	"net/http"
+	"reflect"
+)
+
+// Returns an independent copy of this mux: routes and groups can be added,
+// changed or disabled on either one without affecting the other. Handlers
+// and middleware are shared.
+//
+// The copy isn't hooked up with any http.ServeMux. Use it as an http.Handler
+// directly, e.g. in tests or as a per-request variant of the original mux.
+func (dm *defaultMux) Clone() Mux {
+	routes := dm.snapshot()
+	c := &defaultMux{
+		base:       dm.base,
+		baseLen:    dm.baseLen,
+		routes:     make([]*Route, 0, len(routes)),
+		strictness: dm.strictness,
+		warn:       dm.warn,
+		authorizer: dm.authorizer,
+		cors:       dm.cors,
+		canonical:  dm.canonical,
+		aliases:    dm.aliases,
+		trackStats: dm.trackStats,
+		groups:     make([]*Group, 0, len(dm.groups)),
+		chain:      append([]Mux(nil), dm.chain...),
+		registrars: append([]Registrar(nil), dm.registrars...),
+		preRoute:   append([]func(*http.Request) *http.Request(nil), dm.preRoute...),
+		postMatch:  append([]PostMatchFunc(nil), dm.postMatch...),
+	}
+	c.maxPathLen, c.maxSegments = dm.maxPathLen, dm.maxSegments
+	c.hardened, c.onReject = dm.hardened, dm.onReject
+	c.guardHeaders, c.foldCase = dm.guardHeaders, dm.foldCase
+	c.proxies = dm.proxies
+	c.isolation = dm.isolation
+	c.env = dm.env
+	c.examples = dm.examples
+	c.redaction = dm.redaction
+	c.bases = append([]string(nil), dm.bases...)
+	c.prefix = dm.prefix
+	c.invalidParam = dm.invalidParam
+	c.autoOptions = dm.autoOptions
+	c.nameCollisions = dm.nameCollisions
+	c.trackCoverage = dm.trackCoverage
+	c.slashRedirect = dm.slashRedirect
+	c.transforms = append([]transform(nil), dm.transforms...)
+	c.validators = append([]ValidatorFunc(nil), dm.validators...)
+	if dm.namedMiddleware != nil {
+		c.namedMiddleware = make(map[string]Middleware, len(dm.namedMiddleware))
+		for name, mw := range dm.namedMiddleware {
+			c.namedMiddleware[name] = mw
+		}
+	}
+	groups := make(map[*Group]*Group, len(dm.groups))
+	for _, g := range dm.groups {
+		cg := *g
+		cg.mux = c
+		cg.middleware = append([]namedMiddleware(nil), g.middleware...)
+		cg.prepend = append([]namedMiddleware(nil), g.prepend...)
+		cg.labels = copyLabels(g.labels)
+		groups[g] = &cg
+		c.groups = append(c.groups, &cg)
+	}
+	for _, cg := range c.groups {
+		cg.parent = groups[cg.parent]
+	}
+	c.root = groups[dm.root]
+	for _, r := range routes {
+		cr := *r
+		cr.mux = c
+		cr.group = groups[r.group]
+		cr.Tags = append([]string(nil), r.Tags...)
+		cr.headers = r.headers.Clone()
+		cr.middleware = append([]namedMiddleware(nil), r.middleware...)
+		cr.skip = append([]string(nil), r.skip...)
+		cr.docs = append([]RouteDoc(nil), r.docs...)
+		cr.labels = copyLabels(r.labels)
+		cr.capture = r.capture.clone()
+		if r.responses != nil {
+			cr.responses = make(map[int]reflect.Type, len(r.responses))
+			for code, t := range r.responses {
+				cr.responses[code] = t
+			}
+		}
+		cr.scopes = append([]string(nil), r.scopes...)
+		cr.corsHeaders = append([]string(nil), r.corsHeaders...)
+		cr.schemes = append([]string(nil), r.schemes...)
+		cr.live = new(routeLive)
+		cr.live.disabled.Store(r.live.disabled.Load())
+		cr.live.config.Store(r.live.config.Load())
+		if r.stats != nil {
+			cr.stats = &routeStats{}
+		}
+		c.routes = append(c.routes, &cr)
+	}
+	if dm.cache != nil {
+		c.MatchCache(dm.cache.size)
+	}
+	c.publish()
+	return c
+}
+
+// Disables this route: requests are served as if it didn't exist.
+// Requests already being served complete, see Drained().
+// The route can still be used to build paths with BuildPath().
+func (r *Route) Disable() *Route {
+	r.live.disabled.Store(true)
+	r.group.mux.routesChanged()
+	r.live.checkDrained()
+	r.group.mux.events.emit(RouteDisabled, r, nil)
+	return r
+}
+
+// Enables a route previously disabled with Disable().
+func (r *Route) Enable() *Route {
+	r.live.disabled.Store(false)
+	r.group.mux.routesChanged()
+	r.group.mux.events.emit(RouteEnabled, r, nil)
+	return r
+}
+
+// Reports whether this route has been disabled.
+func (r *Route) Disabled() bool {
+	return r.live.disabled.Load()
+}
@@ -0,0 +1,39 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	m := NewMux("/api", http.NewServeMux())
+	m.Group("users").Use(tagMiddleware("u")).Add("GET", "{id}", dummy).As("profile")
+	m.Add("GET", "products", dummy)
+
+	c := m.Clone()
+	c.Routes()[0].Disable()
+	c.Group("users").Add("PUT", "{id}", dummy)
+	if len(m.Routes()) != 2 || len(c.Routes()) != 3 {
+		t.Fatalf("Expected 2 and 3 routes, got %d and %d",
+			len(m.Routes()), len(c.Routes()))
+	}
+	if m.Routes()[0].Disabled() {
+		t.Fatalf("Expected original route to stay enabled")
+	}
+	if c.Routes()[0].group == m.Routes()[0].group {
+		t.Fatalf("Expected cloned route to have its own group")
+	}
+
+	if w := serve(c, "GET", "/api/users/1"); w.Code != 405 || w.Header().Get("Allow") != "PUT" {
+		t.Fatalf("Expected 405 allowing PUT, got %d, %q", w.Code, w.Header().Get("Allow"))
+	}
+	assertEqual(t, serve(m, "GET", "/api/users/1").Body.String(), "u(params:id=1)")
+	assertEqual(t, serve(c, "PUT", "/api/users/1").Body.String(), "params:id=1")
+	assertEqual(t, c.BuildPath("profile", 2), "/api/users/2")
+
+	c.Routes()[0].Enable()
+	assertEqual(t, serve(c, "GET", "/api/users/1").Body.String(), "u(params:id=1)")
+}
@@ -0,0 +1,284 @@
+package muxer
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Cluster is a ConfigStore shared by replicas of a service, which
+// exchange their configs with each other over HTTP, so that a change
+// made on one of them, e.g. disabling a route from an admin endpoint,
+// reaches all others within Interval:
+//
+//	cluster := muxer.NewCluster("10.0.0.1:8080", "http://10.0.0.2:8080/_routes", "http://10.0.0.3:8080/_routes")
+//	cluster.Token = os.Getenv("ROUTES_TOKEN")
+//	http.Handle("/_routes", cluster)
+//	go cluster.Run(ctx)
+//	err := m.UseConfigStore(ctx, cluster)
+//
+// Each config has a version, one more than the highest one the replica
+// saving it has seen for the route. Replicas keep the config with the
+// highest version, and of the replica with the greatest name if two of
+// them saved different configs concurrently, so they all end up with the
+// same one. Configs live in memory only, a restarted replica gets them
+// from its peers. Configs with versions more than maxVersionGap above the
+// highest one a replica has are ignored, so that a peer can't claim a
+// route forever.
+//
+// Replicas neither accept nor send configs unless Token is set.
+type Cluster struct {
+	// Unique name of this replica, e.g. its address.
+	Replica string
+	// URLs of the handlers of other replicas.
+	Peers []string
+	// How often configs are exchanged with every peer. Defaults to
+	// 5 seconds. Saved configs are sent to peers right away too.
+	Interval time.Duration
+	// Peers must send it as a bearer token and it's sent to them.
+	// Required.
+	Token string
+	// Used to reach peers. Defaults to a client with Interval as timeout.
+	Client *http.Client
+
+	mu       sync.Mutex
+	configs  map[string]VersionedConfig
+	watchers map[*func(map[string]RouteConfig)]struct{}
+}
+
+// How far above the highest version a replica has incoming ones may be.
+const maxVersionGap = 1 << 20
+
+var errNoClusterToken = errors.New("muxer: Cluster.Token not set")
+
+// A RouteConfig with its version, as exchanged by a Cluster.
+type VersionedConfig struct {
+	Config  RouteConfig `json:"config"`
+	Version uint64      `json:"version"`
+	// Replica which saved the config.
+	Replica string `json:"replica"`
+}
+
+// Reports whether c wins over other.
+func (c VersionedConfig) newer(other VersionedConfig) bool {
+	if c.Version != other.Version {
+		return c.Version > other.Version
+	}
+	return c.Replica > other.Replica
+}
+
+// Returns a Cluster of replica exchanging configs with peers.
+func NewCluster(replica string, peers ...string) *Cluster {
+	return &Cluster{Replica: replica, Peers: peers}
+}
+
+// Load implements ConfigStore. Configs are exchanged with peers first,
+// those not responding are skipped.
+func (c *Cluster) Load(ctx context.Context) (map[string]RouteConfig, error) {
+	c.syncAll(ctx, c.Peers)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.routeConfigs(), nil
+}
+
+// Save implements ConfigStore.
+func (c *Cluster) Save(ctx context.Context, name string, rc RouteConfig) error {
+	c.mu.Lock()
+	if c.configs == nil {
+		c.configs = make(map[string]VersionedConfig)
+	}
+	c.configs[name] = VersionedConfig{Config: rc, Version: c.configs[name].Version + 1, Replica: c.Replica}
+	c.notify()
+	go c.syncAll(context.Background(), c.Peers)
+	return nil
+}
+
+// Watch implements ConfigStore.
+func (c *Cluster) Watch(ctx context.Context, f func(map[string]RouteConfig)) error {
+	c.mu.Lock()
+	if c.watchers == nil {
+		c.watchers = make(map[*func(map[string]RouteConfig)]struct{})
+	}
+	c.watchers[&f] = struct{}{}
+	configs := c.routeConfigs()
+	c.mu.Unlock()
+	f(configs)
+	<-ctx.Done()
+	c.mu.Lock()
+	delete(c.watchers, &f)
+	c.mu.Unlock()
+	return ctx.Err()
+}
+
+// Returns versioned configs of all routes this replica knows of.
+func (c *Cluster) Configs() map[string]VersionedConfig {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.copy()
+}
+
+// Exchanges configs with every peer each Interval until ctx is done.
+func (c *Cluster) Run(ctx context.Context) error {
+	t := time.NewTicker(c.interval())
+	defer t.Stop()
+	for {
+		c.syncAll(ctx, c.Peers)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (c *Cluster) interval() time.Duration {
+	if c.Interval <= 0 {
+		return 5 * time.Second
+	}
+	return c.Interval
+}
+
+// ServeHTTP merges configs POSTed by a peer and responds with configs
+// of this replica, so that one request brings both up to date.
+func (c *Cluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if c.Token == "" {
+		http.Error(w, errNoClusterToken.Error(), http.StatusForbidden)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+c.Token)) != 1 {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	var configs map[string]VersionedConfig
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&configs); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.merge(configs)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c.Configs())
+}
+
+// Exchanges configs with peers concurrently, logging failures.
+func (c *Cluster) syncAll(ctx context.Context, peers []string) {
+	var wg sync.WaitGroup
+	for _, peer := range peers {
+		wg.Add(1)
+		go func(peer string) {
+			defer wg.Done()
+			if err := c.sync(ctx, peer); err != nil && ctx.Err() == nil {
+				log.Printf("muxer: exchanging route configs with %s: %v", peer, err)
+			}
+		}(peer)
+	}
+	wg.Wait()
+}
+
+// Sends configs of this replica to peer and merges those it responds with.
+func (c *Cluster) sync(ctx context.Context, peer string) error {
+	if c.Token == "" {
+		return errNoClusterToken
+	}
+	b, err := json.Marshal(c.Configs())
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", peer, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	client := c.Client
+	if client == nil {
+		client = &http.Client{Timeout: c.interval()}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %s", resp.Status)
+	}
+	var configs map[string]VersionedConfig
+	if err := json.NewDecoder(resp.Body).Decode(&configs); err != nil {
+		return errors.Join(errors.New("malformed response"), err)
+	}
+	c.merge(configs)
+	return nil
+}
+
+// Keeps configs newer than those of this replica, notifying watchers if
+// there are any. Configs with versions too far ahead are logged and
+// ignored.
+func (c *Cluster) merge(configs map[string]VersionedConfig) {
+	c.mu.Lock()
+	var highest uint64
+	for _, vc := range c.configs {
+		if vc.Version > highest {
+			highest = vc.Version
+		}
+	}
+	changed := false
+	for name, vc := range configs {
+		if vc.Version > highest+maxVersionGap {
+			log.Printf("muxer: ignoring config of route %q with version %d from %s, the highest known one is %d", name, vc.Version, vc.Replica, highest)
+			continue
+		}
+		if cur, ok := c.configs[name]; !ok || vc.newer(cur) {
+			if c.configs == nil {
+				c.configs = make(map[string]VersionedConfig)
+			}
+			c.configs[name] = vc
+			changed = true
+		}
+	}
+	if !changed {
+		c.mu.Unlock()
+		return
+	}
+	c.notify()
+}
+
+// Calls watchers with the current configs. Called with c.mu held, which
+// it releases.
+func (c *Cluster) notify() {
+	routeConfigs := c.routeConfigs()
+	watchers := make([]func(map[string]RouteConfig), 0, len(c.watchers))
+	for f := range c.watchers {
+		watchers = append(watchers, *f)
+	}
+	c.mu.Unlock()
+	for _, f := range watchers {
+		f(routeConfigs)
+	}
+}
+
+func (c *Cluster) routeConfigs() map[string]RouteConfig {
+	configs := make(map[string]RouteConfig, len(c.configs))
+	for name, vc := range c.configs {
+		configs[name] = vc.Config
+	}
+	return configs
+}
+
+func (c *Cluster) copy() map[string]VersionedConfig {
+	configs := make(map[string]VersionedConfig, len(c.configs))
+	for name, vc := range c.configs {
+		configs[name] = vc
+	}
+	return configs
+}
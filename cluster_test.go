@@ -0,0 +1,99 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCluster(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a, b := NewCluster("a"), NewCluster("b")
+	a.Token, b.Token = "secret", "secret"
+	sa, sb := httptest.NewServer(a), httptest.NewServer(b)
+	defer sa.Close()
+	defer sb.Close()
+	a.Peers, b.Peers = []string{sb.URL}, []string{sa.URL}
+	a.Interval, b.Interval = 10*time.Millisecond, 10*time.Millisecond
+
+	ma := NewMux("/", http.NewServeMux())
+	ma.Add("GET", "a", dummy).As("a")
+	mb := ma.Clone()
+	if err := ma.UseConfigStore(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	if err := ma.Configure("a", RouteConfig{RateLimit: 10}); err != nil {
+		t.Fatal(err)
+	}
+	// Pulled from a when loading.
+	if err := mb.UseConfigStore(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+	if c := mb.Routes()[0].Config(); c.RateLimit != 10 {
+		t.Errorf("loaded config = %+v", c)
+	}
+	go a.Run(ctx)
+	go b.Run(ctx)
+
+	mb.Configure("a", RouteConfig{RateLimit: 20})
+	if c := waitRateLimit(t, ma, 20); c.RateLimit != 20 {
+		t.Errorf("config of the other replica = %+v", c)
+	}
+	if vc := a.Configs()["a"]; vc.Version != 2 || vc.Replica != "b" {
+		t.Errorf("versioned config = %+v", vc)
+	}
+
+	req, _ := http.NewRequest("POST", sa.URL, strings.NewReader("{}"))
+	if resp, err := http.DefaultClient.Do(req); err != nil || resp.StatusCode != 401 {
+		t.Errorf("exchange without token = %v, %v", resp, err)
+	}
+	a.Token = ""
+	req, _ = http.NewRequest("POST", sa.URL, strings.NewReader("{}"))
+	if resp, err := http.DefaultClient.Do(req); err != nil || resp.StatusCode != 403 {
+		t.Errorf("exchange with a replica without token = %v, %v", resp, err)
+	}
+	if err := a.sync(ctx, sb.URL); err != errNoClusterToken {
+		t.Errorf("sync() without token = %v", err)
+	}
+}
+
+func TestClusterConflict(t *testing.T) {
+	c := NewCluster("a")
+	c.Save(context.Background(), "x", RouteConfig{RateLimit: 1})
+	c.merge(map[string]VersionedConfig{
+		"x": {Config: RouteConfig{RateLimit: 2}, Version: 1, Replica: "b"},
+		"y": {Config: RouteConfig{RateLimit: 3}, Version: 1, Replica: "0"},
+	})
+	// Saved concurrently with b, which wins.
+	if vc := c.Configs()["x"]; vc.Config.RateLimit != 2 {
+		t.Errorf("x = %+v, want config of b", vc)
+	}
+	c.merge(map[string]VersionedConfig{
+		"x": {Config: RouteConfig{RateLimit: 4}, Version: 1, Replica: "0"},
+	})
+	if vc := c.Configs()["x"]; vc.Config.RateLimit != 2 {
+		t.Errorf("x = %+v, want config of b", vc)
+	}
+	c.Save(context.Background(), "x", RouteConfig{RateLimit: 5})
+	if vc := c.Configs()["x"]; vc.Version != 2 || vc.Replica != "a" {
+		t.Errorf("x after save = %+v, want version 2 of a", vc)
+	}
+	c.merge(map[string]VersionedConfig{
+		"x": {Config: RouteConfig{RateLimit: 6}, Version: math.MaxUint64, Replica: "b"},
+		"z": {Config: RouteConfig{RateLimit: 7}, Version: 2 + maxVersionGap, Replica: "b"},
+	})
+	if vc := c.Configs()["x"]; vc.Version != 2 || vc.Replica != "a" {
+		t.Errorf("x after merging version too far ahead = %+v, want version 2 of a", vc)
+	}
+	if vc := c.Configs()["z"]; vc.Config.RateLimit != 7 {
+		t.Errorf("z = %+v, want config of b", vc)
+	}
+}
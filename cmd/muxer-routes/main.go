@@ -0,0 +1,86 @@
+/*
+Command muxer-routes prints a route table as a text, JSON or markdown report,
+or the mapping of routes to their owners as JSON or CSV for alert routing.
+
+It reads a JSON array of routes as written by muxer.Report with "json" format,
+from a file or stdin. An app can dump its route table without starting
+the server, e.g. from a small program building the mux with its registrars:
+
+	m := muxer.NewMux("/api", http.NewServeMux())
+	m.Register(blog.Routes{}, shop.Routes{})
+	muxer.Report(os.Stdout, muxer.RouteInfos(m), muxer.ReportOptions{Format: "json"})
+
+Usage:
+
+	muxer-routes [flags] [routes.json]
+
+	go run ./dumproutes | muxer-routes -format markdown -sort path -tag admin
+	go run ./dumproutes | muxer-routes -owners csv > owners.csv
+*/
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	muxer "code.google.com/p/go-muxer"
+)
+
+var (
+	format = flag.String("format", "text", "report format: text, json or markdown")
+	sortBy = flag.String("sort", "", "sort by method, path or name")
+	method = flag.String("method", "", "show only routes with this HTTP method")
+	tag    = flag.String("tag", "", "show only routes with this tag")
+	owners = flag.String("owners", "", "print route owners instead, as json or csv")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: muxer-routes [flags] [routes.json]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var in io.Reader = os.Stdin
+	switch flag.NArg() {
+	case 0:
+	case 1:
+		f, err := os.Open(flag.Arg(0))
+		if err != nil {
+			fatal(err)
+		}
+		defer f.Close()
+		in = f
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var infos []muxer.RouteInfo
+	if err := json.NewDecoder(in).Decode(&infos); err != nil {
+		fatal(err)
+	}
+	if *owners != "" {
+		if err := muxer.ExportOwners(os.Stdout, infos, *owners); err != nil {
+			fatal(err)
+		}
+		return
+	}
+	opts := muxer.ReportOptions{
+		Format: *format,
+		SortBy: *sortBy,
+		Method: *method,
+		Tag:    *tag,
+	}
+	if err := muxer.Report(os.Stdout, infos, opts); err != nil {
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "muxer-routes: %v\n", err)
+	os.Exit(1)
+}
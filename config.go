@@ -0,0 +1,154 @@
+package muxer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RouteConfig holds settings of a route which can be changed at runtime
+// with Mux.Configure(). The zero value changes nothing.
+type RouteConfig struct {
+	// Time limit of the handler, see Timeout(). Zero means none.
+	Timeout time.Duration
+	// Maximum number of requests per second. Excess requests get
+	// 429 Too Many Requests. Zero means unlimited.
+	RateLimit float64
+	// Number of requests allowed in a burst over RateLimit.
+	// Defaults to RateLimit rounded up.
+	Burst int
+	// Sets "Cache-Control: public, max-age=..." on responses if positive,
+	// letting clients and CDNs cache them.
+	Cache time.Duration
+	// Responds with 503 Service Unavailable without calling the handler,
+	// e.g. to shed an expensive route during an incident.
+	Disabled bool
+	// Faults to inject, e.g. to test resilience of clients in staging.
+	// Not allowed in the "production" environment, see Mux.Environment().
+	Faults *Faults
+}
+
+// Applied RouteConfig with its state.
+type routeConfig struct {
+	RouteConfig
+	limiter *tokenBucket
+}
+
+// Applies c to the route named name, replacing its previous RouteConfig
+// as a whole. Safe to call while serving, e.g. from an admin endpoint:
+//
+//	m.Configure("search", muxer.RouteConfig{RateLimit: 50, Timeout: 2 * time.Second})
+//
+// In-flight requests complete with the previous settings. Settings apply
+// right before the route's middleware. The config is saved to the store
+// of UseConfigStore(), if any. Returns an error if there's no such route
+// or the config can't be saved.
+func (dm *defaultMux) Configure(name string, c RouteConfig) error {
+	if err := dm.checkConfig(name, c); err != nil {
+		return err
+	}
+	for _, r := range dm.snapshot() {
+		if r.Name == name {
+			r.setConfig(newRouteConfig(c))
+			if dm.configStore != nil {
+				return dm.configStore.Save(context.Background(), name, c)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("Route '%s' doesn't exist", name)
+}
+
+// Returns an error if c can't be applied to the route named name.
+func (dm *defaultMux) checkConfig(name string, c RouteConfig) error {
+	if c.Faults != nil && dm.env == "production" {
+		return fmt.Errorf("Faults can't be injected into route '%s' in production", name)
+	}
+	return nil
+}
+
+// Returns c ready to be applied to a route.
+func newRouteConfig(c RouteConfig) *routeConfig {
+	rc := &routeConfig{RouteConfig: c}
+	if c.RateLimit > 0 {
+		burst := float64(c.Burst)
+		if burst <= 0 {
+			burst = math.Ceil(c.RateLimit)
+		}
+		rc.limiter = &tokenBucket{rate: c.RateLimit, burst: burst, tokens: burst}
+	}
+	return rc
+}
+
+// Applies rc to this route.
+func (r *Route) setConfig(rc *routeConfig) {
+	r.live.config.Store(rc)
+	r.group.mux.events.emit(ConfigChanged, r, nil)
+}
+
+// Returns the RouteConfig applied to this route with Mux.Configure().
+func (r *Route) Config() RouteConfig {
+	if rc := r.live.config.Load(); rc != nil {
+		return rc.RouteConfig
+	}
+	return RouteConfig{}
+}
+
+// Wraps next according to this config.
+func (rc *routeConfig) wrap(next HandlerFunc) HandlerFunc {
+	h := next
+	if rc.Timeout > 0 {
+		h = Timeout(rc.Timeout, nil)(h)
+	}
+	return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		if rc.Disabled {
+			Error(w, r, NewStatusError(http.StatusServiceUnavailable, ""))
+			return
+		}
+		if rc.limiter != nil {
+			if ok, wait := rc.limiter.take(time.Now()); !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				Error(w, r, NewStatusError(http.StatusTooManyRequests, ""))
+				return
+			}
+		}
+		if rc.Cache > 0 {
+			w.Header().Set("Cache-Control",
+				fmt.Sprintf("public, max-age=%d", int(rc.Cache/time.Second)))
+		}
+		if rc.Faults != nil && !rc.Faults.inject(w, r) {
+			return
+		}
+		h(w, r, v)
+	}
+}
+
+// Token bucket rate limiter.
+type tokenBucket struct {
+	rate, burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// Takes a token at time now. Reports false and how long to wait for
+// the next one if there's none.
+func (b *tokenBucket) take(now time.Time) (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.last.IsZero() {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
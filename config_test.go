@@ -0,0 +1,81 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestConfigure(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	r := m.Add("GET", "search", dummy).As("search")
+
+	if err := m.Configure("missing", RouteConfig{}); err == nil {
+		t.Error("Configure(missing) = nil, want error")
+	}
+
+	m.Configure("search", RouteConfig{Disabled: true})
+	if w := serve(m, "GET", "/search"); w.Code != 503 {
+		t.Errorf("disabled: code = %d, want 503", w.Code)
+	}
+	if !r.Config().Disabled {
+		t.Error("Config().Disabled = false")
+	}
+
+	m.Configure("search", RouteConfig{Cache: time.Minute})
+	w := serve(m, "GET", "/search")
+	if w.Code != 200 {
+		t.Errorf("enabled: code = %d, want 200", w.Code)
+	}
+	assertEqual(t, w.Header().Get("Cache-Control"), "public, max-age=60")
+
+	m.Configure("search", RouteConfig{})
+	assertEqual(t, serve(m, "GET", "/search").Header().Get("Cache-Control"), "")
+}
+
+func TestConfigureRateLimit(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "search", dummy).As("search")
+	m.Configure("search", RouteConfig{RateLimit: 0.5, Burst: 2})
+	for i := 0; i < 2; i++ {
+		if w := serve(m, "GET", "/search"); w.Code != 200 {
+			t.Fatalf("request %d: code = %d, want 200", i, w.Code)
+		}
+	}
+	w := serve(m, "GET", "/search")
+	if w.Code != 429 {
+		t.Errorf("code = %d, want 429", w.Code)
+	}
+	assertEqual(t, w.Header().Get("Retry-After"), "2")
+}
+
+func TestTokenBucket(t *testing.T) {
+	b := &tokenBucket{rate: 10, burst: 1, tokens: 1}
+	now := time.Now()
+	if ok, _ := b.take(now); !ok {
+		t.Fatal("first take failed")
+	}
+	if ok, wait := b.take(now); ok || wait != 100*time.Millisecond {
+		t.Errorf("take() = %v, %v; want false, 100ms", ok, wait)
+	}
+	if ok, _ := b.take(now.Add(100 * time.Millisecond)); !ok {
+		t.Error("take after refill failed")
+	}
+}
+
+func TestConfigureClone(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "search", dummy).As("search")
+	m.Configure("search", RouteConfig{Disabled: true})
+	c := m.Clone()
+	c.Configure("search", RouteConfig{})
+	if w := serve(m, "GET", "/search"); w.Code != 503 {
+		t.Errorf("original: code = %d, want 503", w.Code)
+	}
+	if w := serve(c, "GET", "/search"); w.Code != 200 {
+		t.Errorf("clone: code = %d, want 200", w.Code)
+	}
+}
@@ -0,0 +1,237 @@
+package muxer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ConfigStore keeps RouteConfig of routes by name, so that settings
+// changed at runtime survive restarts and can be shared by replicas, e.g.
+// a file on a shared volume or a key-value store.
+type ConfigStore interface {
+	// Returns configs of all routes in the store.
+	Load(ctx context.Context) (map[string]RouteConfig, error)
+	// Saves config of the route named name.
+	Save(ctx context.Context, name string, c RouteConfig) error
+	// Calls f with configs of all routes right away and whenever they
+	// change afterwards, e.g. by another replica, until ctx is done.
+	Watch(ctx context.Context, f func(map[string]RouteConfig)) error
+}
+
+// Makes this mux apply configs of store to its routes and keep them in
+// sync with it until ctx is done, saving configs set with Configure() to
+// store. Configs of routes this mux doesn't have are ignored, so replicas
+// running different versions can share a store:
+//
+//	err := m.UseConfigStore(ctx, muxer.NewConfigFile("/var/lib/app/routes.json"))
+//
+// Returns an error if configs can't be loaded or applied.
+func (dm *defaultMux) UseConfigStore(ctx context.Context, store ConfigStore) error {
+	configs, err := store.Load(ctx)
+	if err != nil {
+		return err
+	}
+	if err := dm.applyConfigs(configs); err != nil {
+		return err
+	}
+	dm.configStore = store
+	go func() {
+		err := store.Watch(ctx, func(configs map[string]RouteConfig) {
+			if err := dm.applyConfigs(configs); err != nil {
+				log.Printf("muxer: applying route configs: %v", err)
+			}
+		})
+		if err != nil && ctx.Err() == nil {
+			log.Printf("muxer: watching route configs: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Applies configs to routes of this mux by name, skipping unknown ones
+// and those which have them already, so that their rate limits aren't reset.
+func (dm *defaultMux) applyConfigs(configs map[string]RouteConfig) error {
+	var errs []error
+	for _, r := range dm.snapshot() {
+		if c, ok := configs[r.Name]; ok && r.Name != "" && !sameConfig(r.Config(), c) {
+			if err := dm.checkConfig(r.Name, c); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			r.setConfig(newRouteConfig(c))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Reports whether a and b are the same settings.
+func sameConfig(a, b RouteConfig) bool {
+	fa, fb := a.Faults, b.Faults
+	a.Faults, b.Faults = nil, nil
+	if a != b || (fa == nil) != (fb == nil) {
+		return false
+	}
+	return fa == nil || *fa == *fb
+}
+
+// ConfigMap is an in-memory ConfigStore, e.g. for tests or muxes of one
+// process sharing configs. The zero value is an empty map ready to use.
+type ConfigMap struct {
+	mu       sync.Mutex
+	configs  map[string]RouteConfig
+	watchers map[*func(map[string]RouteConfig)]struct{}
+}
+
+// Load implements ConfigStore.
+func (m *ConfigMap) Load(ctx context.Context) (map[string]RouteConfig, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.copy(), nil
+}
+
+// Save implements ConfigStore. Watchers are notified before it returns.
+func (m *ConfigMap) Save(ctx context.Context, name string, c RouteConfig) error {
+	m.mu.Lock()
+	if m.configs == nil {
+		m.configs = make(map[string]RouteConfig)
+	}
+	m.configs[name] = c
+	configs := m.copy()
+	watchers := make([]func(map[string]RouteConfig), 0, len(m.watchers))
+	for f := range m.watchers {
+		watchers = append(watchers, *f)
+	}
+	m.mu.Unlock()
+	for _, f := range watchers {
+		f(configs)
+	}
+	return nil
+}
+
+// Watch implements ConfigStore.
+func (m *ConfigMap) Watch(ctx context.Context, f func(map[string]RouteConfig)) error {
+	m.mu.Lock()
+	if m.watchers == nil {
+		m.watchers = make(map[*func(map[string]RouteConfig)]struct{})
+	}
+	m.watchers[&f] = struct{}{}
+	configs := m.copy()
+	m.mu.Unlock()
+	f(configs)
+	<-ctx.Done()
+	m.mu.Lock()
+	delete(m.watchers, &f)
+	m.mu.Unlock()
+	return ctx.Err()
+}
+
+func (m *ConfigMap) copy() map[string]RouteConfig {
+	c := make(map[string]RouteConfig, len(m.configs))
+	for name, rc := range m.configs {
+		c[name] = rc
+	}
+	return c
+}
+
+// ConfigFile is a ConfigStore keeping configs in a JSON file, an object
+// of RouteConfig by route name, e.g. on a volume shared by replicas.
+// A missing file has no configs.
+type ConfigFile struct {
+	Path string
+	// How often Watch checks the file for changes. Defaults to 5 seconds.
+	Interval time.Duration
+
+	mu sync.Mutex
+}
+
+// Returns a ConfigFile of path.
+func NewConfigFile(path string) *ConfigFile {
+	return &ConfigFile{Path: path}
+}
+
+// Load implements ConfigStore.
+func (f *ConfigFile) Load(ctx context.Context) (map[string]RouteConfig, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	configs, _, err := f.read()
+	return configs, err
+}
+
+// Returns configs in the file and its content.
+func (f *ConfigFile) read() (map[string]RouteConfig, []byte, error) {
+	configs := make(map[string]RouteConfig)
+	b, err := os.ReadFile(f.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return configs, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := json.Unmarshal(b, &configs); err != nil {
+		return nil, nil, err
+	}
+	return configs, b, nil
+}
+
+// Save implements ConfigStore. The file is replaced atomically, so
+// readers never see a partly written one.
+func (f *ConfigFile) Save(ctx context.Context, name string, c RouteConfig) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	configs, _, err := f.read()
+	if err != nil {
+		return err
+	}
+	configs[name] = c
+	b, err := json.MarshalIndent(configs, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), filepath.Base(f.Path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(b, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+// Watch implements ConfigStore. Unreadable or malformed contents are
+// skipped until the file is fixed.
+func (f *ConfigFile) Watch(ctx context.Context, fn func(map[string]RouteConfig)) error {
+	interval := f.Interval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	var last []byte
+	for first := true; ; first = false {
+		f.mu.Lock()
+		configs, b, err := f.read()
+		f.mu.Unlock()
+		if err == nil && (first || !bytes.Equal(b, last)) {
+			last = b
+			fn(configs)
+		}
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
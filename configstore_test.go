@@ -0,0 +1,89 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Returns config of route a of m once it has rate limit rate.
+func waitRateLimit(t *testing.T, m Mux, rate float64) RouteConfig {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		c := m.Routes()[0].Config()
+		if c.RateLimit == rate || time.Now().After(deadline) {
+			return c
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestConfigMap(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := &ConfigMap{}
+	store.Save(ctx, "a", RouteConfig{RateLimit: 10})
+	store.Save(ctx, "gone", RouteConfig{Disabled: true})
+
+	m1 := NewMux("/", http.NewServeMux())
+	m1.Add("GET", "a", dummy).As("a")
+	m2 := m1.Clone()
+	for _, m := range []Mux{m1, m2} {
+		if err := m.UseConfigStore(ctx, store); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c := m2.Routes()[0].Config(); c.RateLimit != 10 {
+		t.Errorf("loaded config = %+v", c)
+	}
+
+	if err := m1.Configure("a", RouteConfig{RateLimit: 20}); err != nil {
+		t.Fatal(err)
+	}
+	if c := waitRateLimit(t, m2, 20); c.RateLimit != 20 {
+		t.Errorf("config of the other mux = %+v", c)
+	}
+	if configs, _ := store.Load(ctx); configs["a"].RateLimit != 20 {
+		t.Errorf("saved configs = %+v", configs)
+	}
+}
+
+func TestConfigFile(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	path := filepath.Join(t.TempDir(), "routes.json")
+	store := &ConfigFile{Path: path, Interval: 10 * time.Millisecond}
+	if configs, err := store.Load(ctx); err != nil || len(configs) != 0 {
+		t.Fatalf("Load() of a missing file = %v, %v", configs, err)
+	}
+
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "a", dummy).As("a")
+	if err := m.UseConfigStore(ctx, store); err != nil {
+		t.Fatal(err)
+	}
+	m.Configure("a", RouteConfig{RateLimit: 5, Faults: &Faults{ErrorRate: 0.1}})
+	configs, err := NewConfigFile(path).Load(ctx)
+	if err != nil || configs["a"].RateLimit != 5 || configs["a"].Faults.ErrorRate != 0.1 {
+		t.Fatalf("saved configs = %+v, %v", configs, err)
+	}
+
+	// Changed by another replica.
+	other := NewConfigFile(path)
+	other.Save(ctx, "a", RouteConfig{RateLimit: 7})
+	if c := waitRateLimit(t, m, 7); c.RateLimit != 7 {
+		t.Errorf("config after the file changed = %+v", c)
+	}
+
+	os.WriteFile(path, []byte("{"), 0o644)
+	if _, err := store.Load(ctx); err == nil {
+		t.Error("malformed file loaded")
+	}
+}
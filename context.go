@@ -0,0 +1,64 @@
+package muxer
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+type routeKey struct{}
+
+// Value of routeKey in a request context.
+type routeContext struct {
+	route  *Route
+	params url.Values
+}
+
+// Derives a context of request matched to route r with params v.
+// The context is canceled when the client goes away, as with any server
+// request, or as soon as the handler returns, whichever happens first.
+func newRequestContext(parent context.Context, r *Route, v url.Values) (context.Context, context.CancelFunc) {
+	ctx := context.WithValue(parent, routeKey{}, &routeContext{r, v})
+	return context.WithCancel(ctx)
+}
+
+// Returns the route matched for request r or nil if r hasn't been
+// dispatched by a mux.
+func CurrentRoute(r *http.Request) *Route {
+	if rc, ok := r.Context().Value(routeKey{}).(*routeContext); ok {
+		return rc.route
+	}
+	return nil
+}
+
+// Returns params extracted from URL path of request r, the same ones
+// passed to the route handler. Useful in http.Handler based code called from
+// a HandlerFunc.
+func Params(r *http.Request) url.Values {
+	if rc, ok := r.Context().Value(routeKey{}).(*routeContext); ok {
+		return rc.params
+	}
+	return nil
+}
+
+// Returns a channel closed when the client of request r goes away or
+// the handler has returned. Streaming handlers should stop writing once it's
+// closed:
+//
+//	for {
+//		select {
+//		case ev := <-events:
+//			writeEvent(w, ev)
+//		case <-muxer.Done(r):
+//			return
+//		}
+//	}
+func Done(r *http.Request) <-chan struct{} {
+	return r.Context().Done()
+}
+
+// Reports whether the client of request r has gone away or the request
+// has been canceled otherwise, e.g. timed out.
+func Canceled(r *http.Request) bool {
+	return r.Context().Err() != nil
+}
@@ -0,0 +1,53 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestRequestContext(t *testing.T) {
+	var route *Route
+	var params url.Values
+	var done <-chan struct{}
+	m := NewMux("/", http.NewServeMux())
+	users := m.Add("GET", "users/{id}", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		route, params, done = CurrentRoute(r), Params(r), Done(r)
+		if Canceled(r) {
+			t.Errorf("Expected request not to be canceled yet")
+		}
+	})
+	serve(m, "GET", "/users/1")
+	if route != users {
+		t.Fatalf("Expected users route in the context, got %v", route)
+	}
+	assertEqual(t, params.Get("id"), "1")
+	select {
+	case <-done:
+	default:
+		t.Fatalf("Expected context to be canceled after the handler returned")
+	}
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	if CurrentRoute(r) != nil || Params(r) != nil {
+		t.Fatalf("Expected no route in a request which wasn't dispatched")
+	}
+}
+
+func TestClientGoesAway(t *testing.T) {
+	stopped := make(chan struct{})
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "stream", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		<-Done(r)
+		close(stopped)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, "GET", "/stream", nil)
+	go serveRequest(m, req)
+	cancel()
+	<-stopped
+}
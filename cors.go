@@ -0,0 +1,176 @@
+package muxer
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORS is a Cross-Origin Resource Sharing policy. Set the default one
+// of a mux with Mux.CORS() and override it for individual routes with
+// Route.CORS(), e.g. for a public widget endpoint:
+//
+//	m.CORS(&muxer.CORS{Origins: []string{"https://app.example.com"}, Credentials: true})
+//	m.Add("GET", "widget/{id}", widget).CORS(&muxer.CORS{Origins: []string{"*"}})
+//
+// Preflight requests are answered by the mux from the route table using
+// the policy of the route matching the requested method, without explicit
+// OPTIONS routes.
+type CORS struct {
+	// Allowed origins, e.g. "https://example.com". "*" allows any origin.
+	Origins []string
+	// Methods allowed in preflight responses. Defaults to methods of all
+	// routes matching the URL path whose policy allows the origin.
+	Methods []string
+	// Request headers allowed in preflight responses. "*" allows any header
+	// the browser asks for.
+	Headers []string
+	// Response headers exposed to scripts.
+	ExposeHeaders []string
+	// Whether requests can include credentials, e.g. cookies. The origin
+	// of a request is sent back instead of "*" then.
+	Credentials bool
+	// How long browsers can cache preflight responses. Zero leaves it
+	// up to the browser.
+	MaxAge time.Duration
+}
+
+// Sets the default CORS policy of routes of this mux. A nil policy
+// disables CORS for routes which don't have their own one.
+func (dm *defaultMux) CORS(c *CORS) {
+	dm.cors = c
+}
+
+// Overrides the CORS policy of the mux for this route.
+func (r *Route) CORS(c *CORS) *Route {
+	r.cors = c
+	return r
+}
+
+// Returns the CORS policy in effect for this route, nil if none.
+func (r *Route) corsPolicy() *CORS {
+	if r.cors != nil {
+		return r.cors
+	}
+	return r.group.mux.cors
+}
+
+// Returns the value of Access-Control-Allow-Origin header for origin
+// or zero string if origin isn't allowed.
+func (c *CORS) allowOrigin(origin string) string {
+	for _, o := range c.Origins {
+		if o == "*" {
+			if c.Credentials {
+				return origin
+			}
+			return "*"
+		}
+		if strings.EqualFold(o, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// Sets CORS headers of a response to a request from origin. Reports false
+// if origin isn't allowed.
+func (c *CORS) writeHeaders(h http.Header, origin string) bool {
+	h.Add("Vary", "Origin")
+	allow := c.allowOrigin(origin)
+	if allow == "" {
+		return false
+	}
+	h.Set("Access-Control-Allow-Origin", allow)
+	if c.Credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+	return true
+}
+
+// Reports whether req is a CORS preflight request.
+func isPreflight(req *http.Request) bool {
+	return req.Method == "OPTIONS" && req.Header.Get("Origin") != "" &&
+		req.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// Sets CORS headers of the response to an actual request served by route r.
+func (r *Route) writeCORS(h http.Header, req *http.Request) {
+	origin := req.Header.Get("Origin")
+	c := r.corsPolicy()
+	if c == nil || origin == "" {
+		return
+	}
+	if c.writeHeaders(h, origin) && len(c.ExposeHeaders) > 0 {
+		h.Set("Access-Control-Expose-Headers", strings.Join(c.ExposeHeaders, ", "))
+	}
+}
+
+// Declares request headers this route accepts in cross-origin requests,
+// e.g. "If-Match". They're added to headers of its CORS policy in
+// preflight responses.
+func (r *Route) AllowHeaders(headers ...string) *Route {
+	r.corsHeaders = append(r.corsHeaders, headers...)
+	return r
+}
+
+// Answers preflight request req for URL path p with the CORS policy of
+// the route matching the requested method. Reports false if there's no
+// such route or it has no CORS policy. Handlers of OPTIONS routes aren't
+// called for preflight requests.
+func (dm *defaultMux) servePreflight(w http.ResponseWriter, req *http.Request, p string) bool {
+	r, _ := dm.match(req.Header.Get("Access-Control-Request-Method"), p, listenerOf(req))
+	if r == nil {
+		return false
+	}
+	c := r.corsPolicy()
+	if c == nil {
+		return false
+	}
+	h := w.Header()
+	origin := req.Header.Get("Origin")
+	if c.writeHeaders(h, origin) {
+		methods := c.Methods
+		if len(methods) == 0 {
+			methods = dm.corsMethods(p, origin, listenerOf(req))
+		}
+		h.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+		if headers := c.allowHeaders(req, r.corsHeaders); headers != "" {
+			h.Set("Access-Control-Allow-Headers", headers)
+		}
+		if c.MaxAge > 0 {
+			h.Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge/time.Second)))
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// Returns methods of routes served on listener matching URL path p whose
+// CORS policy allows origin.
+func (dm *defaultMux) corsMethods(p, origin, listener string) (methods []string) {
+	for _, r := range dm.pathRoutes(p, listener) {
+		if c := r.corsPolicy(); c != nil && c.allowOrigin(origin) != "" &&
+			!containsString(methods, r.Method) {
+			methods = append(methods, r.Method)
+		}
+	}
+	return
+}
+
+// Returns the value of Access-Control-Allow-Headers header of a response
+// to preflight request req, including headers declared by the route.
+func (c *CORS) allowHeaders(req *http.Request, declared []string) string {
+	for _, h := range c.Headers {
+		if h == "*" {
+			return req.Header.Get("Access-Control-Request-Headers")
+		}
+	}
+	headers := append([]string(nil), c.Headers...)
+	for _, h := range declared {
+		if !containsString(headers, h) {
+			headers = append(headers, h)
+		}
+	}
+	return strings.Join(headers, ", ")
+}
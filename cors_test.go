@@ -0,0 +1,114 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.CORS(&CORS{
+		Origins:       []string{"https://app.example.com"},
+		Headers:       []string{"Content-Type"},
+		ExposeHeaders: []string{"X-Request-Id"},
+		Credentials:   true,
+		MaxAge:        10 * time.Minute,
+	})
+	m.Add("PUT", "orders/{id}", dummy)
+	m.Add("GET", "widget/{id}", dummy).CORS(&CORS{Origins: []string{"*"}, Headers: []string{"*"}})
+
+	request := func(method, path, origin string, headers ...string) *http.Request {
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Origin", origin)
+		for i := 0; i < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		return req
+	}
+	tests := []struct {
+		req     *http.Request
+		code    int
+		headers map[string]string
+	}{
+		{request("PUT", "/orders/1", "https://app.example.com"), 200, map[string]string{
+			"Access-Control-Allow-Origin":      "https://app.example.com",
+			"Access-Control-Allow-Credentials": "true",
+			"Access-Control-Expose-Headers":    "X-Request-Id",
+			"Vary":                             "Origin",
+		}},
+		{request("PUT", "/orders/1", "https://evil.example.com"), 200, map[string]string{
+			"Access-Control-Allow-Origin": "",
+			"Vary":                        "Origin",
+		}},
+		{request("OPTIONS", "/orders/1", "https://app.example.com",
+			"Access-Control-Request-Method", "PUT",
+			"Access-Control-Request-Headers", "content-type"), 204, map[string]string{
+			"Access-Control-Allow-Origin":  "https://app.example.com",
+			"Access-Control-Allow-Methods": "PUT",
+			"Access-Control-Allow-Headers": "Content-Type",
+			"Access-Control-Max-Age":       "600",
+		}},
+		{request("OPTIONS", "/orders/1", "https://evil.example.com",
+			"Access-Control-Request-Method", "PUT"), 204, map[string]string{
+			"Access-Control-Allow-Origin":  "",
+			"Access-Control-Allow-Methods": "",
+		}},
+		{request("OPTIONS", "/orders/1", "https://app.example.com",
+			"Access-Control-Request-Method", "DELETE"), 405, nil},
+		{request("GET", "/widget/1", "https://blog.example.com"), 200, map[string]string{
+			"Access-Control-Allow-Origin":      "*",
+			"Access-Control-Allow-Credentials": "",
+		}},
+		{request("OPTIONS", "/widget/1", "https://blog.example.com",
+			"Access-Control-Request-Method", "GET",
+			"Access-Control-Request-Headers", "x-widget-theme"), 204, map[string]string{
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Methods": "GET",
+			"Access-Control-Allow-Headers": "x-widget-theme",
+			"Access-Control-Max-Age":       "",
+		}},
+	}
+	for i, test := range tests {
+		w := serveRequest(m, test.req)
+		if w.Code != test.code {
+			t.Errorf("%d: Expected %d, got %d", i, test.code, w.Code)
+		}
+		for k, v := range test.headers {
+			if got := w.Header().Get(k); got != v {
+				t.Errorf("%d: Expected %s %q, got %q", i, k, v, got)
+			}
+		}
+	}
+}
+
+func TestCORSPreflightFromRoutes(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.CORS(&CORS{Origins: []string{"https://app.example.com"}, Headers: []string{"Content-Type"}})
+	m.Add("GET", "docs/{id}", dummy)
+	m.Add("PUT", "docs/{id}", dummy).AllowHeaders("If-Match")
+	m.Add("DELETE", "docs/{id}", dummy).CORS(&CORS{Origins: []string{"https://admin.example.com"}})
+	m.Add("OPTIONS", "docs/{id}", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		t.Errorf("OPTIONS handler called for %s", r.Header.Get("Origin"))
+	})
+
+	req, _ := http.NewRequest("OPTIONS", "/docs/1", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "PUT")
+	w := serveRequest(m, req)
+	if w.Code != 204 {
+		t.Errorf("Expected 204 No Content, got %d", w.Code)
+	}
+	assertEqual(t, w.Header().Get("Access-Control-Allow-Methods"), "GET, PUT, OPTIONS")
+	assertEqual(t, w.Header().Get("Access-Control-Allow-Headers"), "Content-Type, If-Match")
+
+	req.Header.Set("Origin", "https://admin.example.com")
+	req.Header.Set("Access-Control-Request-Method", "DELETE")
+	w = serveRequest(m, req)
+	assertEqual(t, w.Header().Get("Access-Control-Allow-Methods"), "DELETE")
+	assertEqual(t, w.Header().Get("Access-Control-Allow-Headers"), "")
+}
@@ -0,0 +1,119 @@
+package muxer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"text/tabwriter"
+)
+
+// Number of requests served by a route since Mux.TrackCoverage() was
+// called.
+type RouteCoverage struct {
+	Method  string
+	Pattern string
+	Name    string
+	Hits    uint64
+}
+
+// Makes this mux count requests served by every route, so that tests can
+// find routes none of them exercised, see Coverage() and CheckCoverage().
+// Meant for tests, e.g. with the app's mux shared by a package's tests:
+//
+//	func TestMain(m *testing.M) {
+//		routes.TrackCoverage()
+//		code := m.Run()
+//		if err := muxer.CheckCoverage(routes); code == 0 && err != nil {
+//			fmt.Println(err)
+//			code = 1
+//		}
+//		os.Exit(code)
+//	}
+//
+// Clones made afterwards share counts of the routes they copy, so requests
+// served by a clone count too.
+func (dm *defaultMux) TrackCoverage() {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	dm.trackCoverage = true
+	for _, r := range dm.routes {
+		if r.hits == nil {
+			r.hits = new(atomic.Uint64)
+		}
+	}
+}
+
+// Returns coverage of all routes in the order they were added, or nil if
+// TrackCoverage() wasn't called.
+func (dm *defaultMux) Coverage() []RouteCoverage {
+	if !dm.trackCoverage {
+		return nil
+	}
+	routes := dm.snapshot()
+	cov := make([]RouteCoverage, 0, len(routes))
+	for _, r := range routes {
+		cov = append(cov, RouteCoverage{r.Method, r.Pattern, r.Name, r.hits.Load()})
+	}
+	return cov
+}
+
+// Returns an error listing enabled routes of m which served no requests
+// since m.TrackCoverage() was called, except those tagged with one of
+// exempt, e.g. "internal". Returns nil if there are none.
+func CheckCoverage(m Mux, exempt ...string) error {
+	if m.Coverage() == nil {
+		return fmt.Errorf("coverage of routes isn't tracked, see Mux.TrackCoverage()")
+	}
+	var uncovered []string
+	for _, r := range m.Routes() {
+		if r.Disabled() || r.hits.Load() > 0 || hasAnyTag(r, exempt) {
+			continue
+		}
+		uncovered = append(uncovered, r.Method+" "+r.Pattern)
+	}
+	if len(uncovered) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d routes not covered by tests:\n\t%s",
+		len(uncovered), strings.Join(uncovered, "\n\t"))
+}
+
+// Reports whether route r has one of tags.
+func hasAnyTag(r *Route, tags []string) bool {
+	for _, t := range tags {
+		if r.HasTag(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Writes coverage of m as a table to w, uncovered routes first, followed
+// by a summary.
+func WriteCoverage(w io.Writer, m Mux) error {
+	cov := m.Coverage()
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "HITS\tMETHOD\tPATTERN\tNAME")
+	covered := 0
+	for _, uncovered := range []bool{true, false} {
+		for _, c := range cov {
+			if (c.Hits == 0) != uncovered {
+				continue
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", c.Hits, c.Method, c.Pattern, c.Name)
+			if c.Hits > 0 {
+				covered++
+			}
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	pct := 0.0
+	if len(cov) > 0 {
+		pct = 100 * float64(covered) / float64(len(cov))
+	}
+	_, err := fmt.Fprintf(w, "%d of %d routes covered (%.1f%%)\n", covered, len(cov), pct)
+	return err
+}
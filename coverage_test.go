@@ -0,0 +1,48 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCoverage(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "users/{id}", dummy).As("user")
+	if m.Coverage() != nil || CheckCoverage(m) == nil {
+		t.Error("coverage reported without TrackCoverage()")
+	}
+	m.TrackCoverage()
+	m.Add("PUT", "users/{id}", dummy)
+	m.Add("GET", "debug", dummy).Tag("internal")
+	m.Add("GET", "old", dummy).Disable()
+
+	serve(m, "GET", "/users/1")
+	serve(m.Clone(), "GET", "/users/2")
+	serve(m, "GET", "/none")
+	cov := m.Coverage()
+	if len(cov) != 4 || cov[0].Hits != 2 || cov[1].Hits != 0 {
+		t.Errorf("coverage = %+v", cov)
+	}
+
+	err := CheckCoverage(m, "internal")
+	if err == nil || !strings.Contains(err.Error(), "PUT users/{id}") || strings.Contains(err.Error(), "debug") {
+		t.Errorf("CheckCoverage() = %v", err)
+	}
+	serve(m, "PUT", "/users/1")
+	if err := CheckCoverage(m, "internal"); err != nil {
+		t.Errorf("CheckCoverage() = %v, want nil", err)
+	}
+
+	var buf bytes.Buffer
+	WriteCoverage(&buf, m)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 6 || !strings.Contains(lines[1], "debug") ||
+		lines[5] != "2 of 4 routes covered (50.0%)" {
+		t.Errorf("report:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,48 @@
+package muxer
+
+import (
+	"net/http"
+	"time"
+)
+
+// NoDeadline passed to Route.Deadlines() clears a deadline set by
+// the server, e.g. http.Server.WriteTimeout for a long-lived stream.
+const NoDeadline time.Duration = -1
+
+type deadlines struct {
+	read, write time.Duration
+}
+
+// Overrides read and write deadlines of the connection serving this route,
+// relative to the time the request is matched. Zero keeps the deadline
+// of the server, e.g. a Server-Sent Events stream can outlive the server's
+// WriteTimeout while an upload route is given a strict time to read:
+//
+//	m.Add("GET", "events", stream).Deadlines(0, muxer.NoDeadline)
+//	m.Add("PUT", "files/{id}", upload).Deadlines(30*time.Second, 0)
+//
+// Deadlines are set with http.ResponseController and silently skipped
+// if the ResponseWriter doesn't support them. Idle timeouts apply between
+// requests, so they can only be set on the http.Server.
+func (r *Route) Deadlines(read, write time.Duration) *Route {
+	r.deadlines = &deadlines{read, write}
+	return r
+}
+
+// Sets deadlines of the connection w is writing to.
+func (d *deadlines) apply(w http.ResponseWriter) {
+	rc := http.NewResponseController(w)
+	now := time.Now()
+	deadline := func(d time.Duration) time.Time {
+		if d == NoDeadline {
+			return time.Time{}
+		}
+		return now.Add(d)
+	}
+	if d.read != 0 {
+		rc.SetReadDeadline(deadline(d.read))
+	}
+	if d.write != 0 {
+		rc.SetWriteDeadline(deadline(d.write))
+	}
+}
@@ -0,0 +1,50 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestDeadlines(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	slow := func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte("done"))
+	}
+	m.Add("GET", "strict", slow)
+	m.Add("GET", "stream", slow).Deadlines(0, NoDeadline)
+	m.Add("GET", "extended", slow).Deadlines(0, time.Second)
+	srv := httptest.NewUnstartedServer(m)
+	srv.Config.WriteTimeout = 50 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	get := func(path string) (string, error) {
+		resp, err := srv.Client().Get(srv.URL + path)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		return string(b), err
+	}
+	if body, err := get("/strict"); err == nil {
+		t.Errorf("Expected write timeout, got %q", body)
+	}
+	for _, path := range []string{"/stream", "/extended"} {
+		body, err := get(path)
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+		assertEqual(t, body, "done")
+	}
+	// Recorders don't support deadlines.
+	assertEqual(t, serve(m, "GET", "/stream").Body.String(), "done")
+}
@@ -0,0 +1,72 @@
+package muxer
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+)
+
+type deprecation struct {
+	sunset    time.Time
+	successor string
+}
+
+// Marks this route as deprecated. Responses get headers:
+//
+//	Deprecation: true
+//	Sunset: <sunset as HTTP date>, see RFC 8594
+//	Link: <successor path>; rel="successor-version"
+//
+// Sunset header is omitted if sunset is zero time, Link header if
+// successorRoute is zero string. The successor is a route name; its path
+// is built with params of the current request having the same names.
+// Deprecations are also included in reports, see RouteInfos().
+func (r *Route) Deprecated(sunset time.Time, successorRoute string) *Route {
+	r.deprecation = &deprecation{sunset, successorRoute}
+	return r
+}
+
+// Reports whether this route has been marked as deprecated.
+func (r *Route) IsDeprecated() bool {
+	return r.deprecation != nil
+}
+
+// Sets deprecation response headers.
+func (d *deprecation) writeHeaders(h http.Header, r *Route, v url.Values) {
+	h.Set("Deprecation", "true")
+	if !d.sunset.IsZero() {
+		h.Set("Sunset", d.sunset.UTC().Format(http.TimeFormat))
+	}
+	if p, ok := r.successorPath(v); ok {
+		h.Add("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", p))
+	}
+}
+
+// Builds URL path of the successor route filling in params from v.
+func (r *Route) successorPath(v url.Values) (string, bool) {
+	if r.deprecation.successor == "" {
+		return "", false
+	}
+	var succ *Route
+	for _, route := range r.mux.Routes() {
+		if route.Name == r.deprecation.successor {
+			succ = route
+			break
+		}
+	}
+	if succ == nil {
+		return "", false
+	}
+	parts := make([]string, 1, succ.partsLen+1)
+	parts[0] = r.group.mux.prefix + r.mux.BasePath()
+	for _, rp := range succ.parts {
+		if rp.isVar {
+			parts = append(parts, v.Get(rp.name))
+		} else {
+			parts = append(parts, rp.name)
+		}
+	}
+	return path.Join(parts...), true
+}
@@ -0,0 +1,47 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeprecated(t *testing.T) {
+	m := NewMux("/api", http.NewServeMux())
+	sunset := time.Date(2027, 1, 31, 0, 0, 0, 0, time.UTC)
+	old := m.Add("GET", "user/{id}", dummy).As("old-profile").
+		Deprecated(sunset, "profile")
+	m.Add("GET", "users/{id}", dummy).As("profile")
+	m.Add("GET", "legacy", dummy).Deprecated(time.Time{}, "")
+	if !old.IsDeprecated() || m.Routes()[1].IsDeprecated() {
+		t.Fatalf("Expected only old profile to be deprecated")
+	}
+
+	w := serve(m, "GET", "/api/user/123")
+	assertEqual(t, w.Header().Get("Deprecation"), "true")
+	assertEqual(t, w.Header().Get("Sunset"), "Sun, 31 Jan 2027 00:00:00 GMT")
+	assertEqual(t, w.Header().Get("Link"), `</api/users/123>; rel="successor-version"`)
+
+	w = serve(m, "GET", "/api/legacy")
+	assertEqual(t, w.Header().Get("Deprecation"), "true")
+	if _, ok := w.Header()["Sunset"]; ok {
+		t.Fatalf("Expected no Sunset header")
+	}
+	if w = serve(m, "GET", "/api/users/1"); w.Header().Get("Deprecation") != "" {
+		t.Fatalf("Expected no Deprecation header, got %v", w.Header())
+	}
+
+	var buf bytes.Buffer
+	Report(&buf, RouteInfos(m), ReportOptions{Format: "markdown"})
+	if !strings.Contains(buf.String(), "old-profile (deprecated, sunset 2027-01-31, use profile)") {
+		t.Fatalf("Expected deprecation in the report:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "| GET | `/api/legacy` | (deprecated) |") {
+		t.Fatalf("Expected deprecation of unnamed route in the report:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,118 @@
+package muxer
+
+import "fmt"
+
+// Kind of a route table change reported by Diff().
+type ChangeKind int
+
+const (
+	// Route exists only in the new mux.
+	Added ChangeKind = iota
+	// Route exists only in the old mux.
+	Removed
+	// Same method and pattern, different name.
+	Renamed
+	// Same name, different method or pattern.
+	Repatterned
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Renamed:
+		return "renamed"
+	case Repatterned:
+		return "repatterned"
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// Single difference between two route tables. Old is nil for Added routes,
+// New is nil for Removed ones.
+type Change struct {
+	Kind ChangeKind
+	Old  *Route
+	New  *Route
+}
+
+// Reports whether the change breaks URLs served by the old mux.
+func (c Change) Breaking() bool {
+	return c.Kind == Removed || c.Kind == Repatterned
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("added %s", routeString(c.New))
+	case Removed:
+		return fmt.Sprintf("removed %s", routeString(c.Old))
+	case Renamed:
+		return fmt.Sprintf("renamed %s: '%s' -> '%s'",
+			routeString(c.New), c.Old.Name, c.New.Name)
+	}
+	return fmt.Sprintf("repatterned '%s': %s %s -> %s %s", c.New.Name,
+		c.Old.Method, c.Old.Pattern, c.New.Method, c.New.Pattern)
+}
+
+func routeString(r *Route) string {
+	if r.Name == "" {
+		return fmt.Sprintf("%s %s", r.Method, r.Pattern)
+	}
+	return fmt.Sprintf("%s %s (%s)", r.Method, r.Pattern, r.Name)
+}
+
+// Diff reports changes between route tables of muxers a (old) and b (new).
+// Named routes are paired by name first, the rest by method and pattern.
+// Changes are ordered as the routes of a, followed by added routes of b.
+func Diff(a, b Mux) []Change {
+	var changes []Change
+	newRoutes := b.Routes()
+	paired := make(map[*Route]bool, len(newRoutes))
+	oldPaired := make(map[*Route]bool)
+
+	for _, or := range a.Routes() {
+		if or.Name == "" {
+			continue
+		}
+		for _, nr := range newRoutes {
+			if nr.Name != or.Name {
+				continue
+			}
+			if nr.Method != or.Method || nr.Pattern != or.Pattern {
+				changes = append(changes, Change{Repatterned, or, nr})
+			}
+			paired[nr], oldPaired[or] = true, true
+			break
+		}
+	}
+	for _, or := range a.Routes() {
+		if oldPaired[or] {
+			continue
+		}
+		var match *Route
+		for _, nr := range newRoutes {
+			if !paired[nr] && nr.Method == or.Method && nr.Pattern == or.Pattern {
+				match = nr
+				break
+			}
+		}
+		switch {
+		case match == nil:
+			changes = append(changes, Change{Removed, or, nil})
+		case match.Name != or.Name:
+			changes = append(changes, Change{Renamed, or, match})
+			paired[match] = true
+		default:
+			paired[match] = true
+		}
+	}
+	for _, nr := range newRoutes {
+		if !paired[nr] {
+			changes = append(changes, Change{Added, nil, nr})
+		}
+	}
+	return changes
+}
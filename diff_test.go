@@ -0,0 +1,52 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	a := NewMux("/api", http.NewServeMux())
+	a.Add("GET", "users/{id}", dummy).As("profile")
+	a.Add("GET", "products", dummy)
+	a.Add("PUT", "products/{id}", dummy).As("product")
+	a.Add("DELETE", "products/{id}", dummy)
+	a.Add("GET", "same", dummy).As("same")
+
+	b := a.Clone()
+	b.Routes()[0].Pattern = "people/{id}"
+	b.Routes()[1].As("list")
+	b.Add("POST", "products", dummy)
+	b = removeRoute(b, 3)
+
+	changes := Diff(a, b)
+	expected := []string{
+		"repatterned 'profile': GET users/{id} -> GET people/{id}",
+		"renamed GET products (list): '' -> 'list'",
+		"removed DELETE products/{id}",
+		"added POST products",
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %v", len(expected), changes)
+	}
+	for i, c := range changes {
+		assertEqual(t, c.String(), expected[i])
+	}
+	if !changes[0].Breaking() || changes[1].Breaking() {
+		t.Fatalf("Expected only repatterned change to be breaking")
+	}
+	if len(Diff(a, a)) != 0 {
+		t.Fatalf("Expected no changes between the same muxers")
+	}
+}
+
+// Returns a copy of m without its i-th route.
+func removeRoute(m Mux, i int) Mux {
+	dm := m.Clone().(*defaultMux)
+	dm.routes = append(dm.routes[:i], dm.routes[i+1:]...)
+	dm.publish()
+	return dm
+}
@@ -0,0 +1,163 @@
+package muxer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Hash functions of Content-Digest algorithms, see RFC 9530.
+var digestAlgorithms = map[string]func() hash.Hash{
+	"sha-256": sha256.New,
+	"sha-512": sha512.New,
+}
+
+// DigestOptions configure ContentDigest() middleware.
+type DigestOptions struct {
+	// Reject requests with a body but without a Content-Digest header
+	// of a supported algorithm.
+	Require bool
+	// Maximum size of request bodies read to verify digests.
+	// Defaults to 10 MiB.
+	MaxBodyBytes int64
+	// Routes with this tag get a Content-Digest header on responses, which
+	// are buffered for that. Zero string disables response digests.
+	ResponseTag string
+	// Algorithm of response digests, "sha-256" (default) or "sha-512".
+	Algorithm string
+}
+
+// Returns middleware verifying Content-Digest headers of requests as of
+// RFC 9530, and adding them to responses of routes tagged with
+// opts.ResponseTag:
+//
+//	m.Use(muxer.ContentDigest(muxer.DigestOptions{ResponseTag: "signed"}))
+//	m.Add("POST", "webhooks/payments", hook).Tag("signed")
+//
+// Requests with a mismatching digest are rejected with 400 Bad Request,
+// too large ones with 413 Request Entity Too Large, both passed to
+// Error() as *StatusError. Digests of unsupported algorithms are ignored.
+func ContentDigest(opts DigestOptions) Middleware {
+	if opts.MaxBodyBytes == 0 {
+		opts.MaxBodyBytes = 10 << 20
+	}
+	if opts.Algorithm == "" {
+		opts.Algorithm = "sha-256"
+	}
+	if digestAlgorithms[opts.Algorithm] == nil {
+		panic(fmt.Sprintf("Unsupported digest algorithm '%s'", opts.Algorithm))
+	}
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+			if err := verifyContentDigest(r, opts); err != nil {
+				Error(w, r, err)
+				return
+			}
+			route := CurrentRoute(r)
+			if opts.ResponseTag == "" || route == nil || !route.HasTag(opts.ResponseTag) {
+				next(w, r, v)
+				return
+			}
+			bw := &bufferedWriter{ResponseWriter: w}
+			next(bw, r, v)
+			h := digestAlgorithms[opts.Algorithm]()
+			h.Write(bw.body.Bytes())
+			w.Header().Set("Content-Digest", fmt.Sprintf("%s=:%s:",
+				opts.Algorithm, base64.StdEncoding.EncodeToString(h.Sum(nil))))
+			bw.flush()
+		}
+	}
+}
+
+// Checks Content-Digest header of r against its body, which is replaced
+// with the bytes read.
+func verifyContentDigest(r *http.Request, opts DigestOptions) error {
+	digests := parseContentDigest(r.Header.Get("Content-Digest"))
+	if len(digests) == 0 {
+		if opts.Require && r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0 {
+			return NewStatusError(http.StatusBadRequest, "Content-Digest required")
+		}
+		return nil
+	}
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = io.ReadAll(io.LimitReader(r.Body, opts.MaxBodyBytes+1))
+		r.Body.Close()
+		if err != nil {
+			return err
+		}
+		if int64(len(body)) > opts.MaxBodyBytes {
+			return NewStatusError(http.StatusRequestEntityTooLarge, "")
+		}
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	for alg, want := range digests {
+		h := digestAlgorithms[alg]()
+		h.Write(body)
+		if subtle.ConstantTimeCompare(h.Sum(nil), want) != 1 {
+			return NewStatusError(http.StatusBadRequest, "Content-Digest mismatch")
+		}
+	}
+	return nil
+}
+
+// Parses a Content-Digest header value, e.g. "sha-256=:X48E9q...=:",
+// into digests by algorithm. Unsupported algorithms and malformed members
+// are skipped.
+func parseContentDigest(s string) map[string][]byte {
+	digests := make(map[string][]byte)
+	for _, member := range strings.Split(s, ",") {
+		alg, val, ok := strings.Cut(strings.TrimSpace(member), "=")
+		alg = strings.ToLower(strings.TrimSpace(alg))
+		if !ok || digestAlgorithms[alg] == nil {
+			continue
+		}
+		val, _, _ = strings.Cut(strings.TrimSpace(val), ";")
+		if len(val) < 2 || val[0] != ':' || val[len(val)-1] != ':' {
+			continue
+		}
+		if b, err := base64.StdEncoding.DecodeString(val[1 : len(val)-1]); err == nil {
+			digests[alg] = b
+		}
+	}
+	return digests
+}
+
+// ResponseWriter buffering the whole response until flush() is called,
+// so that headers depending on the body can still be set.
+type bufferedWriter struct {
+	http.ResponseWriter
+	code int
+	body bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// Sends the buffered response.
+func (w *bufferedWriter) flush() {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	w.ResponseWriter.WriteHeader(w.code)
+	w.ResponseWriter.Write(w.body.Bytes())
+}
@@ -0,0 +1,54 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestContentDigest(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Use(ContentDigest(DigestOptions{Require: true, MaxBodyBytes: 16, ResponseTag: "signed"}))
+	echo := func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		b, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(b)
+	}
+	m.Add("POST", "hooks", echo).Tag("signed")
+	m.Add("POST", "plain", echo)
+
+	// sha-256 of "hello"
+	const helloDigest = "sha-256=:LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ=:"
+	tests := []struct {
+		path, body, digest string
+		code               int
+		respDigest         string
+	}{
+		{"/hooks", "hello", helloDigest, 201, helloDigest},
+		{"/hooks", "hello", "sha-512=:AAAA:, " + helloDigest, 400, ""},
+		{"/hooks", "hellO", helloDigest, 400, ""},
+		{"/hooks", "hello", "", 400, ""},
+		{"/hooks", "hello", "md5=:XUFAKrxLKna5cZ2REBfFkg==:", 400, ""},
+		{"/hooks", strings.Repeat("x", 17), helloDigest, 413, ""},
+		{"/plain", "hello", helloDigest, 201, ""},
+	}
+	for i, test := range tests {
+		req, _ := http.NewRequest("POST", test.path, strings.NewReader(test.body))
+		if test.digest != "" {
+			req.Header.Set("Content-Digest", test.digest)
+		}
+		w := serveRequest(m, req)
+		if w.Code != test.code {
+			t.Errorf("%d: Expected %d, got %d", i, test.code, w.Code)
+		}
+		assertEqual(t, w.Header().Get("Content-Digest"), test.respDigest)
+		if test.code == 201 {
+			assertEqual(t, w.Body.String(), test.body)
+		}
+	}
+}
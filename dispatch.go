@@ -0,0 +1,73 @@
+package muxer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Response of a request served in-process, see Mux.Dispatch(), or
+// buffered for transforms, see Mux.Transform().
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Serves a request in-process through the whole pipeline of this mux:
+// PreRoute hooks, matching, middleware, PostMatch hooks, authorization and
+// the route's handler, without an HTTP round-trip. Intended for background
+// jobs reusing route logic:
+//
+//	ctx = muxer.WithPrincipal(ctx, &muxer.Principal{Subject: "cron"})
+//	resp, err := m.Dispatch(ctx, "POST", "/api/reports/daily", nil)
+//
+// Path is absolute, including the base path of the mux, with an optional
+// query. The request has no headers, so authentication middleware should
+// look at the principal of ctx first. Errors are returned only for
+// malformed requests; other failures are in the response status code.
+func (dm *defaultMux) Dispatch(ctx context.Context, method, path string, body io.Reader) (*Response, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("Dispatch path '%s' must be absolute", path)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	return dm.serveInternal(req), nil
+}
+
+// Serves req in memory and returns the response.
+func (dm *defaultMux) serveInternal(req *http.Request) *Response {
+	w := &internalWriter{header: make(http.Header)}
+	dm.ServeHTTP(w, req)
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return &Response{StatusCode: w.code, Header: w.header, Body: w.body.Bytes()}
+}
+
+// In-memory ResponseWriter of a request served in-process.
+type internalWriter struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (w *internalWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *internalWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *internalWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
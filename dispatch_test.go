@@ -0,0 +1,50 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDispatch(t *testing.T) {
+	m := NewMux("/api", http.NewServeMux())
+	m.Use(func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+			w.Header().Set("X-Middleware", "1")
+			next(w, r, v)
+		}
+	})
+	m.Add("POST", "reports/{kind}", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		b, _ := io.ReadAll(r.Body)
+		p := PrincipalFrom(r.Context())
+		w.Header().Set("X-Kind", v.Get("kind"))
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(p.Subject + ":" + string(b) + ":" + r.URL.Query().Get("at")))
+	})
+
+	ctx := WithPrincipal(context.Background(), &Principal{Subject: "cron"})
+	resp, err := m.Dispatch(ctx, "POST", "/api/reports/daily?at=6", strings.NewReader("go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 202 {
+		t.Errorf("Expected 202 Accepted, got %d", resp.StatusCode)
+	}
+	assertEqual(t, resp.Header.Get("X-Kind"), "daily")
+	assertEqual(t, resp.Header.Get("X-Middleware"), "1")
+	assertEqual(t, string(resp.Body), "cron:go:6")
+
+	resp, err = m.Dispatch(ctx, "GET", "/api/reports/daily", nil)
+	if err != nil || resp.StatusCode != 405 {
+		t.Errorf("Expected 405, got %v, %v", resp, err)
+	}
+	if _, err := m.Dispatch(ctx, "GET", "api/reports", nil); err == nil {
+		t.Error("Expected an error for a relative path")
+	}
+}
@@ -0,0 +1,132 @@
+package muxer
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Documentation of a route in one language.
+type RouteDoc struct {
+	Lang        string
+	Summary     string
+	Description string
+}
+
+// Documents this route in language lang, a BCP 47 tag like "en" or
+// "pt-BR". Call it once per language; the first one is used where a
+// requested language is missing:
+//
+//	m.Add("POST", "users", createUser).
+//		Describe("en", "Create a user", "").
+//		Describe("de", "Benutzer anlegen", "")
+func (r *Route) Describe(lang, summary, description string) *Route {
+	for i, d := range r.docs {
+		if strings.EqualFold(d.Lang, lang) {
+			r.docs[i] = RouteDoc{lang, summary, description}
+			return r
+		}
+	}
+	r.docs = append(r.docs, RouteDoc{lang, summary, description})
+	return r
+}
+
+// Returns documentation of this route in language lang, falling back to
+// the base language, e.g. "pt" for "pt-BR", then to the first one added.
+// Reports false if the route isn't documented.
+func (r *Route) Doc(lang string) (RouteDoc, bool) {
+	if len(r.docs) == 0 {
+		return RouteDoc{}, false
+	}
+	for _, tag := range []string{lang, baseLang(lang)} {
+		for _, d := range r.docs {
+			if strings.EqualFold(d.Lang, tag) {
+				return d, true
+			}
+		}
+	}
+	return r.docs[0], true
+}
+
+// Returns primary subtag of language tag lang, e.g. "pt" for "pt-BR".
+func baseLang(lang string) string {
+	base, _, _ := strings.Cut(lang, "-")
+	return base
+}
+
+// Returns a handler responding with the OpenAPI document of m, see
+// GenerateOpenAPI(), with route docs in the language negotiated from
+// Accept-Language among those routes are documented in:
+//
+//	docs.Add("GET", "openapi.json", muxer.DocsHandler(m, "Shop API", "1.2"))
+//
+// The chosen language is sent in Content-Language.
+func DocsHandler(m Mux, title, version string) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		var langs []string
+		for _, route := range m.Routes() {
+			for _, d := range route.docs {
+				if !containsFold(langs, d.Lang) {
+					langs = append(langs, d.Lang)
+				}
+			}
+		}
+		lang := negotiateLang(r.Header.Get("Accept-Language"), langs)
+		var buf bytes.Buffer
+		if err := generateOpenAPI(&buf, m, title, version, lang); err != nil {
+			Error(w, r, err)
+			return
+		}
+		h := w.Header()
+		h.Set("Content-Type", "application/json")
+		h.Add("Vary", "Accept-Language")
+		if lang != "" {
+			h.Set("Content-Language", lang)
+		}
+		w.Write(buf.Bytes())
+	}
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the language of available preferred by Accept-Language header
+// value accept, matching tags or their base languages, or the first
+// available one if none is acceptable.
+func negotiateLang(accept string, available []string) string {
+	if len(available) == 0 {
+		return ""
+	}
+	best, bestQ := available[0], 0.0
+	for _, part := range strings.Split(accept, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, _ = strconv.ParseFloat(v, 64)
+		}
+		if q <= bestQ || tag == "" {
+			continue
+		}
+		if tag == "*" {
+			best, bestQ = available[0], q
+			continue
+		}
+		for _, lang := range available {
+			if strings.EqualFold(lang, tag) || strings.EqualFold(lang, baseLang(tag)) ||
+				strings.EqualFold(baseLang(lang), tag) {
+				best, bestQ = lang, q
+				break
+			}
+		}
+	}
+	return best
+}
@@ -0,0 +1,53 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestNegotiateLang(t *testing.T) {
+	available := []string{"en", "de", "pt-BR"}
+	for accept, want := range map[string]string{
+		"":                    "en",
+		"fr":                  "en",
+		"de":                  "de",
+		"de-AT,en;q=0.5":      "de",
+		"en;q=0.5,pt":         "pt-BR",
+		"fr,*;q=0.1":          "en",
+		"de;q=0, en;q=0.1":    "en",
+		"PT-br;q=0.9, de;q=1": "de",
+	} {
+		if got := negotiateLang(accept, available); got != want {
+			t.Errorf("negotiateLang(%q) = %q, want %q", accept, got, want)
+		}
+	}
+}
+
+func TestDocsHandler(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("POST", "users", dummy).
+		Describe("en", "Create a user", "Adds a user.").
+		Describe("de", "Benutzer anlegen", "")
+	m.Add("GET", "users", dummy).Describe("en", "List users", "")
+	m.Add("GET", "openapi.json", DocsHandler(m, "Test", "1"))
+
+	req, _ := http.NewRequest("GET", "/openapi.json", nil)
+	req.Header.Set("Accept-Language", "de-CH, en;q=0.5")
+	w := serveRequest(m, req)
+	assertEqual(t, w.Header().Get("Content-Language"), "de")
+	assertEqual(t, w.Header().Get("Vary"), "Accept-Language")
+	var doc struct {
+		Paths map[string]map[string]struct{ Summary, Description string }
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, doc.Paths["/users"]["post"].Summary, "Benutzer anlegen")
+	assertEqual(t, doc.Paths["/users"]["post"].Description, "")
+	// Not translated, falls back to the first language.
+	assertEqual(t, doc.Paths["/users"]["get"].Summary, "List users")
+}
@@ -0,0 +1,72 @@
+package muxer
+
+import "sync"
+
+// Requests in flight of a route, so that deploy tooling can wait for
+// those started before the route was disabled.
+type routeDrain struct {
+	mu      sync.Mutex
+	waiters []chan struct{}
+}
+
+// Returns a channel closed once this route is disabled and no requests
+// it matched are being served anymore, e.g. to tear down a backend of a
+// retired endpoint only after its last request:
+//
+//	r.Disable()
+//	select {
+//	case <-r.Drained():
+//		backend.Close()
+//	case <-time.After(time.Minute):
+//		log.Print("route still busy, closing anyway")
+//		backend.Close()
+//	}
+//
+// New requests aren't matched by a disabled route, while those already
+// being served complete normally. The channel isn't closed while the route
+// is enabled.
+func (r *Route) Drained() <-chan struct{} {
+	ch := make(chan struct{})
+	d := &r.live.drain
+	d.mu.Lock()
+	d.waiters = append(d.waiters, ch)
+	d.mu.Unlock()
+	r.live.checkDrained()
+	return ch
+}
+
+// Returns number of requests of this route being served.
+func (r *Route) InFlight() int {
+	return int(r.live.inflight.Load())
+}
+
+// Counts a request matched by the route. Reports false if the route got
+// disabled in the meantime, the request mustn't be served then.
+func (l *routeLive) enter() bool {
+	l.inflight.Add(1)
+	if l.disabled.Load() {
+		l.leave()
+		return false
+	}
+	return true
+}
+
+func (l *routeLive) leave() {
+	if l.inflight.Add(-1) == 0 && l.disabled.Load() {
+		l.checkDrained()
+	}
+}
+
+// Closes channels returned by Drained() once the route is drained.
+func (l *routeLive) checkDrained() {
+	if !l.disabled.Load() || l.inflight.Load() != 0 {
+		return
+	}
+	d := &l.drain
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, ch := range d.waiters {
+		close(ch)
+	}
+	d.waiters = nil
+}
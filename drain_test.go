@@ -0,0 +1,50 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestDrained(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	started, release := make(chan struct{}), make(chan struct{})
+	r := m.Add("GET", "slow", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		close(started)
+		<-release
+		w.Write([]byte("done"))
+	})
+	drained := r.Drained()
+
+	done := make(chan string)
+	go func() { done <- serve(m, "GET", "/slow").Body.String() }()
+	<-started
+	if n := r.InFlight(); n != 1 {
+		t.Errorf("InFlight() = %d, want 1", n)
+	}
+	r.Disable()
+	if w := serve(m, "GET", "/slow"); w.Code != http.StatusNotFound {
+		t.Errorf("new request to a draining route: %d, want 404", w.Code)
+	}
+	select {
+	case <-drained:
+		t.Fatal("drained while a request is in flight")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(release)
+	assertEqual(t, <-done, "done")
+	select {
+	case <-drained:
+	case <-time.After(time.Second):
+		t.Fatal("not drained after the last request")
+	}
+	select {
+	case <-r.Drained():
+	default:
+		t.Error("Drained() of an idle disabled route not closed")
+	}
+}
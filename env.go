@@ -0,0 +1,52 @@
+package muxer
+
+import "fmt"
+
+// Sets the environment this mux runs in, e.g. "dev" or "production",
+// see Route.OnlyIn(). Call it before adding routes.
+func (dm *defaultMux) Environment(name string) {
+	dm.env = name
+}
+
+// Returns the environment set with Environment().
+func (dm *defaultMux) Env() string {
+	return dm.env
+}
+
+// Keeps this route only in environments envs, see Mux.Environment().
+// Elsewhere the route is removed from the route table, so debug or seed
+// endpoints don't exist in production at all:
+//
+//	m.Environment(os.Getenv("APP_ENV"))
+//	m.Add("POST", "debug/seed", seed).OnlyIn("dev", "staging")
+//
+// A removed route isn't served, listed by Routes() or used by BuildPath().
+// Panics if the mux environment isn't set, so a missing setting doesn't
+// expose such routes.
+func (r *Route) OnlyIn(envs ...string) *Route {
+	dm := r.group.mux
+	if dm.env == "" {
+		panic(fmt.Sprintf("Route '%s %s' is environment specific but the mux environment isn't set",
+			r.Method, r.Pattern))
+	}
+	if !containsString(envs, dm.env) {
+		dm.remove(r)
+	}
+	return r
+}
+
+// Removes route r from the route table.
+func (dm *defaultMux) remove(r *Route) {
+	dm.mu.Lock()
+	for i, route := range dm.routes {
+		if route == r {
+			// Published snapshots share the array, so copy the rest.
+			dm.routes = append(dm.routes[:i:i], dm.routes[i+1:]...)
+			dm.publish()
+			dm.mu.Unlock()
+			dm.events.emit(RouteRemoved, r, nil)
+			return
+		}
+	}
+	dm.mu.Unlock()
+}
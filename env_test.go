@@ -0,0 +1,38 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestOnlyIn(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Environment("production")
+	m.Add("GET", "a", dummy)
+	m.Add("POST", "debug/seed", dummy).OnlyIn("dev", "staging").As("seed")
+	m.Add("GET", "debug/vars", dummy).OnlyIn("dev", "production")
+	m.Add("GET", "b", dummy)
+
+	var patterns []string
+	for _, r := range m.Routes() {
+		patterns = append(patterns, r.Pattern)
+	}
+	assertEqual(t, fmt.Sprint(patterns), "[a debug/vars b]")
+	if w := serve(m, "POST", "/debug/seed"); w.Code != http.StatusNotFound {
+		t.Errorf("POST /debug/seed = %d, want 404", w.Code)
+	}
+	assertEqual(t, serve(m, "GET", "/debug/vars").Body.String(), "params:")
+}
+
+func TestOnlyInWithoutEnvironment(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("OnlyIn() without an environment didn't panic")
+		}
+	}()
+	NewMux("/", http.NewServeMux()).Add("GET", "a", dummy).OnlyIn("dev")
+}
@@ -0,0 +1,132 @@
+package muxer
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Kind of a mux lifecycle event, see Mux.Subscribe().
+type EventKind int
+
+const (
+	// A route has been added.
+	RouteAdded EventKind = iota + 1
+	// A route has been removed, see Route.OnlyIn().
+	RouteRemoved
+	// A route has been disabled or enabled.
+	RouteDisabled
+	RouteEnabled
+	// The route table has been frozen, see Mux.UseMatcher().
+	TableFrozen
+	// RouteConfig of a route has been changed, see Mux.Configure() and
+	// Mux.AutoThrottle().
+	ConfigChanged
+	// A panic of a route's handler has been recovered, and a route has
+	// been tripped by too many of them, see Mux.IsolatePanics().
+	PanicRecovered
+	RouteTripped
+)
+
+var eventKinds = map[EventKind]string{
+	RouteAdded:     "route added",
+	RouteRemoved:   "route removed",
+	RouteDisabled:  "route disabled",
+	RouteEnabled:   "route enabled",
+	TableFrozen:    "table frozen",
+	ConfigChanged:  "config changed",
+	PanicRecovered: "panic recovered",
+	RouteTripped:   "route tripped",
+}
+
+func (k EventKind) String() string {
+	if s, ok := eventKinds[k]; ok {
+		return s
+	}
+	return "unknown event"
+}
+
+// Change of the state of a mux.
+type Event struct {
+	Kind EventKind
+	// Route the event is about, nil for TableFrozen.
+	Route *Route
+	Time  time.Time
+	// Recovered value of PanicRecovered events.
+	Panic interface{}
+}
+
+// Function type receiving events, see Mux.Subscribe().
+type EventFunc func(e Event)
+
+type subscriber struct {
+	f     EventFunc
+	kinds []EventKind
+}
+
+// Makes this mux call f with its events of kinds, or all of them if none
+// are given, e.g. for operational tooling or tests waiting for a route to
+// be tripped. Events are delivered synchronously by the goroutine causing
+// them, after the change, so f must not block; it may change the mux.
+// Clones have no subscribers. Returns a function cancelling the
+// subscription.
+func (dm *defaultMux) Subscribe(f EventFunc, kinds ...EventKind) (unsubscribe func()) {
+	s := &subscriber{f, kinds}
+	dm.events.update(func(subs []*subscriber) []*subscriber {
+		return append(subs, s)
+	})
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			dm.events.update(func(subs []*subscriber) []*subscriber {
+				for i, sub := range subs {
+					if sub == s {
+						return append(subs[:i:i], subs[i+1:]...)
+					}
+				}
+				return subs
+			})
+		})
+	}
+}
+
+// Subscribers of a mux, read without locking on every event.
+type eventBus struct {
+	mu   sync.Mutex
+	subs atomic.Pointer[[]*subscriber]
+}
+
+// Replaces subscribers with the result of f.
+func (b *eventBus) update(f func([]*subscriber) []*subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var subs []*subscriber
+	if p := b.subs.Load(); p != nil {
+		subs = *p
+	}
+	subs = f(subs)
+	b.subs.Store(&subs)
+}
+
+// Delivers an event of kind about route r to subscribers.
+func (b *eventBus) emit(kind EventKind, r *Route, panicked interface{}) {
+	p := b.subs.Load()
+	if p == nil || len(*p) == 0 {
+		return
+	}
+	e := Event{Kind: kind, Route: r, Time: time.Now(), Panic: panicked}
+	for _, s := range *p {
+		if len(s.kinds) == 0 || containsKind(s.kinds, kind) {
+			s.f(e)
+		}
+	}
+}
+
+func containsKind(kinds []EventKind, k EventKind) bool {
+	for _, kind := range kinds {
+		if kind == k {
+			return true
+		}
+	}
+	return false
+}
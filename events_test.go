@@ -0,0 +1,65 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Environment("test")
+	m.IsolatePanics(1, time.Minute, func(*http.Request, *Route, interface{}) {})
+	var got []string
+	unsubscribe := m.Subscribe(func(e Event) {
+		s := e.Kind.String()
+		if e.Route != nil {
+			s += " " + e.Route.Pattern
+		}
+		if e.Panic != nil {
+			s += " " + e.Panic.(string)
+		}
+		got = append(got, s)
+	})
+	var configs int
+	m.Subscribe(func(e Event) { configs++ }, ConfigChanged)
+
+	a := m.Add("GET", "a", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		panic("boom")
+	}).As("a")
+	m.Add("GET", "b", dummy).OnlyIn("production")
+	a.Disable().Enable()
+	m.Configure("a", RouteConfig{Timeout: time.Second})
+	m.Configure("a", RouteConfig{})
+	serve(m, "GET", "/a")
+	m.UseMatcher(func(method, path string) (int, url.Values) { return -1, nil }, m.Fingerprint())
+
+	want := []string{
+		"route added a",
+		"route added b",
+		"route removed b",
+		"route disabled a",
+		"route enabled a",
+		"config changed a",
+		"config changed a",
+		"panic recovered a boom",
+		"route tripped a boom",
+		"table frozen",
+	}
+	assertEqual(t, strings.Join(got, "\n"), strings.Join(want, "\n"))
+	if configs != 2 {
+		t.Errorf("got %d config events, want 2", configs)
+	}
+
+	unsubscribe()
+	unsubscribe()
+	a.Disable()
+	if len(got) != len(want) {
+		t.Errorf("event delivered after unsubscribing: %v", got[len(want):])
+	}
+}
@@ -0,0 +1,234 @@
+package muxer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Request and response served by a route, recorded as an example for
+// documentation, see Mux.RecordExamples().
+type Example struct {
+	Method string
+	// URL path and query of the request.
+	Path, Query  string
+	RequestBody  []byte
+	Status       int
+	ContentType  string
+	ResponseBody []byte
+}
+
+// Function type changing an example before it's stored, e.g. to remove
+// personal data from bodies.
+type SanitizeFunc func(e *Example)
+
+// Max size of a recorded body, longer ones are left out.
+const maxExampleBody = 16 << 10
+
+// Makes this mux record the first request and response of each status code
+// of every route as examples, see Route.Examples(), which GenerateOpenAPI()
+// adds to the document. Meant for dev and staging, e.g. to run
+// integration tests against and then generate the docs.
+//
+// JSON bodies are sanitized by replacing values of fields with names
+// suggesting credentials, e.g. "password" or "api_key", and sensitive
+// params of the redaction policy, see Redact(), then by sanitize, if not
+// nil. Request bodies are recorded as far as handlers read them, bodies
+// longer than 16KiB aren't recorded. Call it before serving requests.
+func (dm *defaultMux) RecordExamples(sanitize SanitizeFunc) {
+	dm.examples = &exampleRecorder{sanitize: sanitize}
+}
+
+type exampleRecorder struct {
+	sanitize SanitizeFunc
+}
+
+// Examples of a route by status code.
+type routeExamples struct {
+	mu       sync.Mutex
+	byStatus map[int]Example
+	// Status codes in the order they were first recorded.
+	order []int
+}
+
+// Returns examples recorded for this route, one for each status code.
+func (r *Route) Examples() []Example {
+	x := &r.live.examples
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	examples := make([]Example, 0, len(x.order))
+	for _, code := range x.order {
+		examples = append(examples, x.byStatus[code])
+	}
+	return examples
+}
+
+// Returns h recording examples of route r.
+func (er *exampleRecorder) wrap(r *Route, h HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request, v url.Values) {
+		var tee *teeBody
+		if req.Body != nil && req.Body != http.NoBody {
+			tee = &teeBody{ReadCloser: req.Body, max: maxExampleBody}
+			req.Body = tee
+		}
+		ew := &exampleWriter{ResponseWriter: w}
+		h(ew, req, v)
+		if ew.code == 0 {
+			ew.code = http.StatusOK
+		}
+		var reqBody []byte
+		if tee != nil {
+			if tee.truncated {
+				return
+			}
+			reqBody = tee.buf
+		}
+		if ew.body.Len() > maxExampleBody {
+			return
+		}
+		x := &r.live.examples
+		x.mu.Lock()
+		_, seen := x.byStatus[ew.code]
+		x.mu.Unlock()
+		if seen {
+			return
+		}
+		rd := r.group.mux.redaction
+		sensitive := func(field string) bool {
+			return isSensitiveField(field) || rd.SensitiveParam(field)
+		}
+		e := Example{
+			Method:       req.Method,
+			Path:         req.URL.Path,
+			Query:        req.URL.RawQuery,
+			RequestBody:  sanitizeBody(req.Header.Get("Content-Type"), reqBody, sensitive, rd != nil),
+			Status:       ew.code,
+			ContentType:  w.Header().Get("Content-Type"),
+			ResponseBody: sanitizeBody(w.Header().Get("Content-Type"), ew.body.Bytes(), sensitive, rd != nil),
+		}
+		if rd != nil {
+			e.Path = r.redactPath(e.Path)
+			e.Query = redactQuery(rd, e.Query)
+		}
+		if er.sanitize != nil {
+			er.sanitize(&e)
+		}
+		x.mu.Lock()
+		defer x.mu.Unlock()
+		if _, seen := x.byStatus[e.Status]; !seen {
+			if x.byStatus == nil {
+				x.byStatus = make(map[int]Example)
+			}
+			x.byStatus[e.Status] = e
+			x.order = append(x.order, e.Status)
+		}
+	}
+}
+
+// Keeps a copy of up to maxExampleBody+1 bytes of a response.
+type exampleWriter struct {
+	http.ResponseWriter
+	code int
+	body bytes.Buffer
+}
+
+func (w *exampleWriter) WriteHeader(code int) {
+	if w.code == 0 && code >= 200 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *exampleWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	if n := maxExampleBody + 1 - w.body.Len(); n > 0 {
+		w.body.Write(b[:min(n, len(b))])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *exampleWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *exampleWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Parts of field names suggesting a credential.
+var sensitiveFields = []string{"password", "passwd", "secret", "token", "apikey", "api_key", "authorization", "cookie"}
+
+// Returns body b of content type ct with values of JSON or form fields
+// for which sensitive reports true replaced. Bodies which can't be parsed,
+// e.g. truncated ones, are replaced as a whole if strict, or returned as
+// is otherwise.
+func sanitizeBody(ct string, b []byte, sensitive func(field string) bool, strict bool) []byte {
+	if len(b) == 0 {
+		return b
+	}
+	var v interface{}
+	if json.Unmarshal(b, &v) == nil {
+		if out, err := json.Marshal(redactValue(v, sensitive)); err == nil {
+			return out
+		}
+	} else if mt, _, _ := mime.ParseMediaType(ct); mt == "application/x-www-form-urlencoded" {
+		if form, err := url.ParseQuery(string(b)); err == nil {
+			for k := range form {
+				if sensitive(k) {
+					form[k] = []string{redacted}
+				}
+			}
+			return []byte(form.Encode())
+		}
+	}
+	if strict {
+		return []byte(fmt.Sprintf("%s (%d bytes not parsed)", redacted, len(b)))
+	}
+	return b
+}
+
+func redactValue(v interface{}, sensitive func(string) bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, fv := range v {
+			if sensitive(k) {
+				v[k] = redacted
+			} else {
+				v[k] = redactValue(fv, sensitive)
+			}
+		}
+	case []interface{}:
+		for i, ev := range v {
+			v[i] = redactValue(ev, sensitive)
+		}
+	}
+	return v
+}
+
+func isSensitiveField(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range sensitiveFields {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns b decoded as JSON, or nil if it isn't JSON.
+func exampleValue(b []byte) interface{} {
+	var v interface{}
+	if len(b) == 0 || json.Unmarshal(b, &v) != nil {
+		return nil
+	}
+	return v
+}
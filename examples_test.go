@@ -0,0 +1,97 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRecordExamples(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.RecordExamples(func(e *Example) {
+		e.Query = ""
+	})
+	r := m.Add("POST", "login", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), "alice") {
+			http.Error(w, "bad", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"user":"alice","session_token":"abc"}`))
+	})
+
+	login := func(body string) {
+		req, _ := http.NewRequest("POST", "/login?debug=1", strings.NewReader(body))
+		serveRequest(m, req)
+	}
+	login(`{"user":"alice","password":"hunter2"}`)
+	login(`{"user":"bob","password":"x"}`)
+	login(`{"user":"alice","password":"other"}`)
+
+	examples := r.Examples()
+	if len(examples) != 2 {
+		t.Fatalf("got %d examples, want 2", len(examples))
+	}
+	e := examples[0]
+	if e.Status != 200 || e.Query != "" || e.ContentType != "application/json" {
+		t.Errorf("example = %+v", e)
+	}
+	assertEqual(t, string(e.RequestBody), `{"password":"REDACTED","user":"alice"}`)
+	assertEqual(t, string(e.ResponseBody), `{"session_token":"REDACTED","user":"alice"}`)
+	if examples[1].Status != http.StatusUnauthorized {
+		t.Errorf("second example status = %d", examples[1].Status)
+	}
+
+	var buf bytes.Buffer
+	GenerateOpenAPI(&buf, m, "Test", "1")
+	var doc struct {
+		Paths map[string]map[string]struct {
+			RequestBody struct {
+				Content map[string]struct{ Example map[string]string }
+			}
+			Responses map[string]struct{ Description string }
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	op := doc.Paths["/login"]["post"]
+	assertEqual(t, op.RequestBody.Content["application/json"].Example["password"], "REDACTED")
+	assertEqual(t, op.Responses["401"].Description, "Unauthorized")
+}
+
+// Counts reads of a request body.
+type countingReader struct {
+	io.Reader
+	reads int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	r.reads++
+	return r.Reader.Read(p)
+}
+
+func TestRecordExamplesUnreadBody(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.RecordExamples(nil)
+	r := m.Add("POST", "upload", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		http.Error(w, "too large", http.StatusRequestEntityTooLarge)
+	})
+	body := &countingReader{Reader: strings.NewReader(`{"x":1}`)}
+	req, _ := http.NewRequest("POST", "/upload", body)
+	serveRequest(m, req)
+	if body.reads != 0 {
+		t.Errorf("Expected the body not to be read, got %d reads", body.reads)
+	}
+	if examples := r.Examples(); len(examples) != 1 || len(examples[0].RequestBody) != 0 {
+		t.Errorf("examples = %+v", examples)
+	}
+}
@@ -0,0 +1,49 @@
+package muxer
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// Faults injected into requests of a route, see RouteConfig. Each rate is
+// a probability between 0 and 1 of the fault happening to a request:
+//
+//	m.Configure("checkout", muxer.RouteConfig{Faults: &muxer.Faults{
+//		DelayRate: 0.2, Delay: 2 * time.Second,
+//		ErrorRate: 0.05,
+//	}})
+//
+// and muxer.RouteConfig{} to stop injecting them.
+type Faults struct {
+	// Delays requests by Delay before calling the handler, or less if the
+	// request is canceled.
+	DelayRate float64
+	Delay     time.Duration
+	// Responds with 500 Internal Server Error without calling the handler.
+	ErrorRate float64
+	// Drops the connection without a response.
+	DropRate float64
+}
+
+// Injects faults into request r. Reports false if the request has been
+// served with a fault.
+func (f *Faults) inject(w http.ResponseWriter, r *http.Request) bool {
+	if f.DropRate > 0 && rand.Float64() < f.DropRate {
+		// Makes net/http close the connection without logging.
+		panic(http.ErrAbortHandler)
+	}
+	if f.DelayRate > 0 && rand.Float64() < f.DelayRate {
+		t := time.NewTimer(f.Delay)
+		select {
+		case <-t.C:
+		case <-r.Context().Done():
+			t.Stop()
+		}
+	}
+	if f.ErrorRate > 0 && rand.Float64() < f.ErrorRate {
+		Error(w, r, NewStatusError(http.StatusInternalServerError, "Injected fault"))
+		return false
+	}
+	return true
+}
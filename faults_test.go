@@ -0,0 +1,49 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestFaults(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "a", dummy).As("a")
+
+	m.Configure("a", RouteConfig{Faults: &Faults{ErrorRate: 1}})
+	if w := serve(m, "GET", "/a"); w.Code != http.StatusInternalServerError {
+		t.Errorf("code = %d, want 500", w.Code)
+	}
+
+	m.Configure("a", RouteConfig{Faults: &Faults{DelayRate: 1, Delay: 20 * time.Millisecond}})
+	start := time.Now()
+	assertEqual(t, serve(m, "GET", "/a").Body.String(), "params:")
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Errorf("served in %v, want a delay of 20ms", d)
+	}
+
+	m.Configure("a", RouteConfig{Faults: &Faults{DropRate: 1}})
+	func() {
+		defer func() {
+			if p := recover(); p != http.ErrAbortHandler {
+				t.Errorf("recovered %v, want http.ErrAbortHandler", p)
+			}
+		}()
+		serve(m, "GET", "/a")
+	}()
+
+	m.Configure("a", RouteConfig{})
+	assertEqual(t, serve(m, "GET", "/a").Body.String(), "params:")
+}
+
+func TestFaultsInProduction(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Environment("production")
+	m.Add("GET", "a", dummy).As("a")
+	if err := m.Configure("a", RouteConfig{Faults: &Faults{ErrorRate: 1}}); err == nil {
+		t.Error("faults configured in production")
+	}
+}
@@ -0,0 +1,108 @@
+package muxer
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Returns a transform keeping only fields listed in query param param of
+// JSON responses, so clients can ask for sparse fieldsets, e.g. with
+// ?fields=id,author.name, of routes opting in with a tag:
+//
+//	m.Transform("sparse", muxer.FieldsFilter("fields"))
+//	m.Add("GET", "posts", listPosts).Tag("sparse")
+//
+// Fields of nested objects are separated by dots. Arrays are filtered
+// element by element, at the top level and in nested fields. Responses
+// without the param, of other content types or with error status codes
+// are left as they are. Keys of filtered objects are sorted.
+func FieldsFilter(param string) TransformFunc {
+	return func(r *http.Request, resp *Response) error {
+		fields := r.URL.Query().Get(param)
+		if fields == "" || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return nil
+		}
+		if mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mt != "application/json" {
+			return nil
+		}
+		tree := fieldTree{}
+		for _, f := range strings.Split(fields, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				tree.add(strings.Split(f, "."))
+			}
+		}
+		filtered, err := tree.filter(resp.Body)
+		if err != nil {
+			// Not valid JSON, the handler's business.
+			return nil
+		}
+		resp.Body = append(filtered, '\n')
+		return nil
+	}
+}
+
+// Selected fields by name, with nested ones. A field without nested
+// ones is kept as a whole.
+type fieldTree map[string]fieldTree
+
+func (t fieldTree) add(path []string) {
+	sub, ok := t[path[0]]
+	if ok && sub == nil {
+		// Already kept as a whole.
+		return
+	}
+	if len(path) == 1 {
+		t[path[0]] = nil
+		return
+	}
+	if sub == nil {
+		sub = fieldTree{}
+		t[path[0]] = sub
+	}
+	sub.add(path[1:])
+}
+
+// Returns JSON value b with only the fields of t.
+func (t fieldTree) filter(b []byte) ([]byte, error) {
+	b = bytes.TrimSpace(b)
+	switch {
+	case len(b) > 0 && b[0] == '[':
+		var elems []json.RawMessage
+		if err := json.Unmarshal(b, &elems); err != nil {
+			return nil, err
+		}
+		for i, e := range elems {
+			f, err := t.filter(e)
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = f
+		}
+		return json.Marshal(elems)
+	case len(b) > 0 && b[0] == '{':
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(b, &obj); err != nil {
+			return nil, err
+		}
+		kept := make(map[string]json.RawMessage, len(t))
+		for name, sub := range t {
+			v, ok := obj[name]
+			if !ok {
+				continue
+			}
+			if sub != nil {
+				var err error
+				if v, err = sub.filter(v); err != nil {
+					return nil, err
+				}
+			}
+			kept[name] = v
+		}
+		return json.Marshal(kept)
+	}
+	// Scalars have no fields to select.
+	return b, nil
+}
@@ -0,0 +1,39 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestFieldsFilter(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Transform("sparse", FieldsFilter("fields"))
+	m.Add("GET", "posts", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write([]byte(`[{"id":1,"title":"a","author":{"name":"x","email":"e"},"tags":[{"n":"go","c":2}]},
+			{"id":2,"title":"b","author":null}]`))
+	}).Tag("sparse")
+
+	for query, want := range map[string]string{
+		"":                                 "",
+		"?fields=id":                       `[{"id":1},{"id":2}]` + "\n",
+		"?fields=id,author.name":           `[{"author":{"name":"x"},"id":1},{"author":null,"id":2}]` + "\n",
+		"?fields=tags.n,missing":           `[{"tags":[{"n":"go"}]},{}]` + "\n",
+		"?fields=author.name,author,title": `[{"author":{"name":"x","email":"e"},"title":"a"},{"author":null,"title":"b"}]` + "\n",
+	} {
+		got := serve(m, "GET", "/posts"+query).Body.String()
+		if want == "" {
+			if len(got) < 100 {
+				t.Errorf("unfiltered response = %s", got)
+			}
+			continue
+		}
+		if got != want {
+			t.Errorf("%s: got %s, want %s", query, got, want)
+		}
+	}
+}
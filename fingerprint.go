@@ -0,0 +1,21 @@
+package muxer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// Returns a stable hex-encoded SHA-256 hash of the base path and method,
+// pattern and name of every route, in the order they were added.
+// Since the order affects matching, muxers with the same routes added
+// in a different order have different fingerprints.
+// Handlers and runtime state, e.g. disabled routes, aren't included.
+func (dm *defaultMux) Fingerprint() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%q\n", dm.base)
+	for _, r := range dm.snapshot() {
+		fmt.Fprintf(h, "%q %q %q\n", r.Method, r.Pattern, r.Name)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
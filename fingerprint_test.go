@@ -0,0 +1,40 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	build := func(base string, patterns ...string) Mux {
+		m := NewMux(base, http.NewServeMux())
+		for _, p := range patterns {
+			m.Add("GET", p, dummy)
+		}
+		return m
+	}
+	a := build("/api", "users/{id}", "products")
+	fp := a.Fingerprint()
+	if len(fp) != 64 {
+		t.Fatalf("Expected 64 hex chars, got '%s'", fp)
+	}
+	assertEqual(t, build("/api", "users/{id}", "products").Fingerprint(), fp)
+	assertEqual(t, a.Clone().Fingerprint(), fp)
+
+	for _, m := range []Mux{
+		build("/api", "products", "users/{id}"),
+		build("/v1", "users/{id}", "products"),
+		build("/api", "users/{id}"),
+	} {
+		if m.Fingerprint() == fp {
+			t.Fatalf("Expected different fingerprint for %v", m.Routes())
+		}
+	}
+	a.Routes()[1].As("list")
+	if a.Fingerprint() == fp {
+		t.Fatalf("Expected route name to change the fingerprint")
+	}
+}
@@ -0,0 +1,182 @@
+package muxer
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Element of a Forwarded header, see RFC 7239, describing one hop of
+// a request through proxies. Fields are "" if not given.
+type ForwardedElement struct {
+	// Node which sent the request to the proxy and the proxy itself,
+	// e.g. "192.0.2.60", "[2001:db8::1]:4711", "unknown" or "_hidden".
+	For, By string
+	// Host header and scheme of the request the proxy received.
+	Host, Proto string
+}
+
+// Returns the address of For if it's an IP address, with an optional port.
+func (e ForwardedElement) ForAddr() (netip.Addr, bool) {
+	return nodeAddr(e.For)
+}
+
+// Parses values of Forwarded headers, e.g. req.Header.Values("Forwarded"),
+// into elements in the order proxies added them, the proxy closest to the
+// client first. Parameter names are case-insensitive, values are unquoted
+// and Proto is lower-cased. Returns an error for malformed values.
+func ParseForwarded(values []string) ([]ForwardedElement, error) {
+	var elems []ForwardedElement
+	for _, v := range values {
+		for len(v) > 0 {
+			var e ForwardedElement
+			var err error
+			if e, v, err = parseForwardedElement(v); err != nil {
+				return nil, err
+			}
+			elems = append(elems, e)
+		}
+	}
+	return elems, nil
+}
+
+// Parses the first element of Forwarded header value v and returns it with
+// the rest of v after the comma.
+func parseForwardedElement(v string) (ForwardedElement, string, error) {
+	var e ForwardedElement
+	for {
+		v = strings.TrimLeft(v, " \t")
+		if v == "" || v[0] == ',' {
+			return e, strings.TrimPrefix(v, ","), nil
+		}
+		eq := strings.IndexByte(v, '=')
+		if eq <= 0 {
+			return e, "", fmt.Errorf("muxer: malformed Forwarded pair in %q", v)
+		}
+		name := strings.ToLower(strings.TrimSpace(v[:eq]))
+		v = v[eq+1:]
+		var value string
+		if strings.HasPrefix(v, `"`) {
+			end := 1
+			var b strings.Builder
+			for ; end < len(v) && v[end] != '"'; end++ {
+				if v[end] == '\\' && end+1 < len(v) {
+					end++
+				}
+				b.WriteByte(v[end])
+			}
+			if end == len(v) {
+				return e, "", fmt.Errorf("muxer: unterminated quoted string in Forwarded")
+			}
+			value, v = b.String(), v[end+1:]
+		} else {
+			end := strings.IndexAny(v, ";, \t")
+			if end < 0 {
+				end = len(v)
+			}
+			value, v = v[:end], v[end:]
+		}
+		switch name {
+		case "for":
+			e.For = value
+		case "by":
+			e.By = value
+		case "host":
+			e.Host = value
+		case "proto":
+			e.Proto = strings.ToLower(value)
+		}
+		v = strings.TrimLeft(v, " \t")
+		if strings.HasPrefix(v, ";") {
+			v = v[1:]
+		} else if v != "" && v[0] != ',' {
+			return e, "", fmt.Errorf("muxer: malformed Forwarded element near %q", v)
+		}
+	}
+}
+
+// Returns IP address of node name n of a Forwarded element, e.g.
+// "192.0.2.60:80" or "[2001:db8::1]".
+func nodeAddr(n string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(n); err == nil {
+		n = host
+	}
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(n, "["), "]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// Returns the Forwarded element of req describing the client, the last
+// one not added by a trusted proxy, see TrustProxies(). Reports false if
+// req doesn't come from a trusted proxy or has no valid Forwarded header.
+func (dm *defaultMux) forwarded(req *http.Request) (ForwardedElement, bool) {
+	values := req.Header.Values("Forwarded")
+	if len(values) == 0 || !dm.fromProxy(req) {
+		return ForwardedElement{}, false
+	}
+	elems, err := ParseForwarded(values)
+	if err != nil || len(elems) == 0 {
+		return ForwardedElement{}, false
+	}
+	// Element i was added by the proxy the element i+1 is for.
+	i := len(elems) - 1
+	for ; i > 0; i-- {
+		addr, ok := elems[i].ForAddr()
+		if !ok || !dm.trustedProxy(addr) {
+			break
+		}
+	}
+	return elems[i], true
+}
+
+// Returns IP address of the client of req: the one proxies trusted by this
+// mux, see TrustProxies(), got the request from according to the
+// Forwarded header, or X-Forwarded-For if there's none, or the remote
+// address of req. Returns "" if it isn't known, e.g. "unknown" in
+// Forwarded.
+func (dm *defaultMux) ClientIP(req *http.Request) string {
+	if e, ok := dm.forwarded(req); ok {
+		if addr, ok := e.ForAddr(); ok {
+			return addr.String()
+		}
+		return ""
+	}
+	if dm.fromProxy(req) {
+		if xff := req.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+			hops := strings.Split(strings.Join(xff, ","), ",")
+			for i := len(hops) - 1; i >= 0; i-- {
+				addr, ok := nodeAddr(strings.TrimSpace(hops[i]))
+				if !ok {
+					return ""
+				}
+				if i == 0 || !dm.trustedProxy(addr) {
+					return addr.String()
+				}
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	return host
+}
+
+// Returns host of req as sent by the client: the one of the Forwarded
+// header of a trusted proxy, or Host of the request.
+func (dm *defaultMux) host(req *http.Request) string {
+	if e, ok := dm.forwarded(req); ok && e.Host != "" {
+		return e.Host
+	}
+	return req.Host
+}
+
+// Returns absolute URL of the named route on the host and with the scheme
+// req was sent to, as seen through trusted proxies, see BuildPathFor().
+func (dm *defaultMux) BuildURL(req *http.Request, name string, params ...interface{}) string {
+	return dm.scheme(req) + "://" + dm.host(req) + dm.BuildPathFor(req, name, params...)
+}
@@ -0,0 +1,123 @@
+package muxer
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// GenerateClient writes to w the source of a Go file of package pkg with
+// a typed API client for the named routes of m. For a route named "profile"
+// with pattern "users/{id}" it generates:
+//
+//	type ProfileParams struct {
+//		Id string
+//	}
+//
+//	func (c *Client) Profile(ctx context.Context, p ProfileParams, body io.Reader) (*http.Response, error)
+//
+// Params are path-escaped. Routes without params get no params struct.
+// Fields of params named alike, e.g. of "{id}/x/{id}", are numbered: Id
+// and Id2.
+func GenerateClient(w io.Writer, m Mux, pkg string) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by muxer.GenerateClient. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	fmt.Fprintf(&buf, "%s", `import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var _ = url.PathEscape
+
+// Client calls the API routes. BaseURL is scheme and host of the API server,
+// e.g. "https://api.example.com".
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewClient creates a Client using http.DefaultClient.
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: baseURL, HTTPClient: http.DefaultClient}
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.BaseURL, "/")+path, body)
+	if err != nil {
+		return nil, err
+	}
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return hc.Do(req)
+}
+`)
+	for _, r := range m.Routes() {
+		if r.Name != "" {
+			writeClientMethod(&buf, m.BasePath(), r)
+		}
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// Writes params struct and Client method for named route r.
+func writeClientMethod(w io.Writer, base string, r *Route) {
+	name := goIdent(r.Name, true)
+	var fields []string
+	seen := make(map[string]bool)
+	expr := []string{}
+	static := strings.TrimSuffix(base, "/")
+	for _, rp := range r.parts {
+		if rp.name == "" && !rp.isVar {
+			continue
+		}
+		static += "/"
+		if !rp.isVar {
+			static += rp.name
+			continue
+		}
+		field := uniqueName(seen, goIdent(rp.name, true))
+		fields = append(fields, field)
+		escaped := "url.PathEscape(p." + field + ")"
+		if rp.rest {
+			// Slashes of a catch-all separate segments.
+			escaped = `strings.ReplaceAll(` + escaped + `, "%2F", "/")`
+		}
+		expr = append(expr, strconv.Quote(static), escaped)
+		static = ""
+	}
+	if static == "" && len(expr) == 0 {
+		static = "/"
+	}
+	if static != "" {
+		expr = append(expr, strconv.Quote(static))
+	}
+
+	params := ""
+	if len(fields) > 0 {
+		fmt.Fprintf(w, "\n// %sParams are URL path params of route %q.\n", name, r.Name)
+		fmt.Fprintf(w, "type %sParams struct {\n", name)
+		for _, f := range fields {
+			fmt.Fprintf(w, "%s string\n", f)
+		}
+		fmt.Fprintf(w, "}\n")
+		params = fmt.Sprintf("p %sParams, ", name)
+	}
+	fmt.Fprintf(w, "\n// %s calls route %q: %s %s.\n", name, r.Name, r.Method, r.Pattern)
+	fmt.Fprintf(w, "func (c *Client) %s(ctx context.Context, %sbody io.Reader) (*http.Response, error) {\n",
+		name, params)
+	fmt.Fprintf(w, "return c.do(ctx, %q, %s, body)\n}\n", r.Method, strings.Join(expr, " + "))
+}
@@ -0,0 +1,55 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGenerateClient(t *testing.T) {
+	m := NewMux("/api", http.NewServeMux())
+	m.Add("GET", "users/{id}", dummy).As("profile")
+	m.Add("GET", "products", dummy).As("list")
+	m.Add("PUT", "products/{id}/do", dummy)
+	m.Add("POST", "{domain}/{action}/{id}", dummy).As("whatever")
+	m.Add("GET", "pairs/{id}/x/{id}", dummy).As("pair")
+
+	var buf bytes.Buffer
+	if err := GenerateClient(&buf, m, "apiclient"); err != nil {
+		t.Fatal(err)
+	}
+	src := buf.String()
+	for _, expected := range []string{
+		"package apiclient\n",
+		`// ProfileParams are URL path params of route "profile".
+type ProfileParams struct {
+	Id string
+}
+
+// Profile calls route "profile": GET users/{id}.
+func (c *Client) Profile(ctx context.Context, p ProfileParams, body io.Reader) (*http.Response, error) {
+	return c.do(ctx, "GET", "/api/users/"+url.PathEscape(p.Id), body)
+}`,
+		`// List calls route "list": GET products.
+func (c *Client) List(ctx context.Context, body io.Reader) (*http.Response, error) {
+	return c.do(ctx, "GET", "/api/products", body)
+}`,
+		`"/api/"+url.PathEscape(p.Domain)+"/"+url.PathEscape(p.Action)+"/"+url.PathEscape(p.Id)`,
+		`type PairParams struct {
+	Id  string
+	Id2 string
+}`,
+		`"/api/pairs/"+url.PathEscape(p.Id)+"/x/"+url.PathEscape(p.Id2)`,
+	} {
+		if !strings.Contains(src, expected) {
+			t.Fatalf("Expected generated source to contain:\n%s\ngot:\n%s", expected, src)
+		}
+	}
+	if strings.Contains(src, "products/{id}/do") {
+		t.Fatalf("Expected no method for unnamed route:\n%s", src)
+	}
+}
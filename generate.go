@@ -0,0 +1,279 @@
+package muxer
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Function type of a matcher generated with GenerateMatcher().
+// Returns index of the matched route in Mux.Routes() or -1 if none matches,
+// and parameters extracted from URL path, relative to the mux base path.
+type MatcherFunc func(method, path string) (int, url.Values)
+
+// Makes this mux match requests with f instead of looking through its routes
+// at runtime. The fingerprint must be the one f was generated from,
+// otherwise UseMatcher panics. The route table is frozen afterwards:
+// adding more routes panics.
+//
+// A matched route which has been disabled is skipped with the regular
+// matching.
+func (dm *defaultMux) UseMatcher(f MatcherFunc, fingerprint string) {
+	if fp := dm.Fingerprint(); fp != fingerprint {
+		panic(fmt.Sprintf("Matcher is generated for route table %s, have %s",
+			fingerprint, fp))
+	}
+	dm.matcher = f
+	dm.events.emit(TableFrozen, nil, nil)
+}
+
+// GenerateMatcher writes to w the source of a Go file of package pkg with:
+//
+//   - func matchRoute, a MatcherFunc for the current routes of m;
+//   - const routesFingerprint, value of m.Fingerprint();
+//   - a path building func for each named route, e.g. ProfilePath(id string)
+//     for a route named "profile", with args of params named alike
+//     numbered, e.g. PairPath(id, id2 string) for "{id}/x/{id}".
+//
+// The matcher is a set of nested switches on method and number of segments,
+// so no pattern is parsed at runtime. Routes ending with a catch-all param
+// are tried for every number of segments they match. Hook it up with:
+//
+//	m.UseMatcher(matchRoute, routesFingerprint)
+//
+// A typical way to run it is a small program next to the app's package,
+// invoked by go generate:
+//
+//	//go:generate go run ./gen
+//
+// which builds the mux, e.g. with m.Register(), and calls GenerateMatcher
+// writing to routes_gen.go.
+func GenerateMatcher(w io.Writer, m Mux, pkg string) error {
+	routes := m.Routes()
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by muxer.GenerateMatcher. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	imports := []string{"net/url", "strings"}
+	for _, r := range routes {
+		if r.Name != "" {
+			imports = []string{"net/url", "path", "strings"}
+			break
+		}
+	}
+	// Param constraints, compiled once by the generated code.
+	var constraints []string
+	cindex := make(map[string]int)
+	for _, r := range routes {
+		for _, rp := range r.parts {
+			if rp.re == nil {
+				continue
+			}
+			if _, ok := cindex[rp.re.String()]; !ok {
+				cindex[rp.re.String()] = len(constraints)
+				constraints = append(constraints, rp.re.String())
+			}
+		}
+	}
+	if len(constraints) > 0 {
+		imports = append(imports, "regexp")
+		sort.Strings(imports)
+	}
+	fmt.Fprintf(&buf, "import (\n")
+	for _, imp := range imports {
+		fmt.Fprintf(&buf, "%q\n", imp)
+	}
+	fmt.Fprintf(&buf, ")\n\n")
+	fmt.Fprintf(&buf, "const routesFingerprint = %q\n\n", m.Fingerprint())
+	if len(constraints) > 0 {
+		fmt.Fprintf(&buf, "var paramConstraints = [...]*regexp.Regexp{\n")
+		for _, c := range constraints {
+			fmt.Fprintf(&buf, "regexp.MustCompile(%s),\n", strconv.Quote(c))
+		}
+		fmt.Fprintf(&buf, "}\n\n")
+	}
+
+	// method -> number of segments -> indices of routes
+	byMethod := make(map[string]map[int][]int)
+	// method -> indices of routes ending with a catch-all
+	catchAll := make(map[string][]int)
+	for i, r := range routes {
+		if byMethod[r.Method] == nil {
+			byMethod[r.Method] = make(map[int][]int)
+		}
+		if r.catchAll() {
+			catchAll[r.Method] = append(catchAll[r.Method], i)
+			continue
+		}
+		byMethod[r.Method][r.partsLen] = append(byMethod[r.Method][r.partsLen], i)
+	}
+	fmt.Fprintf(&buf, "func matchRoute(method, path string) (int, url.Values) {\n")
+	fmt.Fprintf(&buf, "parts := strings.Split(path, \"/\")\n")
+	fmt.Fprintf(&buf, "switch method {\n")
+	for _, method := range sortedKeys(byMethod) {
+		fmt.Fprintf(&buf, "case %q:\nswitch len(parts) {\n", method)
+		lens := make([]int, 0, len(byMethod[method]))
+		for n := range byMethod[method] {
+			lens = append(lens, n)
+		}
+		sort.Ints(lens)
+		for _, n := range lens {
+			fmt.Fprintf(&buf, "case %d:\n", n)
+			// Catch-alls matching n segments, in the order routes were added.
+			indices := append([]int(nil), byMethod[method][n]...)
+			for _, i := range catchAll[method] {
+				if routes[i].fits(n) {
+					indices = append(indices, i)
+				}
+			}
+			sort.Ints(indices)
+			for _, i := range indices {
+				writeRouteMatch(&buf, i, routes[i], false, cindex)
+			}
+		}
+		if len(catchAll[method]) > 0 {
+			fmt.Fprintf(&buf, "default:\n")
+			for _, i := range catchAll[method] {
+				writeRouteMatch(&buf, i, routes[i], true, cindex)
+			}
+		}
+		fmt.Fprintf(&buf, "}\n")
+	}
+	fmt.Fprintf(&buf, "}\nreturn -1, nil\n}\n")
+
+	for _, r := range routes {
+		if r.Name != "" {
+			writePathFunc(&buf, m.BasePath(), r)
+		}
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// Writes an if statement returning i and params of route r if parts match,
+// checking there are enough of them for a catch-all if checkLen is set.
+// Constraints are referred to by their index in cindex.
+func writeRouteMatch(w io.Writer, i int, r *Route, checkLen bool, cindex map[string]int) {
+	var conds, names []string
+	// Values of params by name, in order, since a name can repeat.
+	values := make(map[string][]string)
+	if checkLen {
+		conds = append(conds, fmt.Sprintf("len(parts) >= %d", r.partsLen))
+	}
+	for j, rp := range r.parts {
+		if !rp.isVar {
+			conds = append(conds, fmt.Sprintf("parts[%d] == %q", j, rp.name))
+			continue
+		}
+		if rp.re != nil && !rp.rest {
+			conds = append(conds, fmt.Sprintf("paramConstraints[%d].MatchString(parts[%d])",
+				cindex[rp.re.String()], j))
+		}
+		if _, ok := values[rp.name]; !ok {
+			names = append(names, rp.name)
+		}
+		if rp.rest {
+			values[rp.name] = append(values[rp.name], fmt.Sprintf("strings.Join(parts[%d:], \"/\")", j))
+		} else {
+			values[rp.name] = append(values[rp.name], fmt.Sprintf("parts[%d]", j))
+		}
+	}
+	vals := make([]string, len(names))
+	for k, name := range names {
+		vals[k] = fmt.Sprintf("%q: {%s}", name, strings.Join(values[name], ", "))
+	}
+	ret := fmt.Sprintf("return %d, url.Values{%s}", i, strings.Join(vals, ", "))
+	if len(conds) == 0 {
+		fmt.Fprintf(w, "%s\n", ret)
+		return
+	}
+	fmt.Fprintf(w, "if %s {\n%s\n}\n", strings.Join(conds, " && "), ret)
+}
+
+// Writes a func building URL path of named route r the same way
+// BuildPath() does.
+func writePathFunc(w io.Writer, base string, r *Route) {
+	args := make([]string, 0, r.partsLen)
+	seen := map[string]bool{"path": true}
+	expr := []string{strconv.Quote(base)}
+	for _, rp := range r.parts {
+		if !rp.isVar {
+			expr = append(expr, strconv.Quote(rp.name))
+			continue
+		}
+		arg := goIdent(rp.name, false)
+		if arg == "path" {
+			arg = "pathParam"
+		}
+		arg = uniqueName(seen, arg)
+		args = append(args, arg)
+		expr = append(expr, arg)
+	}
+	sig := ""
+	if len(args) > 0 {
+		sig = strings.Join(args, ", ") + " string"
+	}
+	fname := goIdent(r.Name, true) + "Path"
+	fmt.Fprintf(w, "\n// %s returns URL path of route %q: %s %s.\n",
+		fname, r.Name, r.Method, r.Pattern)
+	fmt.Fprintf(w, "func %s(%s) string {\nreturn path.Join(%s)\n}\n",
+		fname, sig, strings.Join(expr, ", "))
+}
+
+// Returns name, with the lowest number from 2 up appended if it's in seen,
+// and adds the result to seen. Keeps params named alike, e.g. of
+// "{id}/x/{id}", apart in generated code.
+func uniqueName(seen map[string]bool, name string) string {
+	unique := name
+	for i := 2; seen[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	seen[unique] = true
+	return unique
+}
+
+// Converts s, e.g. a route or param name, to a Go identifier: "user-id"
+// becomes "userId" or "UserId" if exported.
+func goIdent(s string, exported bool) string {
+	var b strings.Builder
+	upper := exported
+	for _, c := range s {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			upper = b.Len() > 0 || exported
+			continue
+		}
+		if b.Len() == 0 && unicode.IsDigit(c) {
+			b.WriteByte('p')
+		}
+		if upper {
+			c = unicode.ToUpper(c)
+		}
+		b.WriteRune(c)
+		upper = false
+	}
+	id := b.String()
+	if id == "" || token.Lookup(id).IsKeyword() {
+		id += "Param"
+	}
+	return id
+}
+
+func sortedKeys(m map[string]map[int][]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
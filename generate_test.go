@@ -0,0 +1,160 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestGenerateMatcher(t *testing.T) {
+	m := NewMux("/api", http.NewServeMux())
+	m.Add("GET", "users/{id}", dummy).As("profile")
+	m.Add("GET", "products", dummy).As("product-list")
+	m.Add("PUT", "products/{id}/do", dummy)
+	m.Add("GET", "{domain}/{path}", dummy).As("whatever")
+	m.Add("PUT", "files/{dir}/{path...}", dummy)
+
+	var buf bytes.Buffer
+	if err := GenerateMatcher(&buf, m, "myapp"); err != nil {
+		t.Fatal(err)
+	}
+	expected := `// Code generated by muxer.GenerateMatcher. DO NOT EDIT.
+
+package myapp
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+const routesFingerprint = "` + m.Fingerprint() + `"
+
+func matchRoute(method, path string) (int, url.Values) {
+	parts := strings.Split(path, "/")
+	switch method {
+	case "GET":
+		switch len(parts) {
+		case 1:
+			if parts[0] == "products" {
+				return 1, url.Values{}
+			}
+		case 2:
+			if parts[0] == "users" {
+				return 0, url.Values{"id": {parts[1]}}
+			}
+			return 3, url.Values{"domain": {parts[0]}, "path": {parts[1]}}
+		}
+	case "PUT":
+		switch len(parts) {
+		case 3:
+			if parts[0] == "products" && parts[2] == "do" {
+				return 2, url.Values{"id": {parts[1]}}
+			}
+			if parts[0] == "files" {
+				return 4, url.Values{"dir": {parts[1]}, "path": {strings.Join(parts[2:], "/")}}
+			}
+		default:
+			if len(parts) >= 3 && parts[0] == "files" {
+				return 4, url.Values{"dir": {parts[1]}, "path": {strings.Join(parts[2:], "/")}}
+			}
+		}
+	}
+	return -1, nil
+}
+
+// ProfilePath returns URL path of route "profile": GET users/{id}.
+func ProfilePath(id string) string {
+	return path.Join("/api/", "users", id)
+}
+
+// ProductListPath returns URL path of route "product-list": GET products.
+func ProductListPath() string {
+	return path.Join("/api/", "products")
+}
+
+// WhateverPath returns URL path of route "whatever": GET {domain}/{path}.
+func WhateverPath(domain, pathParam string) string {
+	return path.Join("/api/", domain, pathParam)
+}
+`
+	if buf.String() != expected {
+		t.Fatalf("Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestGenerateMatcherRepeatedParams(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "pairs/{id}/x/{id}", dummy).As("pair")
+	var buf bytes.Buffer
+	if err := GenerateMatcher(&buf, m, "myapp"); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`return 0, url.Values{"id": {parts[1], parts[3]}}`,
+		`func PairPath(id, id2 string) string {
+	return path.Join("/", "pairs", id, "x", id2)
+}`,
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Fatalf("Expected generated source to contain:\n%s\ngot:\n%s", expected, buf.String())
+		}
+	}
+	assertEqual(t, serve(m, "GET", "/pairs/1/x/2").Body.String(), "params:id=1&id=2")
+}
+
+func TestUseMatcher(t *testing.T) {
+	m := NewMux("/api", http.NewServeMux())
+	m.Add("GET", "users/{id}", dummy)
+	m.Add("GET", "{x}/{id}", dummy)
+	fp := m.Fingerprint()
+	calls := 0
+	m.UseMatcher(func(method, path string) (int, url.Values) {
+		calls++
+		if method == "GET" && path == "users/1" {
+			return 0, url.Values{"id": {"generated"}}
+		}
+		return -1, nil
+	}, fp)
+
+	assertEqual(t, serve(m, "GET", "/api/users/1").Body.String(), "params:id=generated")
+	m.Routes()[0].Disable()
+	assertEqual(t, serve(m, "GET", "/api/users/1").Body.String(), "params:id=1&x=users")
+	if calls != 2 {
+		t.Fatalf("Expected matcher to be called twice, got %d", calls)
+	}
+
+	defer func() {
+		if err := recover(); err == nil {
+			t.Fatalf("Expected panic, got no error instead")
+		}
+	}()
+	// should panic because the route table is frozen
+	m.Add("POST", "users", dummy)
+}
+
+func TestGenerateMatcherConstraints(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "users/{id:[0-9]+}", dummy)
+	m.Add("GET", "posts/{id:[0-9]+}/{slug}", dummy)
+
+	var buf bytes.Buffer
+	if err := GenerateMatcher(&buf, m, "myapp"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"\t\"regexp\"\n",
+		"var paramConstraints = [...]*regexp.Regexp{\n\tregexp.MustCompile(\"^(?:[0-9]+)$\"),\n}\n",
+		`if parts[0] == "users" && paramConstraints[0].MatchString(parts[1]) {`,
+		`if parts[0] == "posts" && paramConstraints[0].MatchString(parts[1]) {`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Generated matcher lacks %q:\n%s", want, buf.String())
+		}
+	}
+}
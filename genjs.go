@@ -0,0 +1,104 @@
+package muxer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// GenerateTS writes to w a TypeScript module exporting buildPath(name, params)
+// for all named routes of m, with route names and params typed:
+//
+//	import { buildPath } from "./routes";
+//	buildPath("profile", { id: 123 }); // "/api/users/123"
+//
+// Path is built the same way Mux.BuildPath() does it, so frontend code
+// never has to hardcode URLs. Params named alike, e.g. of "{id}/x/{id}",
+// are numbered: id and id2.
+func GenerateTS(w io.Writer, m Mux) error {
+	return generateJS(w, m, true)
+}
+
+// GenerateJS is GenerateTS for plain JavaScript: it writes an ES module
+// without type annotations.
+func GenerateJS(w io.Writer, m Mux) error {
+	return generateJS(w, m, false)
+}
+
+func generateJS(w io.Writer, m Mux, typed bool) error {
+	var buf bytes.Buffer
+	fname := "GenerateJS"
+	if typed {
+		fname = "GenerateTS"
+	}
+	fmt.Fprintf(&buf, "// Code generated by muxer.%s. DO NOT EDIT.\n\n", fname)
+
+	var named []*Route
+	for _, r := range m.Routes() {
+		if r.Name != "" {
+			named = append(named, r)
+		}
+	}
+	// Param names by route, made unique.
+	params := make(map[*Route][]string, len(named))
+	for _, r := range named {
+		seen := make(map[string]bool)
+		for _, rp := range r.parts {
+			if rp.isVar {
+				params[r] = append(params[r], uniqueName(seen, rp.name))
+			}
+		}
+	}
+	if typed {
+		fmt.Fprintf(&buf, "export interface RouteParams {\n")
+		for _, r := range named {
+			var fields []string
+			for _, name := range params[r] {
+				fields = append(fields, fmt.Sprintf("%q: string | number | boolean", name))
+			}
+			if len(fields) == 0 {
+				fmt.Fprintf(&buf, "  %q: {};\n", r.Name)
+			} else {
+				fmt.Fprintf(&buf, "  %q: { %s };\n", r.Name, strings.Join(fields, "; "))
+			}
+		}
+		fmt.Fprintf(&buf, "}\n\nexport type RouteName = keyof RouteParams;\n\n")
+		fmt.Fprintf(&buf, "const routes: { [K in RouteName]: string[] } = {\n")
+	} else {
+		fmt.Fprintf(&buf, "const routes = {\n")
+	}
+	for _, r := range named {
+		segs := make([]string, 0, r.partsLen)
+		names := params[r]
+		for _, rp := range r.parts {
+			if rp.isVar {
+				segs = append(segs, fmt.Sprintf("%q", "{"+names[0]+"}"))
+				names = names[1:]
+			} else {
+				segs = append(segs, fmt.Sprintf("%q", rp.name))
+			}
+		}
+		fmt.Fprintf(&buf, "  %q: [%s],\n", r.Name, strings.Join(segs, ", "))
+	}
+	fmt.Fprintf(&buf, "};\n\n")
+
+	fmt.Fprintf(&buf, "const basePath = %q;\n\n", m.BasePath())
+	if typed {
+		fmt.Fprintf(&buf, "export function buildPath<K extends RouteName>(name: K, params: RouteParams[K]): string {\n")
+		fmt.Fprintf(&buf, "  const values = params as { [param: string]: string | number | boolean };\n")
+	} else {
+		fmt.Fprintf(&buf, "export function buildPath(name, params) {\n")
+		fmt.Fprintf(&buf, "  const values = params || {};\n")
+	}
+	fmt.Fprintf(&buf, "%s", `  const parts = basePath.split("/");
+  for (const seg of routes[name]) {
+    const isVar = seg.length > 1 && seg[0] === "{" && seg[seg.length - 1] === "}";
+    parts.push(isVar ? String(values[seg.slice(1, -1)]) : seg);
+  }
+  return "/" + parts.filter((p) => p !== "").join("/");
+}
+`)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
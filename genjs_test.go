@@ -0,0 +1,76 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+)
+
+func TestGenerateTS(t *testing.T) {
+	m := NewMux("/api", http.NewServeMux())
+	m.Add("GET", "users/{id}", dummy).As("profile")
+	m.Add("GET", "products", dummy).As("list")
+	m.Add("PUT", "products/{id}/do", dummy)
+
+	var buf bytes.Buffer
+	if err := GenerateTS(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	expected := `// Code generated by muxer.GenerateTS. DO NOT EDIT.
+
+export interface RouteParams {
+  "profile": { "id": string | number | boolean };
+  "list": {};
+}
+
+export type RouteName = keyof RouteParams;
+
+const routes: { [K in RouteName]: string[] } = {
+  "profile": ["users", "{id}"],
+  "list": ["products"],
+};
+
+const basePath = "/api/";
+
+export function buildPath<K extends RouteName>(name: K, params: RouteParams[K]): string {
+  const values = params as { [param: string]: string | number | boolean };
+  const parts = basePath.split("/");
+  for (const seg of routes[name]) {
+    const isVar = seg.length > 1 && seg[0] === "{" && seg[seg.length - 1] === "}";
+    parts.push(isVar ? String(values[seg.slice(1, -1)]) : seg);
+  }
+  return "/" + parts.filter((p) => p !== "").join("/");
+}
+`
+	if buf.String() != expected {
+		t.Fatalf("Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	buf.Reset()
+	if err := GenerateJS(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("RouteParams")) {
+		t.Fatalf("Expected no types in JS output:\n%s", buf.String())
+	}
+}
+
+func TestGenerateTSRepeatedParams(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "pairs/{id}/x/{id}", dummy).As("pair")
+	var buf bytes.Buffer
+	if err := GenerateTS(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`"pair": { "id": string | number | boolean; "id2": string | number | boolean };`,
+		`"pair": ["pairs", "{id}", "x", "{id2}"],`,
+	} {
+		if !bytes.Contains(buf.Bytes(), []byte(expected)) {
+			t.Fatalf("Expected generated source to contain:\n%s\ngot:\n%s", expected, buf.String())
+		}
+	}
+}
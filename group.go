@@ -0,0 +1,181 @@
+package muxer
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Group is a set of routes of a mux sharing the same path prefix.
+// Each group can have its own NotFound, MethodNotAllowed and error handlers.
+// Those which are not set are inherited from the parent group.
+//
+//	api := m.Group("api").NotFound(jsonNotFound)
+//	api.Add("GET", "users/{id}", handler1) // matches /base/api/users/{id}
+type Group struct {
+	mux    *defaultMux
+	parent *Group
+	// Relative to the mux base path: either zero string or ends with "/".
+	prefix           string
+	notFound         HandlerFunc
+	methodNotAllowed HandlerFunc
+	errorHandler     ErrorHandlerFunc
+	middleware       []namedMiddleware
+	// Set with Prepend()
+	prepend []namedMiddleware
+	// Set with Listener()
+	listener string
+	// Set with CasePolicy()
+	casePolicy *CasePolicy
+	// Set with Label()
+	labels map[string]string
+	// Set with Owner()
+	owner string
+	// Set with Namespace()
+	namespace string
+}
+
+// Function type that knows how to respond to an error returned by a route's
+// handler. See Error().
+type ErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
+
+// Returns path prefix of this group relative to the mux base path.
+func (g *Group) Prefix() string {
+	return g.prefix
+}
+
+// Creates a nested group. Its prefix is appended to the prefix of g.
+func (g *Group) Group(prefix string) *Group {
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	child := &Group{mux: g.mux, parent: g, prefix: g.prefix + prefix}
+	g.mux.groups = append(g.mux.groups, child)
+	return child
+}
+
+// Adds a new route to the mux. Pattern is relative to the group prefix.
+func (g *Group) Add(method string, pattern string, h HandlerFunc) *Route {
+	r := g.mux.add(g, method, pattern, h)
+	g.mux.events.emit(RouteAdded, r, nil)
+	return r
+}
+
+// Sets a handler for requests under this group's prefix which don't match
+// any route.
+func (g *Group) NotFound(h HandlerFunc) *Group {
+	g.notFound = h
+	return g
+}
+
+// Sets a handler for requests whose URL path matches one of this group's
+// routes but the HTTP method doesn't. The Allow header listing methods of
+// routes matching the path is set before it's called. When unset anywhere
+// up the parent chain, such requests get 405 Method Not Allowed.
+func (g *Group) MethodNotAllowed(h HandlerFunc) *Group {
+	g.methodNotAllowed = h
+	return g
+}
+
+// Sets a handler for errors reported by this group's route handlers
+// with Error().
+func (g *Group) Error(h ErrorHandlerFunc) *Group {
+	g.errorHandler = h
+	return g
+}
+
+func (g *Group) notFoundHandler() HandlerFunc {
+	for ; g != nil; g = g.parent {
+		if g.notFound != nil {
+			return g.notFound
+		}
+	}
+	return nil
+}
+
+func (g *Group) methodNotAllowedHandler() HandlerFunc {
+	for ; g != nil; g = g.parent {
+		if g.methodNotAllowed != nil {
+			return g.methodNotAllowed
+		}
+	}
+	return nil
+}
+
+func (g *Group) errorHandlerFunc() ErrorHandlerFunc {
+	for ; g != nil; g = g.parent {
+		if g.errorHandler != nil {
+			return g.errorHandler
+		}
+	}
+	return nil
+}
+
+// Sets a handler for requests which don't match any route of this mux or
+// the chained ones and have no NotFound handler of a group, instead of
+// http.NotFound, e.g. to respond with a JSON body. It's called with the
+// request as the mux got it and nil params.
+func (dm *defaultMux) NotFound(h HandlerFunc) {
+	dm.root.NotFound(h)
+}
+
+// Sets a handler for requests whose URL path matches a route but the HTTP
+// method doesn't, for routes of groups without a MethodNotAllowed handler,
+// instead of the plain 405 Method Not Allowed, e.g. to respond with a JSON
+// body. The Allow header is set before it's called.
+func (dm *defaultMux) MethodNotAllowed(h HandlerFunc) {
+	dm.root.MethodNotAllowed(h)
+}
+
+// Returns the group served on listener with the longest prefix matching
+// URL path p.
+func (dm *defaultMux) groupFor(p, listener string) *Group {
+	best := dm.root
+	p += "/"
+	for _, g := range dm.groups {
+		if len(g.prefix) > len(best.prefix) && strings.HasPrefix(p, g.prefix) &&
+			g.listenerName() == listener {
+			best = g
+		}
+	}
+	return best
+}
+
+// Error responds to request r with err using the error handler of the group
+// the matched route belongs to. Falls back to http.Error with
+// 500 Internal Server Error status code when no error handler is set,
+// or the code of err if it's a *StatusError.
+// Intended to be called by route handlers.
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	if route := CurrentRoute(r); route != nil {
+		if h := route.group.errorHandlerFunc(); h != nil {
+			h(w, r, err)
+			return
+		}
+	}
+	code := http.StatusInternalServerError
+	if se, ok := err.(*StatusError); ok {
+		code = se.Code
+	}
+	http.Error(w, err.Error(), code)
+}
+
+// StatusError is an error with an HTTP status code, e.g. of a request
+// rejected by the mux itself. Error handlers can use the code to render
+// consistent error responses.
+type StatusError struct {
+	Code    int
+	Message string
+}
+
+// Creates a StatusError. Message defaults to the status text of code.
+func NewStatusError(code int, message string) *StatusError {
+	if message == "" {
+		message = http.StatusText(code)
+	}
+	return &StatusError{code, message}
+}
+
+func (e *StatusError) Error() string {
+	return e.Message
+}
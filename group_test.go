@@ -0,0 +1,125 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func serve(h http.Handler, method, path string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest(method, path, nil)
+	if err != nil {
+		panic(err)
+	}
+	return serveRequest(h, req)
+}
+
+func serveRequest(h http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestGroupRoutes(t *testing.T) {
+	m := NewMux("/base", http.NewServeMux())
+	api := m.Group("/api/")
+	assertEqual(t, api.Prefix(), "api/")
+	r := api.Group("v1").Add("GET", "/users/{id}", dummy)
+	assertEqual(t, r.Pattern, "api/v1/users/{id}")
+	assertEqual(t, api.Add("GET", "", dummy).Pattern, "api")
+
+	w := serve(m, "GET", "/base/api/v1/users/alex")
+	if w.Code != 200 {
+		t.Fatalf("Expected 200 OK, got %d", w.Code)
+	}
+	assertEqual(t, w.Body.String(), "params:id=alex")
+}
+
+func TestGroupErrorHandlers(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	api := m.Group("api").
+		NotFound(func(w http.ResponseWriter, r *http.Request, v url.Values) {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		}).
+		MethodNotAllowed(func(w http.ResponseWriter, r *http.Request, v url.Values) {
+			http.Error(w, `{"error":"bad method"}`, http.StatusMethodNotAllowed)
+		}).
+		Error(func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, err), http.StatusTeapot)
+		})
+	fail := func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		Error(w, r, errors.New("oops"))
+	}
+	api.Group("users").Add("GET", "{id}", fail)
+	m.Group("web").Add("GET", "{id}", fail)
+
+	tests := []struct {
+		method, path string
+		code         int
+		body         string
+	}{
+		{"GET", "/api/users/1", 418, "{\"error\":\"oops\"}\n"},
+		{"PUT", "/api/users/1", 405, "{\"error\":\"bad method\"}\n"},
+		{"GET", "/api/users/1/x", 404, "{\"error\":\"not found\"}\n"},
+		{"GET", "/api", 404, "{\"error\":\"not found\"}\n"},
+		{"GET", "/web/1", 500, "oops\n"},
+		{"PUT", "/web/1", 405, "Method Not Allowed\n"},
+		{"GET", "/apis", 404, "404 page not found\n"},
+	}
+	for i, test := range tests {
+		w := serve(m, test.method, test.path)
+		if w.Code != test.code {
+			t.Errorf("%d: Expected %d, got %d", i, test.code, w.Code)
+		}
+		assertEqual(t, w.Body.String(), test.body)
+	}
+}
+
+func TestMuxNotFound(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.NotFound(func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		if CurrentRoute(r) != nil || v != nil {
+			t.Errorf("not found request has route %v, params %v", CurrentRoute(r), v)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, `{"error":"no %s"}`, r.URL.Path)
+	})
+	m.Group("api").NotFound(func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		http.Error(w, "api", http.StatusNotFound)
+	})
+	m.Add("GET", "users/{id}", dummy)
+
+	w := serve(m, "GET", "/users/1/x")
+	if w.Code != http.StatusNotFound || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("GET /users/1/x = %d, %v", w.Code, w.Header())
+	}
+	assertEqual(t, w.Body.String(), `{"error":"no /users/1/x"}`)
+	assertEqual(t, serve(m, "GET", "/api/x").Body.String(), "api\n")
+}
+
+func TestMuxMethodNotAllowed(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		fmt.Fprintf(w, `{"error":"%s not allowed","allow":"%s"}`, r.Method, w.Header().Get("Allow"))
+	})
+	m.Group("users").Add("PUT", "{id}", dummy)
+	m.Group("v2").MethodNotAllowed(func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		http.Error(w, "v2", http.StatusMethodNotAllowed)
+	}).Add("POST", "ping", dummy)
+
+	w := serve(m, "DELETE", "/users/1")
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("DELETE /users/1 = %d, %v", w.Code, w.Header())
+	}
+	assertEqual(t, w.Body.String(), `{"error":"DELETE not allowed","allow":"PUT"}`)
+	assertEqual(t, serve(m, "GET", "/v2/ping").Body.String(), "v2\n")
+}
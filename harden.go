@@ -0,0 +1,111 @@
+package muxer
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// Function type of hooks notified about requests rejected before matching,
+// with a short reason, e.g. "double percent-encoding". See OnReject().
+type RejectFunc func(r *http.Request, reason string)
+
+// Makes this mux reject request paths which are ambiguous once decoded
+// with 400 Bad Request before PreRoute hooks and matching:
+//
+//   - malformed or double percent-encoding, e.g. "%zz" or "%252e";
+//   - percent-encoded bytes which aren't valid UTF-8, including overlong
+//     encodings like "%c0%ae";
+//   - null bytes and other control characters;
+//   - encoded separators "%2f" and "%5c", and backslashes;
+//   - dot segments, e.g. "/a/../b" or "/a/%2e%2e/b".
+//
+// Such paths are rarely sent by legitimate clients but can make a proxy,
+// the mux and a handler disagree about the requested path. Log them with
+// OnReject().
+func (dm *defaultMux) HardenPaths() {
+	dm.hardened = true
+}
+
+// Sets a hook called for every request rejected by path limits, path
+// hardening or the header guard, e.g. to log it.
+func (dm *defaultMux) OnReject(f RejectFunc) {
+	dm.onReject = f
+}
+
+// Responds with 400 Bad Request if the path of req is ambiguous.
+// Reports whether it has.
+func (dm *defaultMux) rejectAmbiguous(w http.ResponseWriter, req *http.Request) bool {
+	reason := pathProblem(rawPath(req))
+	if reason == "" {
+		return false
+	}
+	dm.reject(w, req, NewStatusError(http.StatusBadRequest, "Invalid path: "+reason), reason)
+	return true
+}
+
+// Notifies the reject hook and responds with err.
+func (dm *defaultMux) reject(w http.ResponseWriter, req *http.Request, err *StatusError, reason string) {
+	if dm.onReject != nil {
+		dm.onReject(req, reason)
+	}
+	Error(w, req, err)
+}
+
+// Returns the path of req as sent by the client.
+func rawPath(req *http.Request) string {
+	if p := req.RequestURI; strings.HasPrefix(p, "/") {
+		if i := strings.IndexByte(p, '?'); i >= 0 {
+			p = p[:i]
+		}
+		return p
+	}
+	return req.URL.EscapedPath()
+}
+
+// Returns why escaped path p is ambiguous, or zero string if it isn't.
+func pathProblem(p string) string {
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; {
+		case c == '%':
+			if i+2 >= len(p) || !ishex(p[i+1]) || !ishex(p[i+2]) {
+				return "malformed percent-encoding"
+			}
+			if p[i+1] == '2' && p[i+2] == '5' && i+4 < len(p) && ishex(p[i+3]) && ishex(p[i+4]) {
+				return "double percent-encoding"
+			}
+			if e := strings.ToLower(p[i+1 : i+3]); e == "2f" || e == "5c" {
+				return "encoded path separator"
+			}
+			i += 2
+		case c == '\\':
+			return "backslash"
+		case c < 0x20 || c == 0x7f:
+			return "control character"
+		}
+	}
+	d, err := url.PathUnescape(p)
+	if err != nil {
+		return "malformed percent-encoding"
+	}
+	if !utf8.ValidString(d) {
+		return "invalid UTF-8"
+	}
+	for i := 0; i < len(d); i++ {
+		if d[i] < 0x20 || d[i] == 0x7f {
+			return "control character"
+		}
+	}
+	segs := NewSegments(d)
+	for seg, ok := segs.Next(); ok; seg, ok = segs.Next() {
+		if seg == "." || seg == ".." {
+			return "dot segment"
+		}
+	}
+	return ""
+}
+
+func ishex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
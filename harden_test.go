@@ -0,0 +1,61 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPathProblem(t *testing.T) {
+	for p, want := range map[string]string{
+		"/users/42":          "",
+		"/caf%C3%A9":         "",
+		"/a%20b":             "",
+		"/a%zz":              "malformed percent-encoding",
+		"/a%2":               "malformed percent-encoding",
+		"/%252e%252e/etc":    "double percent-encoding",
+		"/a%2Fb":             "encoded path separator",
+		"/a%5cb":             "encoded path separator",
+		`/a\b`:               "backslash",
+		"/%c0%ae%c0%ae/x":    "invalid UTF-8",
+		"/a%00.txt":          "control character",
+		"/a/../admin":        "dot segment",
+		"/a/%2e%2e/admin":    "dot segment",
+		"/a/./b":             "dot segment",
+		"/a/...":             "",
+		"/files/report.v2.1": "",
+	} {
+		if got := pathProblem(p); got != want {
+			t.Errorf("pathProblem(%q) = %q, want %q", p, got, want)
+		}
+	}
+}
+
+func TestHardenPaths(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "{a}/{b}", dummy)
+	var rejected []string
+	m.OnReject(func(r *http.Request, reason string) {
+		rejected = append(rejected, r.URL.EscapedPath()+" "+reason)
+	})
+
+	req := httptest.NewRequest("GET", "/a/%2e%2e", nil)
+	if w := serveRequest(m, req); w.Code != 200 {
+		t.Errorf("before HardenPaths(): code = %d, want 200", w.Code)
+	}
+	m.HardenPaths()
+	if w := serveRequest(m, req); w.Code != 400 {
+		t.Errorf("code = %d, want 400", w.Code)
+	}
+	if w := serveRequest(m, httptest.NewRequest("GET", "/a/b?x=%2e%2e", nil)); w.Code != 200 {
+		t.Errorf("query: code = %d, want 200", w.Code)
+	}
+	m.PathLimits(0, 1)
+	serve(m, "GET", "/a/b")
+	if len(rejected) != 2 || rejected[0] != "/a/%2e%2e dot segment" || rejected[1] != "/a/b too many segments" {
+		t.Errorf("rejected = %q", rejected)
+	}
+}
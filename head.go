@@ -0,0 +1,29 @@
+package muxer
+
+import "net/http"
+
+// Makes this GET route not serve HEAD requests. Otherwise a HEAD request
+// with no HEAD route of its own is handled by the GET route matching it,
+// with the response body discarded.
+func (r *Route) NoHead() *Route {
+	r.noHead = true
+	return r
+}
+
+// Reports whether this route serves HEAD requests as a GET route.
+func (r *Route) servesHead() bool {
+	return r.Method == "GET" && !r.noHead
+}
+
+// ResponseWriter of a HEAD request served by a GET route, dropping the body.
+type headWriter struct {
+	http.ResponseWriter
+}
+
+func (w headWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w headWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
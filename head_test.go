@@ -0,0 +1,38 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestHead(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "users/{id}", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		w.Header().Set("X-Method", r.Method)
+		w.Write([]byte("user " + v.Get("id")))
+	})
+	m.Add("GET", "files/{name}", dummy)
+	m.Add("HEAD", "files/{name}", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		w.Header().Set("X-Head", v.Get("name"))
+	})
+	m.Add("GET", "private", dummy).NoHead()
+	m.Add("POST", "private", dummy)
+
+	w := serve(m, "HEAD", "/users/1")
+	if w.Code != 200 || w.Body.Len() != 0 || w.Header().Get("X-Method") != "HEAD" {
+		t.Errorf("HEAD /users/1 = %d, %q, %v", w.Code, w.Body.String(), w.Header())
+	}
+	assertEqual(t, serve(m, "GET", "/users/1").Body.String(), "user 1")
+	assertEqual(t, serve(m, "HEAD", "/files/a").Header().Get("X-Head"), "a")
+
+	w = serve(m, "HEAD", "/private")
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("HEAD /private = %d, want 405", w.Code)
+	}
+	assertEqual(t, w.Header().Get("Allow"), "GET, POST")
+	assertEqual(t, serve(m, "PUT", "/users/1").Header().Get("Allow"), "GET, HEAD")
+}
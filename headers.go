@@ -0,0 +1,30 @@
+package muxer
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// Sets a static response header of this route, e.g.
+//
+//	m.Add("GET", "drafts/{id}", h).SetHeader("X-Robots-Tag", "noindex")
+//
+// Headers are set before middleware and the handler are called,
+// so either can still change them.
+func (r *Route) SetHeader(key, value string) *Route {
+	if r.headers == nil {
+		r.headers = make(http.Header)
+	}
+	r.headers.Set(key, value)
+	return r
+}
+
+// Sets static response headers of this route before its handler is called.
+func (r *Route) writeHeaders(h http.Header, v url.Values) {
+	for k, vals := range r.headers {
+		h[k] = append([]string(nil), vals...)
+	}
+	if r.deprecation != nil {
+		r.deprecation.writeHeaders(h, r, v)
+	}
+}
@@ -0,0 +1,25 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestSetHeader(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "drafts/{id}", dummy).
+		SetHeader("X-Robots-Tag", "noindex").
+		SetHeader("cache-control", "no-store")
+	m.Add("GET", "override", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		w.Header().Set("Cache-Control", "max-age=60")
+	}).SetHeader("Cache-Control", "no-store")
+
+	w := serve(m, "GET", "/drafts/1")
+	assertEqual(t, w.Header().Get("X-Robots-Tag"), "noindex")
+	assertEqual(t, w.Header().Get("Cache-Control"), "no-store")
+	assertEqual(t, serve(m, "GET", "/override").Header().Get("Cache-Control"), "max-age=60")
+}
@@ -0,0 +1,72 @@
+package muxer
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+)
+
+// Makes this route serve JSONP to GET requests with query param param set
+// to the name of a callback, e.g. "callback", for legacy widgets loading
+// data with script tags. JSON responses are wrapped in a call of the
+// callback and sent as JavaScript. Callbacks must be JavaScript
+// identifiers, optionally dotted, e.g. "jQuery1.cb", other ones get
+// 400 Bad Request. Responses of other content types are sent as they are.
+func (r *Route) JSONP(param string) *Route {
+	if r.Method != "GET" {
+		panic(fmt.Sprintf("Route '%s %s': JSONP is only served to GET requests", r.Method, r.Pattern))
+	}
+	r.jsonp = param
+	return r
+}
+
+// Returns a transform wrapping JSON in a call of the callback in query
+// param param.
+func jsonpTransform(param string) TransformFunc {
+	return func(r *http.Request, resp *Response) error {
+		callback := r.URL.Query().Get(param)
+		if callback == "" {
+			return nil
+		}
+		if !validCallback(callback) {
+			return NewStatusError(http.StatusBadRequest, "Invalid JSONP callback")
+		}
+		if mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mt != "application/json" {
+			return nil
+		}
+		// The comment keeps the response from starting with bytes of a
+		// content type sniffed by old browsers, see the Rosetta Flash attack.
+		body := make([]byte, 0, len(resp.Body)+len(callback)+8)
+		body = append(body, "/**/"...)
+		body = append(body, callback...)
+		body = append(body, '(')
+		body = append(body, resp.Body...)
+		resp.Body = append(body, ");"...)
+		resp.Header.Set("Content-Type", "application/javascript; charset=utf-8")
+		resp.Header.Set("X-Content-Type-Options", "nosniff")
+		return nil
+	}
+}
+
+// Reports whether s is a dotted JavaScript identifier of ASCII letters,
+// digits, "_" and "$".
+func validCallback(s string) bool {
+	if len(s) > 128 {
+		return false
+	}
+	start := true
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '.' && !start && i < len(s)-1:
+			start = true
+			continue
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', c == '_', c == '$':
+		case '0' <= c && c <= '9' && !start:
+		default:
+			return false
+		}
+		start = false
+	}
+	return !start
+}
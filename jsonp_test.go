@@ -0,0 +1,28 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestJSONP(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "widget", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"n":1}`))
+	}).JSONP("callback")
+
+	w := serve(m, "GET", "/widget?callback=jQuery1.cb_2")
+	assertEqual(t, w.Body.String(), `/**/jQuery1.cb_2({"n":1});`)
+	assertEqual(t, w.Header().Get("Content-Type"), "application/javascript; charset=utf-8")
+	assertEqual(t, serve(m, "GET", "/widget").Body.String(), `{"n":1}`)
+	for _, cb := range []string{"alert(1)", "1a", "a..b", "a.", ".a", "a-b"} {
+		if w := serve(m, "GET", "/widget?callback="+url.QueryEscape(cb)); w.Code != http.StatusBadRequest {
+			t.Errorf("callback %q: code %d, want 400", cb, w.Code)
+		}
+	}
+}
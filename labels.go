@@ -0,0 +1,78 @@
+package muxer
+
+import "fmt"
+
+// Adds a static label to metrics and traces of routes of this group and
+// its nested groups, e.g. "team" or "domain", see Route.Labels().
+func (g *Group) Label(key, value string) *Group {
+	checkLabel(key)
+	if g.labels == nil {
+		g.labels = make(map[string]string)
+	}
+	g.labels[key] = value
+	return g
+}
+
+// Adds a static label to metrics and traces of this route, e.g. "tier",
+// overriding the one of its group, so that dashboards can be sliced by
+// ownership without parsing paths:
+//
+//	m.Group("billing").Label("team", "payments").Label("domain", "billing")
+//	m.Add("GET", "health", health).Label("tier", "internal")
+func (r *Route) Label(key, value string) *Route {
+	checkLabel(key)
+	if r.labels == nil {
+		r.labels = make(map[string]string)
+	}
+	r.labels[key] = value
+	return r
+}
+
+// Returns labels of this route merged with the ones of its groups, for
+// metrics and tracing integrations. Returns nil if there are none.
+func (r *Route) Labels() map[string]string {
+	var labels map[string]string
+	set := func(from map[string]string) {
+		for k, v := range from {
+			if labels == nil {
+				labels = make(map[string]string)
+			}
+			labels[k] = v
+		}
+	}
+	var groups []*Group
+	for g := r.group; g != nil; g = g.parent {
+		groups = append(groups, g)
+	}
+	for i := len(groups) - 1; i >= 0; i-- {
+		set(groups[i].labels)
+	}
+	set(r.labels)
+	return labels
+}
+
+// Panics if key isn't usable as a label name by metrics backends:
+// letters, digits and underscores, not starting with a digit.
+func checkLabel(key string) {
+	for i, c := range key {
+		if c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || i > 0 && '0' <= c && c <= '9' {
+			continue
+		}
+		panic(fmt.Sprintf("Invalid label name '%s'", key))
+	}
+	if key == "" {
+		panic("Label name can't be empty")
+	}
+}
+
+// Returns a copy of labels.
+func copyLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+	c := make(map[string]string, len(labels))
+	for k, v := range labels {
+		c[k] = v
+	}
+	return c
+}
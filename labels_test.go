@@ -0,0 +1,53 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestLabels(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.TrackStats()
+	billing := m.Group("billing").Label("team", "payments").Label("domain", "billing")
+	invoices := billing.Group("invoices").Label("domain", "invoicing")
+	a := invoices.Add("GET", "{id}", dummy).Label("tier", "1")
+	b := m.Add("GET", "health", dummy)
+
+	want := map[string]string{"team": "payments", "domain": "invoicing", "tier": "1"}
+	if got := a.Labels(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Labels() = %v, want %v", got, want)
+	}
+	if got := b.Labels(); got != nil {
+		t.Errorf("Labels() of unlabeled route = %v", got)
+	}
+	if got := m.Stats()[0].Labels; !reflect.DeepEqual(got, want) {
+		t.Errorf("stats labels = %v, want %v", got, want)
+	}
+	if got := RouteInfos(m)[0].Labels; !reflect.DeepEqual(got, want) {
+		t.Errorf("route info labels = %v, want %v", got, want)
+	}
+
+	// Labels of clones are independent.
+	c := m.Clone()
+	c.Routes()[0].Label("tier", "2")
+	assertEqual(t, a.Labels()["tier"], "1")
+	assertEqual(t, c.Routes()[0].Labels()["tier"], "2")
+}
+
+func TestLabelInvalid(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	for _, key := range []string{"", "1tier", "team-name"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Label(%q) didn't panic", key)
+				}
+			}()
+			m.Add("GET", "a/"+key, dummy).Label(key, "x")
+		}()
+	}
+}
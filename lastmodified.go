@@ -0,0 +1,60 @@
+package muxer
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Function type returning the time a route's resource identified by params
+// v was last modified. Zero time means unknown.
+type LastModifiedFunc func(v url.Values) (time.Time, error)
+
+// Sets f to look up modification time of resources of this route. GET and
+// HEAD responses get a Last-Modified header, and conditional requests with
+// If-Modified-Since are answered with 304 Not Modified without calling
+// the handler:
+//
+//	m.Add("GET", "articles/{id}", showArticle).LastModified(func(v url.Values) (time.Time, error) {
+//		return articles.UpdatedAt(v.Get("id"))
+//	})
+//
+// Middleware and authorization still run for conditional requests.
+// Errors of f are passed to Error().
+func (r *Route) LastModified(f LastModifiedFunc) *Route {
+	r.lastModified = f
+	r.group.mux.handlersChanged()
+	return r
+}
+
+func lastModifiedHandler(f LastModifiedFunc, next HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		if r.Method != "GET" && r.Method != "HEAD" {
+			next(w, r, v)
+			return
+		}
+		mod, err := f(v)
+		if err != nil {
+			Error(w, r, err)
+			return
+		}
+		if mod.IsZero() || mod.Equal(time.Unix(0, 0)) {
+			next(w, r, v)
+			return
+		}
+		// HTTP dates have a resolution of one second.
+		mod = mod.Truncate(time.Second)
+		w.Header().Set("Last-Modified", mod.UTC().Format(http.TimeFormat))
+		// If-None-Match takes precedence, see RFC 9110, section 13.1.3.
+		if r.Header.Get("If-None-Match") == "" {
+			if ims, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !mod.After(ims) {
+				h := w.Header()
+				delete(h, "Content-Type")
+				delete(h, "Content-Length")
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		next(w, r, v)
+	}
+}
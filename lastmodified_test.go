@@ -0,0 +1,63 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestLastModified(t *testing.T) {
+	updated := time.Date(2024, 3, 1, 12, 0, 0, 500, time.UTC)
+	calls := 0
+	m := NewMux("/", http.NewServeMux())
+	h := func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		calls++
+		w.Write([]byte("article"))
+	}
+	m.Add("GET", "articles/{id}", h).LastModified(func(v url.Values) (time.Time, error) {
+		switch v.Get("id") {
+		case "new":
+			return time.Time{}, nil
+		case "bad":
+			return time.Time{}, errors.New("db is down")
+		}
+		return updated, nil
+	})
+
+	tests := []struct {
+		path, ims, inm string
+		code           int
+		lastModified   string
+		calls          int
+	}{
+		{"/articles/1", "", "", 200, "Fri, 01 Mar 2024 12:00:00 GMT", 1},
+		{"/articles/1", "Fri, 01 Mar 2024 12:00:00 GMT", "", 304, "Fri, 01 Mar 2024 12:00:00 GMT", 0},
+		{"/articles/1", "Fri, 01 Mar 2024 11:59:59 GMT", "", 200, "Fri, 01 Mar 2024 12:00:00 GMT", 1},
+		{"/articles/1", "Fri, 01 Mar 2024 12:00:00 GMT", `"v1"`, 200, "Fri, 01 Mar 2024 12:00:00 GMT", 1},
+		{"/articles/new", "Fri, 01 Mar 2024 12:00:00 GMT", "", 200, "", 1},
+		{"/articles/bad", "", "", 500, "", 0},
+	}
+	for i, test := range tests {
+		calls = 0
+		req, _ := http.NewRequest("GET", test.path, nil)
+		if test.ims != "" {
+			req.Header.Set("If-Modified-Since", test.ims)
+		}
+		if test.inm != "" {
+			req.Header.Set("If-None-Match", test.inm)
+		}
+		w := serveRequest(m, req)
+		if w.Code != test.code {
+			t.Errorf("%d: Expected %d, got %d", i, test.code, w.Code)
+		}
+		assertEqual(t, w.Header().Get("Last-Modified"), test.lastModified)
+		if calls != test.calls {
+			t.Errorf("%d: Expected %d handler calls, got %d", i, test.calls, calls)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package muxer
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Makes this mux reject requests with URL paths longer than maxLength
+// bytes with 414 URI Too Long, and paths of more than maxSegments segments
+// with 400 Bad Request, e.g. "/a/////////...". Limits are checked first,
+// before PreRoute hooks and matching, rejected requests are reported
+// to OnReject() hook. Zero disables a limit.
+//
+//	m.PathLimits(2048, 32)
+func (dm *defaultMux) PathLimits(maxLength, maxSegments int) {
+	if maxLength < 0 || maxSegments < 0 {
+		panic("Path limits can't be negative")
+	}
+	dm.maxPathLen, dm.maxSegments = maxLength, maxSegments
+}
+
+// Responds with an error if req exceeds path limits. Reports whether it has.
+func (dm *defaultMux) rejectPath(w http.ResponseWriter, req *http.Request) bool {
+	p := req.URL.Path
+	if dm.maxPathLen > 0 && len(p) > dm.maxPathLen {
+		dm.reject(w, req, NewStatusError(http.StatusRequestURITooLong,
+			fmt.Sprintf("Path longer than %d bytes", dm.maxPathLen)), "path too long")
+		return true
+	}
+	// Counting stops early, so a huge path costs no more than the limit.
+	if dm.maxSegments > 0 && len(p) > dm.maxSegments {
+		n := 0
+		for i := 0; i < len(p); i++ {
+			if p[i] == '/' {
+				if n++; n > dm.maxSegments {
+					dm.reject(w, req, NewStatusError(http.StatusBadRequest,
+						fmt.Sprintf("Path has more than %d segments", dm.maxSegments)), "too many segments")
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPathLimits(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "{a}/{b}/{c}", dummy)
+	m.PathLimits(32, 3)
+	for _, tc := range []struct {
+		path string
+		code int
+	}{
+		{"/a/b/c", 200},
+		{"/a/b/c/", 400},
+		{"/" + strings.Repeat("/", 1000), 414},
+		{"/" + strings.Repeat("x", 32), 414},
+		{"/a///", 400},
+	} {
+		if w := serve(m, "GET", tc.path); w.Code != tc.code {
+			t.Errorf("%.20s: code = %d, want %d", tc.path, w.Code, tc.code)
+		}
+	}
+	if w := serve(m.Clone(), "GET", "/a/b/c/"); w.Code != 400 {
+		t.Errorf("clone: code = %d, want 400", w.Code)
+	}
+	m.PathLimits(0, 0)
+	if w := serve(m, "GET", "/"+strings.Repeat("x", 32)); w.Code != 404 {
+		t.Errorf("no limits: code = %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,213 @@
+package muxer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Case of words in static segments of patterns, see LintStyle.
+type SegmentCase int
+
+const (
+	// "user-profiles". This is the default.
+	KebabCase SegmentCase = iota
+	// "user_profiles"
+	SnakeCase
+	// "userProfiles"
+	CamelCase
+)
+
+func (c SegmentCase) String() string {
+	switch c {
+	case KebabCase:
+		return "kebab-case"
+	case SnakeCase:
+		return "snake_case"
+	case CamelCase:
+		return "camelCase"
+	}
+	return fmt.Sprintf("SegmentCase(%d)", int(c))
+}
+
+// Form of collection names in patterns, see LintStyle.
+type NounForm int
+
+const (
+	// "users/{id}". This is the default.
+	PluralNouns NounForm = iota
+	// "user/{id}"
+	SingularNouns
+)
+
+// URL conventions checked by LintStyle.Lint(). The zero value is
+// kebab-case plural collections without trailing slashes.
+type LintStyle struct {
+	Case  SegmentCase
+	Nouns NounForm
+	// Whether patterns end with "/".
+	TrailingSlash bool
+}
+
+// A convention a pattern doesn't follow, found by Lint().
+type LintFinding struct {
+	Pattern string
+	// Index of the offending segment, -1 for the pattern as a whole.
+	Segment int
+	// "trailing-slash", "case" or "noun".
+	Rule    string
+	Message string
+	// The pattern with the problem fixed.
+	Fix string
+}
+
+func (f LintFinding) String() string {
+	return fmt.Sprintf("%s: %s, use '%s'", f.Pattern, f.Message, f.Fix)
+}
+
+// Checks patterns against the default LintStyle, e.g. in a test of an
+// app's routes:
+//
+//	var patterns []string
+//	for _, r := range app.Routes().Routes() {
+//		patterns = append(patterns, r.Pattern)
+//	}
+//	for _, f := range muxer.Lint(patterns...) {
+//		t.Error(f)
+//	}
+func Lint(patterns ...string) []LintFinding {
+	return LintStyle{}.Lint(patterns...)
+}
+
+// Returns findings of patterns not following s, with suggested fixes.
+// Collections are static segments followed by a param, e.g. "users" of
+// "users/{id}". Their plural and singular forms are guessed from English
+// suffixes, so irregular nouns aren't recognized.
+func (s LintStyle) Lint(patterns ...string) []LintFinding {
+	var findings []LintFinding
+	for _, p := range patterns {
+		switch {
+		case p == "":
+		case strings.HasSuffix(p, "/") && !s.TrailingSlash:
+			findings = append(findings, LintFinding{p, -1, "trailing-slash",
+				"trailing slash", strings.TrimRight(p, "/")})
+		case !strings.HasSuffix(p, "/") && s.TrailingSlash:
+			findings = append(findings, LintFinding{p, -1, "trailing-slash",
+				"no trailing slash", p + "/"})
+		}
+		segs := strings.Split(strings.Trim(p, "/"), "/")
+		for i, seg := range segs {
+			if seg == "" || strings.HasPrefix(seg, "{") {
+				continue
+			}
+			fixed := seg
+			if words, ok := segmentWords(seg); ok {
+				if fixed = s.Case.join(words); fixed != seg {
+					findings = append(findings, LintFinding{p, i, "case",
+						fmt.Sprintf("segment '%s' isn't %s", seg, s.Case), replaceSegment(p, i, fixed)})
+				}
+			}
+			if i+1 == len(segs) || !strings.HasPrefix(segs[i+1], "{") {
+				continue
+			}
+			if noun := s.Nouns.fix(fixed); noun != fixed {
+				form := "plural"
+				if s.Nouns == SingularNouns {
+					form = "singular"
+				}
+				findings = append(findings, LintFinding{p, i, "noun",
+					fmt.Sprintf("collection '%s' isn't %s", seg, form), replaceSegment(p, i, noun)})
+			}
+		}
+	}
+	return findings
+}
+
+// Returns p with segment i, counted without a leading "/", replaced by seg.
+func replaceSegment(p string, i int, seg string) string {
+	lead := strings.HasPrefix(p, "/")
+	segs := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	segs[i] = seg
+	fixed := strings.Join(segs, "/")
+	if lead {
+		fixed = "/" + fixed
+	}
+	return fixed
+}
+
+// Returns lowercase words of static segment seg split on "-", "_" and
+// case changes. Reports false for segments with other characters, e.g.
+// "robots.txt", which aren't checked.
+func segmentWords(seg string) ([]string, bool) {
+	var words []string
+	var word []rune
+	prev := rune(0)
+	for _, c := range seg {
+		switch {
+		case c == '-' || c == '_':
+			if len(word) > 0 {
+				words = append(words, string(word))
+			}
+			word = word[:0]
+		case unicode.IsUpper(c):
+			if len(word) > 0 && (unicode.IsLower(prev) || unicode.IsDigit(prev)) {
+				words = append(words, string(word))
+				word = word[:0]
+			}
+			word = append(word, unicode.ToLower(c))
+		case unicode.IsLower(c) || unicode.IsDigit(c):
+			word = append(word, c)
+		default:
+			return nil, false
+		}
+		prev = c
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words, len(words) > 0
+}
+
+// Returns words joined in this case.
+func (c SegmentCase) join(words []string) string {
+	switch c {
+	case SnakeCase:
+		return strings.Join(words, "_")
+	case CamelCase:
+		var b strings.Builder
+		for i, w := range words {
+			if i > 0 {
+				w = strings.ToUpper(w[:1]) + w[1:]
+			}
+			b.WriteString(w)
+		}
+		return b.String()
+	}
+	return strings.Join(words, "-")
+}
+
+// Returns name with its last word in this form.
+func (f NounForm) fix(name string) string {
+	plural := strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss")
+	switch {
+	case f == PluralNouns && !plural:
+		switch {
+		case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsRune("aeiou", rune(name[len(name)-2])):
+			return name[:len(name)-1] + "ies"
+		case strings.HasSuffix(name, "s") || strings.HasSuffix(name, "x") ||
+			strings.HasSuffix(name, "ch") || strings.HasSuffix(name, "sh"):
+			return name + "es"
+		}
+		return name + "s"
+	case f == SingularNouns && plural:
+		switch {
+		case strings.HasSuffix(name, "ies"):
+			return name[:len(name)-3] + "y"
+		case strings.HasSuffix(name, "sses") || strings.HasSuffix(name, "xes") ||
+			strings.HasSuffix(name, "ches") || strings.HasSuffix(name, "shes"):
+			return name[:len(name)-2]
+		}
+		return name[:len(name)-1]
+	}
+	return name
+}
@@ -0,0 +1,37 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	got := Lint("users/{id}", "orders/", "userProfiles/{id}", "category/{id}/items",
+		"robots.txt", "/box/{id}", "")
+	want := []LintFinding{
+		{"orders/", -1, "trailing-slash", "trailing slash", "orders"},
+		{"userProfiles/{id}", 0, "case", "segment 'userProfiles' isn't kebab-case", "user-profiles/{id}"},
+		{"category/{id}/items", 0, "noun", "collection 'category' isn't plural", "categories/{id}/items"},
+		{"/box/{id}", 0, "noun", "collection 'box' isn't plural", "/boxes/{id}"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Lint() = %v\nwant %v", got, want)
+	}
+	assertEqual(t, want[1].String(),
+		"userProfiles/{id}: segment 'userProfiles' isn't kebab-case, use 'user-profiles/{id}'")
+
+	style := LintStyle{Case: SnakeCase, Nouns: SingularNouns, TrailingSlash: true}
+	got = style.Lint("user-profiles/{id}/", "addresses/{id}/", "user/{id}")
+	want = []LintFinding{
+		{"user-profiles/{id}/", 0, "case", "segment 'user-profiles' isn't snake_case", "user_profiles/{id}/"},
+		{"user-profiles/{id}/", 0, "noun", "collection 'user-profiles' isn't singular", "user_profile/{id}/"},
+		{"addresses/{id}/", 0, "noun", "collection 'addresses' isn't singular", "address/{id}/"},
+		{"user/{id}", -1, "trailing-slash", "no trailing slash", "user/{id}/"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Lint() = %v\nwant %v", got, want)
+	}
+}
@@ -0,0 +1,55 @@
+package muxer
+
+import (
+	"context"
+	"net/http"
+)
+
+type listenerKey struct{}
+
+// Binds routes of this group and its nested groups to listener name,
+// e.g. "admin". Such routes are served only by the handler returned by
+// Mux.Listener(name), not by the mux itself:
+//
+//	ops := m.Group("ops").Listener("admin")
+//	ops.Add("GET", "metrics", metrics)
+//	go http.ListenAndServe("127.0.0.1:9090", m.Listener("admin"))
+//
+// Nested groups can be bound to another listener. Zero string is the
+// default listener of the mux.
+func (g *Group) Listener(name string) *Group {
+	g.listener = name
+	return g
+}
+
+// Returns the listener this group is bound to, inherited from its parents.
+func (g *Group) listenerName() string {
+	for ; g != nil; g = g.parent {
+		if g.listener != "" {
+			return g.listener
+		}
+	}
+	return ""
+}
+
+// Returns the listener this route is served on.
+func (r *Route) listener() string {
+	return r.group.listenerName()
+}
+
+// Returns a handler serving routes of groups bound to listener name,
+// see Group.Listener(). Requests go through the same PreRoute hooks,
+// middleware and error handlers as the ones served by the mux itself,
+// which serves only routes of the default listener. Requests for routes
+// of other listeners are treated as not found.
+func (dm *defaultMux) Listener(name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		dm.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), listenerKey{}, name)))
+	})
+}
+
+// Returns the listener request req has been received on.
+func listenerOf(req *http.Request) string {
+	name, _ := req.Context().Value(listenerKey{}).(string)
+	return name
+}
@@ -0,0 +1,50 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestListeners(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "users/{id}", dummy)
+	ops := m.Group("ops").Listener("admin").
+		NotFound(func(w http.ResponseWriter, r *http.Request, v url.Values) {
+			http.Error(w, "no such op", http.StatusNotFound)
+		})
+	ops.Add("GET", "metrics", dummy)
+	ops.Group("debug").Add("GET", "vars", dummy)
+	ops.Group("public").Listener("status").Add("GET", "health", dummy)
+	admin := m.Listener("admin")
+	status := m.Listener("status")
+
+	tests := []struct {
+		h    http.Handler
+		path string
+		code int
+		body string
+	}{
+		{m, "/users/1", 200, "params:id=1"},
+		{m, "/ops/metrics", 404, "404 page not found\n"},
+		{m, "/ops/debug/vars", 404, "404 page not found\n"},
+		{admin, "/ops/metrics", 200, ""},
+		{admin, "/ops/debug/vars", 200, ""},
+		{admin, "/ops/nope", 404, "no such op\n"},
+		{admin, "/users/1", 404, "404 page not found\n"},
+		{admin, "/ops/public/health", 404, "no such op\n"},
+		{status, "/ops/public/health", 200, ""},
+	}
+	for i, test := range tests {
+		w := serve(test.h, "GET", test.path)
+		if w.Code != test.code {
+			t.Errorf("%d: Expected %d, got %d", i, test.code, w.Code)
+		}
+		if test.body != "" {
+			assertEqual(t, w.Body.String(), test.body)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package muxer
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Priority of a route. Lower priority routes are the first to be shed
+// under load, see LoadShedder.
+type Priority int
+
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	// Routes with high priority, e.g. health checks, are never shed.
+	PriorityHigh Priority = 1
+)
+
+// Sets priority of this route. Default is PriorityNormal.
+func (r *Route) Prioritize(p Priority) *Route {
+	r.Priority = p
+	return r
+}
+
+// LoadShedder protects a mux from overload by rejecting requests to lower
+// priority routes with 503 Service Unavailable. Load is the larger of
+// in-flight requests relative to MaxInFlight and moving average latency
+// relative to MaxLatency:
+//
+//   - at 80% load, PriorityLow routes are shed;
+//   - at 100% load, PriorityNormal routes are shed too.
+//
+// PriorityHigh routes are never shed. Shed requests weigh latency down, so
+// the mux starts serving requests again as soon as they can be let through.
+// Use it as a mux middleware:
+//
+//	m.Use(muxer.NewLoadShedder(500, 200*time.Millisecond).Middleware())
+type LoadShedder struct {
+	// Zero disables the corresponding load measure.
+	MaxInFlight int
+	MaxLatency  time.Duration
+	// Called for each rejected request, if not nil.
+	OnShed func(r *http.Request, route *Route)
+
+	mu       sync.Mutex
+	inFlight int
+	latency  time.Duration // exponentially weighted moving average
+}
+
+// Creates a new load shedder. See LoadShedder for the meaning of params.
+func NewLoadShedder(maxInFlight int, maxLatency time.Duration) *LoadShedder {
+	return &LoadShedder{MaxInFlight: maxInFlight, MaxLatency: maxLatency}
+}
+
+// Returns current load: 1 means at the limit.
+func (s *LoadShedder) Load() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadLocked()
+}
+
+func (s *LoadShedder) loadLocked() float64 {
+	var load float64
+	if s.MaxInFlight > 0 {
+		load = float64(s.inFlight) / float64(s.MaxInFlight)
+	}
+	if s.MaxLatency > 0 {
+		if l := float64(s.latency) / float64(s.MaxLatency); l > load {
+			load = l
+		}
+	}
+	return load
+}
+
+// Reports whether a request to a route with priority p should be rejected
+// at current load. Otherwise counts the request as in-flight.
+func (s *LoadShedder) admit(p Priority) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	load := s.loadLocked()
+	switch {
+	case p >= PriorityHigh:
+	case load >= 1, p < PriorityNormal && load >= 0.8:
+		// Shed requests count as fast ones, otherwise latency would
+		// never go down again without any requests being served.
+		s.latency -= s.latency / 8
+		return false
+	}
+	s.inFlight++
+	return true
+}
+
+func (s *LoadShedder) done(elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight--
+	s.latency += (elapsed - s.latency) / 8
+}
+
+// Returns middleware applying load shedding to the routes it wraps.
+func (s *LoadShedder) Middleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+			p := PriorityNormal
+			route := CurrentRoute(r)
+			if route != nil {
+				p = route.Priority
+			}
+			if !s.admit(p) {
+				if s.OnShed != nil {
+					s.OnShed(r, route)
+				}
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable),
+					http.StatusServiceUnavailable)
+				return
+			}
+			start := time.Now()
+			defer func() { s.done(time.Since(start)) }()
+			next(w, r, v)
+		}
+	}
+}
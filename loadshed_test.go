@@ -0,0 +1,78 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestLoadShedder(t *testing.T) {
+	s := NewLoadShedder(10, time.Second)
+	shed := 0
+	s.OnShed = func(r *http.Request, route *Route) { shed++ }
+	m := NewMux("/", http.NewServeMux())
+	m.Use(s.Middleware())
+	m.Add("GET", "export", dummy).Prioritize(PriorityLow)
+	m.Add("GET", "users", dummy)
+	m.Add("GET", "health", dummy).Prioritize(PriorityHigh)
+
+	codes := func() (codes [3]int) {
+		for i, p := range []string{"/export", "/users", "/health"} {
+			codes[i] = serve(m, "GET", p).Code
+		}
+		return
+	}
+	if c := codes(); c != [3]int{200, 200, 200} {
+		t.Fatalf("Expected all 200 OK without load, got %v", c)
+	}
+
+	s.mu.Lock()
+	s.inFlight = 8
+	s.mu.Unlock()
+	if c := codes(); c != [3]int{503, 200, 200} {
+		t.Fatalf("Expected low priority to be shed at 80%%, got %v", c)
+	}
+
+	s.mu.Lock()
+	s.inFlight = 0
+	s.latency = 2 * time.Second
+	s.mu.Unlock()
+	if c := codes(); c != [3]int{503, 503, 200} {
+		t.Fatalf("Expected only high priority at full load, got %v", c)
+	}
+	if shed != 3 {
+		t.Fatalf("Expected 3 shed requests, got %d", shed)
+	}
+	w := serve(m, "GET", "/users")
+	assertEqual(t, w.Header().Get("Retry-After"), "1")
+}
+
+func TestLoadShedderLatency(t *testing.T) {
+	s := NewLoadShedder(0, 10*time.Millisecond)
+	m := NewMux("/", http.NewServeMux())
+	m.Use(s.Middleware())
+	m.Add("GET", "slow", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		time.Sleep(20 * time.Millisecond)
+	})
+	serve(m, "GET", "/slow")
+	if l := s.Load(); l < 0.2 {
+		t.Fatalf("Expected load of at least 0.2 after a slow request, got %f", l)
+	}
+
+	s.mu.Lock()
+	s.latency = 20 * time.Millisecond
+	s.mu.Unlock()
+	shed := 0
+	for serve(m, "GET", "/slow").Code == http.StatusServiceUnavailable {
+		shed++
+	}
+	// Average latency drops below the limit after 6 shed requests:
+	// 20ms * (7/8)^6 = 8.97ms.
+	if shed != 6 {
+		t.Fatalf("Expected 6 shed requests before recovery, got %d", shed)
+	}
+}
@@ -0,0 +1,129 @@
+package muxer
+
+import (
+	"container/list"
+	"hash/maphash"
+	"net/url"
+	"sync"
+	"sync/atomic"
+)
+
+// Number of independently locked parts of a match cache, so that
+// concurrent requests rarely wait for each other.
+const matchCacheShards = 16
+
+// Makes this mux remember up to size most recently matched request paths
+// with their routes, so that frequent URLs, e.g. "/api/health" or a popular
+// product page, skip matching. Pays off with skewed traffic and large route
+// tables. The cache is emptied whenever a route is added, disabled or
+// enabled. Call it before serving requests.
+func (dm *defaultMux) MatchCache(size int) {
+	if size <= 0 {
+		dm.cache = nil
+		return
+	}
+	c := &matchCache{size: size, seed: maphash.MakeSeed()}
+	for i := range c.shards {
+		// Shards hold size entries in total.
+		per := size / matchCacheShards
+		if i < size%matchCacheShards {
+			per++
+		}
+		c.shards[i] = lruShard{size: per, items: make(map[string]*list.Element), ll: list.New()}
+	}
+	dm.cache = c
+}
+
+type matchCache struct {
+	size int
+	seed maphash.Seed
+	// Incremented when entries are dropped, so that matches against
+	// the previous route table aren't stored.
+	gen    atomic.Uint64
+	shards [matchCacheShards]lruShard
+}
+
+type lruShard struct {
+	mu    sync.Mutex
+	size  int
+	items map[string]*list.Element
+	ll    *list.List
+}
+
+type matchEntry struct {
+	key   string
+	route *Route
+	vals  url.Values
+}
+
+// Returns the cache key of a request.
+func matchKey(method, path, listener string) string {
+	return method + " " + listener + " " + path
+}
+
+func (c *matchCache) shard(key string) *lruShard {
+	return &c.shards[maphash.String(c.seed, key)%matchCacheShards]
+}
+
+// Returns the cached route of key and a copy of its params, since
+// handlers may change them.
+func (c *matchCache) get(key string) (*Route, url.Values) {
+	s := c.shard(key)
+	s.mu.Lock()
+	el, ok := s.items[key]
+	if !ok {
+		s.mu.Unlock()
+		return nil, nil
+	}
+	s.ll.MoveToFront(el)
+	e := el.Value.(*matchEntry)
+	s.mu.Unlock()
+	vals := make(url.Values, len(e.vals))
+	for k, v := range e.vals {
+		vals[k] = append([]string(nil), v...)
+	}
+	return e.route, vals
+}
+
+// Stores a match of key made with the route table of generation gen.
+func (c *matchCache) put(gen uint64, key string, r *Route, v url.Values) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c.gen.Load() != gen || s.size == 0 {
+		return
+	}
+	if el, ok := s.items[key]; ok {
+		s.ll.MoveToFront(el)
+		return
+	}
+	vals := make(url.Values, len(v))
+	for k, vs := range v {
+		vals[k] = append([]string(nil), vs...)
+	}
+	s.items[key] = s.ll.PushFront(&matchEntry{key, r, vals})
+	if s.ll.Len() > s.size {
+		last := s.ll.Back()
+		s.ll.Remove(last)
+		delete(s.items, last.Value.(*matchEntry).key)
+	}
+}
+
+// Drops cached matches once routes are added, disabled or enabled.
+func (dm *defaultMux) routesChanged() {
+	if dm.cache != nil {
+		dm.cache.reset()
+	}
+}
+
+// Drops all entries.
+func (c *matchCache) reset() {
+	c.gen.Add(1)
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		clear(s.items)
+		s.ll.Init()
+		s.mu.Unlock()
+	}
+}
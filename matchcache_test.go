@@ -0,0 +1,90 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestMatchCache(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.MatchCache(32)
+	dm := m.(*defaultMux)
+	a := m.Add("GET", "users/{id}", dummy).As("a")
+
+	r, v := dm.match("GET", "users/1", "")
+	if r != a || v.Get("id") != "1" {
+		t.Fatalf("match = %v, %v", r, v)
+	}
+	// Cached params are copied, so changing them doesn't leak.
+	v.Set("id", "changed")
+	if _, v := dm.cache.get(matchKey("GET", "users/1", "")); v.Get("id") != "1" {
+		t.Errorf("cached id = %q, want 1", v.Get("id"))
+	}
+
+	b := m.Add("GET", "users/me", dummy)
+	if _, v := dm.cache.get(matchKey("GET", "users/1", "")); v != nil {
+		t.Error("cache not emptied after adding a route")
+	}
+	a.Disable()
+	if r, _ := dm.match("GET", "users/me", ""); r != b {
+		t.Errorf("match with a disabled = %v, want b", r)
+	}
+	a.Enable()
+	if r, _ := dm.match("GET", "users/me", ""); r != a {
+		t.Errorf("match with a enabled = %v, want a", r)
+	}
+	assertEqual(t, serve(m, "GET", "/users/2").Body.String(), "params:id=2")
+	assertEqual(t, serve(m, "GET", "/users/2").Body.String(), "params:id=2")
+}
+
+func TestMatchCacheSize(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	r := m.Add("GET", "{id}", dummy)
+	for _, size := range []int{1, 20, 3 * matchCacheShards} {
+		m.MatchCache(size)
+		c := m.(*defaultMux).cache
+		gen := c.gen.Load()
+		for i := 0; i < 1000; i++ {
+			c.put(gen, matchKey("GET", fmt.Sprint(i), ""), r, nil)
+		}
+		n := 0
+		for i := range c.shards {
+			n += c.shards[i].ll.Len()
+		}
+		if n != size {
+			t.Errorf("MatchCache(%d) holds %d entries", size, n)
+		}
+	}
+}
+
+func TestMatchCacheEviction(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.MatchCache(matchCacheShards)
+	c := m.(*defaultMux).cache
+	r := m.Add("GET", "{id}", dummy)
+	// Keys of the same shard, which holds one entry.
+	var keys []string
+	for i := 0; len(keys) < 2; i++ {
+		if k := matchKey("GET", fmt.Sprint(i), ""); c.shard(k) == &c.shards[0] {
+			keys = append(keys, k)
+		}
+	}
+	gen := c.gen.Load()
+	c.put(gen, keys[0], r, nil)
+	c.put(gen, keys[1], r, nil)
+	if got, _ := c.get(keys[0]); got != nil {
+		t.Error("least recently used entry not evicted")
+	}
+	if got, _ := c.get(keys[1]); got != r {
+		t.Error("recent entry evicted")
+	}
+	// Stale generation.
+	c.put(gen-1, keys[0], r, nil)
+	if got, _ := c.get(keys[0]); got != nil {
+		t.Error("match of a previous route table cached")
+	}
+}
@@ -0,0 +1,212 @@
+package muxer
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"runtime"
+)
+
+// Middleware wraps a route handler, e.g. to log requests or check
+// authentication before calling the next handler in the chain.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Adds middleware applied to all routes of this mux.
+// The first added is the outermost one.
+func (dm *defaultMux) Use(mw ...Middleware) {
+	dm.root.Use(mw...)
+}
+
+// Adds middleware applied to all routes of this group and its nested groups,
+// after its parent's middleware.
+func (g *Group) Use(mw ...Middleware) *Group {
+	g.middleware = appendMiddleware(g.middleware, mw)
+	g.mux.handlersChanged()
+	return g
+}
+
+// Same as Use(), contrasting with Prepend().
+func (g *Group) Append(mw ...Middleware) *Group {
+	return g.Use(mw...)
+}
+
+// Adds middleware applied to all routes of this group and its nested groups
+// before its parent's middleware, e.g. request ID or tracing which must see
+// every request before logging and auth set up by the parents:
+//
+//	m.Use(logging, auth)
+//	api := m.Group("api").Prepend(tracing).Use(rateLimit)
+//	// Routes of api run tracing, logging, auth, rateLimit.
+//
+// Prepended middleware of a group runs in the order it was added.
+// See Route.MiddlewareChain() for the resulting order.
+func (g *Group) Prepend(mw ...Middleware) *Group {
+	g.prepend = appendMiddleware(g.prepend, mw)
+	g.mux.handlersChanged()
+	return g
+}
+
+// Wraps h in middleware of this group and its parents.
+func (g *Group) wrap(h HandlerFunc, skip []string) HandlerFunc {
+	h = wrapMiddleware(g.middleware, h, skip)
+	if g.parent != nil {
+		h = g.parent.wrap(h, skip)
+	}
+	return wrapMiddleware(g.prepend, h, skip)
+}
+
+// Middleware of a route's chain, see Route.MiddlewareChain().
+type MiddlewareInfo struct {
+	// Name the middleware was registered with, see Mux.Middleware(),
+	// or of its function, e.g. "main.logRequests".
+	Name string
+	// Where the middleware was added: "route", "mux" or the prefix
+	// of a group, e.g. "api/".
+	Source string
+	// Added with Group.Prepend().
+	Prepended bool
+}
+
+// Returns the middleware wrapping this route's handler in the order
+// it runs, the outermost first.
+func (r *Route) MiddlewareChain() []MiddlewareInfo {
+	links := r.group.chain(nil, r.skip)
+	for _, mw := range r.middleware {
+		links = append(links, MiddlewareInfo{Name: mw.displayName(), Source: "route"})
+	}
+	return links
+}
+
+// Appends middleware of this group and its parents to links in the order
+// it runs.
+func (g *Group) chain(links []MiddlewareInfo, skip []string) []MiddlewareInfo {
+	source := g.prefix
+	if g.parent == nil {
+		source = "mux"
+	}
+	for _, mw := range g.prepend {
+		if !mw.skipped(skip) {
+			links = append(links, MiddlewareInfo{Name: mw.displayName(), Source: source, Prepended: true})
+		}
+	}
+	if g.parent != nil {
+		links = g.parent.chain(links, skip)
+	}
+	for _, mw := range g.middleware {
+		if !mw.skipped(skip) {
+			links = append(links, MiddlewareInfo{Name: mw.displayName(), Source: source})
+		}
+	}
+	return links
+}
+
+// Returns the full name of function f.
+func funcName(f interface{}) string {
+	if fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer()); fn != nil {
+		return fn.Name()
+	}
+	return ""
+}
+
+// Adds middleware applied only to this route, after the middleware
+// of its group.
+func (r *Route) Use(mw ...Middleware) *Route {
+	r.middleware = appendMiddleware(r.middleware, mw)
+	r.group.mux.handlersChanged()
+	return r
+}
+
+// Returns route handler wrapped in its own middleware and the middleware
+// of its group and all parents. PostMatch hooks and authorization run
+// right before the handler, TLS requirements before any middleware.
+func (r *Route) handler() HandlerFunc {
+	h := r.Handler
+	if r.sampler != nil {
+		h = handlerPhase(h)
+	}
+	if r.pool != nil {
+		h = r.pool.wrap(h)
+	}
+	if r.lastModified != nil {
+		h = lastModifiedHandler(r.lastModified, h)
+	}
+	if r.policy != "" {
+		h = authorizeHandler(r.group.mux, r.policy, h)
+	}
+	if hooks := r.group.mux.postMatch; len(hooks) > 0 {
+		h = postMatchHandler(hooks, h)
+	}
+	h = wrapMiddleware(r.middleware, h, nil)
+	h = r.group.wrap(h, r.skip)
+	if r.tlsVersion != 0 {
+		h = requireTLSHandler(r.group.mux, r.tlsVersion, h)
+	}
+	return h
+}
+
+// Returns handler() of this route, built once rather than for every
+// request, so that middleware runs its factory, e.g. setting up a counter
+// or a cache, once too. It's built anew after middleware, hooks or options
+// wrapping the handler change, see handlersChanged().
+func (r *Route) builtHandler() HandlerFunc {
+	gen := r.group.mux.handlersGen.Load()
+	if b := r.live.handler.Load(); b != nil && b.gen == gen {
+		return b.h
+	}
+	h := r.handler()
+	r.live.handler.Store(&builtHandler{gen, h})
+	return h
+}
+
+// A route handler built with middleware of generation gen.
+type builtHandler struct {
+	gen uint64
+	h   HandlerFunc
+}
+
+// Makes routes build their handlers anew, called whenever what
+// Route.handler() wraps them in changes.
+func (dm *defaultMux) handlersChanged() {
+	dm.handlersGen.Add(1)
+}
+
+// Function type of hooks called after a route has been matched, once all
+// middleware has run, right before the handler. A hook can enrich
+// the request, e.g. its context, by returning a new one, or veto it
+// by returning a non-zero HTTP status code. Returning nil request keeps
+// it as is.
+type PostMatchFunc func(r *http.Request, route *Route, v url.Values) (*http.Request, int)
+
+// Adds a hook called for every matched request, see PostMatchFunc.
+// This is the natural place for per-route authorization decisions:
+//
+//	m.PostMatch(func(r *http.Request, route *muxer.Route, v url.Values) (*http.Request, int) {
+//		if route.HasTag("admin") && !isAdmin(r) {
+//			return nil, http.StatusForbidden
+//		}
+//		return nil, 0
+//	})
+//
+// Vetoed requests are responded to with a *StatusError passed to Error(),
+// so the error handler of the route's group can render it.
+func (dm *defaultMux) PostMatch(f PostMatchFunc) {
+	dm.postMatch = append(dm.postMatch, f)
+	dm.handlersChanged()
+}
+
+func postMatchHandler(hooks []PostMatchFunc, next HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		route := CurrentRoute(r)
+		for _, f := range hooks {
+			req, code := f(r, route, v)
+			if req != nil {
+				r = req
+			}
+			if code != 0 {
+				Error(w, r, NewStatusError(code, ""))
+				return
+			}
+		}
+		next(w, r, v)
+	}
+}
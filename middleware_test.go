@@ -0,0 +1,112 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPrepend(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Use(tagMiddleware("log"), tagMiddleware("auth"))
+	api := m.Group("api").Prepend(tagMiddleware("trace")).Use(tagMiddleware("limit"))
+	v1 := api.Group("v1").Append(tagMiddleware("v1")).Prepend(tagMiddleware("id"))
+	v1.Add("GET", "x", dummy).Use(tagMiddleware("route"))
+
+	assertEqual(t, serve(m, "GET", "/api/v1/x").Body.String(),
+		"id(trace(log(auth(limit(v1(route(params:)))))))")
+}
+
+func namedA(next HandlerFunc) HandlerFunc { return next }
+func namedB(next HandlerFunc) HandlerFunc { return next }
+
+func TestRouteChain(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Use(namedA)
+	r := m.Group("api").Prepend(namedB).Use(namedA).Add("GET", "x", dummy).Use(namedB)
+
+	var got []string
+	for _, l := range r.MiddlewareChain() {
+		name := l.Name[strings.LastIndexByte(l.Name, '.')+1:]
+		if l.Prepended {
+			name += "^"
+		}
+		got = append(got, name+"@"+l.Source)
+	}
+	want := []string{"namedB^@api/", "namedA@mux", "namedA@api/", "namedB@route"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Chain() = %v, want %v", got, want)
+	}
+}
+
+func TestNamedMiddleware(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Middleware("auth", tagMiddleware("auth"))
+	m.Middleware("gzip", tagMiddleware("gzip"))
+	m.UseNamed("gzip")
+	r := m.Group("api").PrependNamed("auth").Add("GET", "x", dummy).UseNamed("auth")
+
+	assertEqual(t, serve(m, "GET", "/api/x").Body.String(), "auth(gzip(auth(params:)))")
+	var names []string
+	for _, l := range r.MiddlewareChain() {
+		names = append(names, l.Name)
+	}
+	assertEqual(t, strings.Join(names, ","), "auth,gzip,auth")
+}
+
+func TestUseNamedPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("UseNamed(missing) didn't panic")
+		}
+	}()
+	NewMux("/", http.NewServeMux()).UseNamed("missing")
+}
+
+func TestSkip(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Middleware("auth", tagMiddleware("auth"))
+	m.Middleware("log", tagMiddleware("log"))
+	m.UseNamed("log", "auth")
+	m.Use(tagMiddleware("anon"))
+	api := m.Group("api").PrependNamed("auth")
+	api.Add("GET", "x", dummy)
+	r := api.Add("GET", "healthz", dummy).Skip("auth").UseNamed("auth")
+
+	assertEqual(t, serve(m, "GET", "/api/x").Body.String(), "auth(log(auth(anon(params:))))")
+	// Route's own middleware isn't skipped.
+	assertEqual(t, serve(m, "GET", "/api/healthz").Body.String(), "log(anon(auth(params:)))")
+	if n := len(r.MiddlewareChain()); n != 3 {
+		t.Errorf("len(MiddlewareChain()) = %d, want 3", n)
+	}
+}
+
+func TestMiddlewareBuiltOnce(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	built := 0
+	counter := func(next HandlerFunc) HandlerFunc {
+		built++
+		n := 0
+		return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+			n++
+			w.Header().Set("X-Count", fmt.Sprint(n))
+			next(w, r, v)
+		}
+	}
+	m.Use(counter)
+	m.Add("GET", "a", dummy)
+	serve(m, "GET", "/a")
+	if w := serve(m, "GET", "/a"); w.Header().Get("X-Count") != "2" || built != 1 {
+		t.Errorf("X-Count = %q, built %d times, want 2, once", w.Header().Get("X-Count"), built)
+	}
+	m.Use(func(next HandlerFunc) HandlerFunc { return next })
+	if w := serve(m, "GET", "/a"); w.Header().Get("X-Count") != "1" || built != 2 {
+		t.Errorf("after Use(): X-Count = %q, built %d times, want 1, twice", w.Header().Get("X-Count"), built)
+	}
+}
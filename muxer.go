@@ -1,5 +1,5 @@
 /*
-Simple muxer without regexp.
+Simple muxer, matching without regexp unless a param is constrained with one.
 Usage example:
 
 	package myapp
@@ -21,31 +21,122 @@ Usage example:
 		m.Add("GET", "products", handler2)
 		m.Add("PUT", "products/{id}/do", handler3)
 		m.Add("POST", "{domain}/{action}/{id}", handler4).As("whatever")
+		m.Add("GET", "files/{path...}", handler5)
 	}
 
+A trailing catch-all param, e.g. "{path...}", matches one or more remaining
+segments: "/api/files/a/b/c.png" gets path "a/b/c.png".
+
+A param can be constrained with a regular expression matching the whole
+segment, e.g. "users/{id:[0-9]+}", which can't contain "/". Routes whose
+constraint doesn't match are skipped, so a later route or 404 applies.
+Constraints are compiled once, when a route is added. Built-in ones, e.g.
+"users/{id:int}", respond with 400 Bad Request instead when no other route
+matches, see InvalidParamStatus().
+
+HEAD requests are served by the matching GET route with the body
+discarded, unless there's a HEAD route for them or the GET route opts out
+with NoHead().
+
 See muxer_test.go for more.
 */
 package muxer
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"io/fs"
 	"net/http"
+	"net/netip"
 	"net/url"
 	"path"
+	"reflect"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type Mux interface {
 	BasePath() string
 	Routes() []*Route
 	Add(method string, pattern string, h HandlerFunc) *Route
+	Group(prefix string) *Group
+	NotFound(h HandlerFunc)
+	MethodNotAllowed(h HandlerFunc)
 	BuildPath(routeName string, params ...interface{}) string
 	ServeHTTP(w http.ResponseWriter, req *http.Request)
+	SetStrictness(s Strictness)
+	OnWarning(f WarningFunc)
+	NameCollisions(c NameCollision)
+	Chain(next Mux) Mux
+	Use(mw ...Middleware)
+	Register(rs ...Registrar)
+	Registrars() []Registrar
+	Clone() Mux
+	Fingerprint() string
+	UseMatcher(f MatcherFunc, fingerprint string)
+	MatchCache(size int)
+	PathLimits(maxLength, maxSegments int)
+	HardenPaths()
+	OnReject(f RejectFunc)
+	GuardHeaders()
+	CasePolicy(p CasePolicy)
+	RedirectTrailingSlash()
+	TrustProxies(cidrs ...string)
+	Middleware(name string, mw Middleware)
+	UseNamed(names ...string)
+	IsolatePanics(limit int, window time.Duration, onPanic PanicFunc)
+	AddValidator(f ValidatorFunc)
+	Validate() error
+	Environment(name string)
+	Env() string
+	RecordExamples(sanitize SanitizeFunc)
+	WatchSLOs(window time.Duration, threshold float64, f BurnFunc) (stop func())
+	AutoThrottle(window time.Duration, t Throttle, notify ThrottleFunc) (stop func())
+	Subscribe(f EventFunc, kinds ...EventKind) (unsubscribe func())
+	Redact(r Redaction)
+	Redaction() *Redaction
+	Transform(tag string, f TransformFunc)
+	RobotsTxt(rules string)
+	Favicon(icon interface{})
+	WellKnown(name, content string)
+	WellKnownHandler(name string, h HandlerFunc)
+	AddBasePath(basePath string)
+	BasePaths() []string
+	ExternalPrefix(prefix string)
+	BuildPathFor(req *http.Request, routeName string, params ...interface{}) string
+	BuildURL(req *http.Request, routeName string, params ...interface{}) string
+	ClientIP(req *http.Request) string
+	ExportTemplates(w io.Writer) error
+	Assets(pattern string, fsys fs.FS) *Route
+	Pool(name string, workers, queueLen int) *Pool
+	PreRoute(f func(*http.Request) *http.Request)
+	PostMatch(f PostMatchFunc)
+	SetAuthorizer(a Authorizer)
+	CORS(c *CORS)
+	Canonicalize(c *Canonical)
+	Aliases(store AliasStore)
+	Batch(pattern string, max int) *Route
+	Dispatch(ctx context.Context, method, path string, body io.Reader) (*Response, error)
+	Listener(name string) http.Handler
+	ACMEChallenges(mgr CertManager)
+	Configure(name string, c RouteConfig) error
+	UseConfigStore(ctx context.Context, store ConfigStore) error
+	InvalidParamStatus(code int)
+	AutoOptions()
+	TrackStats()
+	TrackCoverage()
+	Coverage() []RouteCoverage
+	Stats() []RouteStats
 }
 
 // NewMux creates a new muxer and hooks it up with provided http.ServeMux.
 // Uses http.DefaultServeMux If httpMux param is nil.
-// First param, basePath, is the base for all routes added to this muxer. 
+// First param, basePath, is the base for all routes added to this muxer.
 // It can also be zero string, in which case "/" is used as the base path.
 // NewMux always prefixes and suffixes provided basePath with "/".
 func NewMux(basePath string, httpMux *http.ServeMux) (m Mux) {
@@ -58,20 +149,111 @@ func NewMux(basePath string, httpMux *http.ServeMux) (m Mux) {
 	if httpMux == nil {
 		httpMux = http.DefaultServeMux
 	}
-	m = &defaultMux{
+	dm := &defaultMux{
 		base:    basePath,
 		baseLen: len(basePath),
 		routes:  make([]*Route, 0),
+		httpMux: httpMux,
+		// See InvalidParamStatus()
+		invalidParam: http.StatusBadRequest,
 	}
-	httpMux.Handle(basePath, m)
-	return
+	dm.root = &Group{mux: dm}
+	dm.groups = []*Group{dm.root}
+	httpMux.Handle(basePath, dm)
+	return dm
 }
 
 // Default implementation of Mux interface
 type defaultMux struct {
-	base    string
-	baseLen int
-	routes  []*Route
+	base       string
+	baseLen    int
+	httpMux    *http.ServeMux
+	routes     []*Route
+	strictness Strictness
+	// Set with NameCollisions()
+	nameCollisions NameCollision
+	warn           WarningFunc
+	root           *Group
+	groups         []*Group
+	chain          []Mux
+	registrars     []Registrar
+	// Set while a Registrar is adding its routes.
+	registering Registrar
+	// Generated matcher, see UseMatcher().
+	matcher MatcherFunc
+	pools   map[string]*Pool
+	// Set with PreRoute()
+	preRoute []func(*http.Request) *http.Request
+	// Set with PostMatch()
+	postMatch []PostMatchFunc
+	// Incremented when handlers of routes must be built anew, see
+	// handlersChanged().
+	handlersGen atomic.Uint64
+	// Set with SetAuthorizer()
+	authorizer Authorizer
+	// Set with CORS()
+	cors *CORS
+	// Set with Canonicalize()
+	canonical *Canonical
+	// Set with Aliases()
+	aliases AliasStore
+	// Set with ACMEChallenges()
+	acme CertManager
+	// Set with TrackStats()
+	trackStats bool
+	// Set with TrackCoverage()
+	trackCoverage bool
+	// Interned pattern segments, see makeParts().
+	segments map[string]string
+	// Compiled param constraints by expression, see makeParts().
+	constraints map[string]*regexp.Regexp
+	// Serializes adding routes. Requests are never blocked by it: they
+	// read the route table from an immutable snapshot, see table.
+	mu sync.Mutex
+	// Routes published for matching, a prefix of routes.
+	table atomic.Pointer[[]*Route]
+	// Set with MatchCache()
+	cache *matchCache
+	// Set with PathLimits()
+	maxPathLen, maxSegments int
+	// Set with HardenPaths()
+	hardened bool
+	// Set with OnReject()
+	onReject RejectFunc
+	// Set with GuardHeaders()
+	guardHeaders bool
+	// Set once a group has a case policy other than CaseStrict.
+	foldCase bool
+	// Set with RedirectTrailingSlash()
+	slashRedirect bool
+	// Set with TrustProxies()
+	proxies []netip.Prefix
+	// Set with Middleware()
+	namedMiddleware map[string]Middleware
+	// Set with IsolatePanics()
+	isolation *panicIsolation
+	// Set with AddValidator()
+	validators []ValidatorFunc
+	// Set with Environment()
+	env string
+	// Set with RecordExamples()
+	examples *exampleRecorder
+	// Set with Redact()
+	redaction *Redaction
+	// Set with Transform()
+	transforms []transform
+	// Other base paths, set with AddBasePath()
+	bases []string
+	// Set with ExternalPrefix()
+	prefix string
+	// Subscribers, see Subscribe()
+	events eventBus
+	// Set with UseConfigStore()
+	configStore ConfigStore
+	// Set with InvalidParamStatus()
+	invalidParam int
+	// Set with AutoOptions()
+	autoOptions bool
 }
 
 // Returns base path of this mux.
@@ -81,14 +263,52 @@ func (dm *defaultMux) BasePath() string {
 
 // Returns the slice of all routes added to this mux.
 func (dm *defaultMux) Routes() []*Route {
-	return dm.routes
+	return dm.snapshot()
+}
+
+// Returns the current route table without locking. Routes added later
+// don't show up in it, so it's safe to use while routes are being added.
+func (dm *defaultMux) snapshot() []*Route {
+	if t := dm.table.Load(); t != nil {
+		return *t
+	}
+	return nil
+}
+
+// Publishes routes added so far to requests. The snapshot shares the array
+// of routes: later appends only write past its length.
+func (dm *defaultMux) publish() {
+	t := dm.routes[:len(dm.routes):len(dm.routes)]
+	dm.table.Store(&t)
+	dm.routesChanged()
 }
 
 // Add a new route to the mux.
 func (dm *defaultMux) Add(m string, p string, h HandlerFunc) *Route {
+	return dm.root.Add(m, p, h)
+}
+
+// Creates a new group of routes sharing provided path prefix.
+func (dm *defaultMux) Group(prefix string) *Group {
+	return dm.root.Group(prefix)
+}
+
+// Adds a new route to the mux as a member of group g.
+func (dm *defaultMux) add(g *Group, m string, p string, h HandlerFunc) *Route {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
 	if len(p) > 0 && p[0] == '/' {
 		p = p[1:]
 	}
+	if p == "" {
+		p = strings.TrimSuffix(g.prefix, "/")
+	} else {
+		p = g.prefix + p
+	}
+	if dm.matcher != nil {
+		panic(fmt.Sprintf("Can't add route '%s %s' to a frozen route table", m, p))
+	}
+	dm.checkPattern(m, p)
 	for _, r := range dm.routes {
 		if r.Method == m && r.Pattern == p {
 			panic(fmt.Sprintf("Route '%s %s' already exists", m, p))
@@ -99,18 +319,38 @@ func (dm *defaultMux) Add(m string, p string, h HandlerFunc) *Route {
 		Pattern: p,
 		Handler: h,
 		mux:     dm,
-		parts:   makeParts(p),
+		group:   g,
+		parts:   dm.makeParts(p),
+		live:    new(routeLive),
 	}
+	route.Registrar = dm.registering
 	route.partsLen = len(route.parts)
+	for _, rp := range route.parts {
+		if rp.isVar {
+			route.vars++
+		}
+	}
+	if dm.trackStats {
+		route.stats = &routeStats{}
+	}
+	if dm.trackCoverage {
+		route.hits = new(atomic.Uint64)
+	}
 	dm.routes = append(dm.routes, route)
+	dm.publish()
 	return route
 }
 
 // Generates a path from previously added route pattern extending it with
 // provided params
 func (dm *defaultMux) BuildPath(name string, params ...interface{}) string {
+	return dm.buildPath(dm.prefix, name, params)
+}
+
+// Same as BuildPath() with the path prefixed with prefix.
+func (dm *defaultMux) buildPath(prefix, name string, params []interface{}) string {
 	var route *Route
-	for _, r := range dm.routes {
+	for _, r := range dm.snapshot() {
 		if r.Name == name {
 			route = r
 			break
@@ -121,7 +361,7 @@ func (dm *defaultMux) BuildPath(name string, params ...interface{}) string {
 	}
 
 	parts := make([]string, 1, route.partsLen+1)
-	parts[0] = dm.base
+	parts[0] = prefix + dm.base
 	pi := 0
 	for _, rp := range route.parts {
 		if rp.isVar {
@@ -131,47 +371,317 @@ func (dm *defaultMux) BuildPath(name string, params ...interface{}) string {
 			parts = append(parts, rp.name)
 		}
 	}
+	if route.assets != nil {
+		last := len(parts) - 1
+		parts[last] = route.assets.hashedName(parts[last])
+	}
 	return path.Join(parts...)
 }
 
 // Matches request URL and hand it over to the route's handler providing it
 // with parameters extracted from the URL path (if any).
+// If neither this mux nor the chained ones have a matching route, the request
+// is handed over to NotFound or MethodNotAllowed handler of the most specific
+// group for the URL path. Requests not in canonical form, see Canonicalize(),
+// are redirected before matching, and aliases, see Aliases(), are looked up
+// after.
 func (m *defaultMux) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	h, v := m.match(req.Method, req.URL.Path[m.baseLen:])
+	if (m.maxPathLen > 0 || m.maxSegments > 0) && m.rejectPath(w, req) {
+		return
+	}
+	if m.hardened && m.rejectAmbiguous(w, req) {
+		return
+	}
+	if m.guardHeaders && m.rejectHeaders(w, req) {
+		return
+	}
+	for _, f := range m.preRoute {
+		if r := f(req); r != nil {
+			req = r
+		}
+	}
+	if m.canonical != nil && m.canonical.redirect(w, req, m) {
+		return
+	}
+	if m.tryServe(w, req) {
+		return
+	}
+	if m.foldCase && m.serveFolded(w, req) {
+		return
+	}
+	if m.slashRedirect && m.redirectSlash(w, req) {
+		return
+	}
+	p, _ := m.relPath(req)
+	if m.serveAlias(w, req, p) {
+		return
+	}
+	m.serveNoMatch(w, req, p)
+}
+
+// Adds a hook called with every request before matching. The hook can
+// rewrite paths, hosts or headers, e.g. strip a locale prefix:
+//
+//	m.PreRoute(func(r *http.Request) *http.Request {
+//		if rest, ok := strings.CutPrefix(r.URL.Path, "/de/"); ok {
+//			r = r.Clone(context.WithValue(r.Context(), localeKey, "de"))
+//			r.URL.Path = "/" + rest
+//		}
+//		return r
+//	})
+//
+// Hooks are called in the order they were added, each with the request
+// returned by the previous one. Returning nil keeps the request as is.
+// Hooks of chained muxers aren't called, only those of the mux which
+// received the request.
+func (dm *defaultMux) PreRoute(f func(*http.Request) *http.Request) {
+	dm.preRoute = append(dm.preRoute, f)
+}
+
+// Serves the request with a matching route of this mux, if any.
+// Reports whether the request has been served.
+func (dm *defaultMux) serveRoute(w http.ResponseWriter, req *http.Request) bool {
+	p, ok := dm.relPath(req)
+	if !ok {
+		return false
+	}
+	if isPreflight(req) && dm.servePreflight(w, req, p) {
+		return true
+	}
+	r, v := dm.lookup(req, req.Method, p)
+	if r == nil && req.Method == "HEAD" {
+		if r, v = dm.lookup(req, "GET", p); r != nil && r.noHead {
+			r = nil
+		} else if r != nil {
+			w = headWriter{w}
+		}
+	}
+	if r == nil || !r.live.enter() {
+		return false
+	}
+	defer r.live.leave()
+	if r.hits != nil {
+		r.hits.Add(1)
+	}
+	ctx, cancel := newRequestContext(req.Context(), r, v)
+	defer cancel()
+	req = req.WithContext(ctx)
+	r.writeHeaders(w.Header(), v)
+	r.writeCORS(w.Header(), req)
+	if r.deadlines != nil {
+		r.deadlines.apply(w)
+	}
+	h := r.builtHandler()
+	if fs := dm.transformsOf(r); fs != nil {
+		h = transformResponse(fs, h)
+	}
+	if r.maxResponse > 0 {
+		h = limitResponse(r, h)
+	}
+	if dm.examples != nil {
+		h = dm.examples.wrap(r, h)
+	}
+	if dm.isolation != nil {
+		h = dm.isolation.wrap(r, h)
+	}
+	if rc := r.live.config.Load(); rc != nil {
+		h = rc.wrap(h)
+	}
+	if r.capture != nil {
+		h = r.capture.wrap(r, h)
+	}
+	if r.sampler != nil && r.sampler.sample() {
+		sampled := h
+		h = func(w http.ResponseWriter, req *http.Request, v url.Values) {
+			r.sampler.serve(r, sampled, w, req, v)
+		}
+	}
+	if r.stats != nil {
+		sw := &statsWriter{ResponseWriter: w}
+		start := time.Now()
+		h(sw, req, v)
+		if sw.code == 0 {
+			sw.code = http.StatusOK
+		}
+		r.stats.record(sw.code, time.Since(start))
+		return true
+	}
+	h(w, req, v)
+	return true
+}
+
+// Returns the route of this mux serving method at URL path p relative to
+// the base path, or nil.
+func (dm *defaultMux) lookup(req *http.Request, method, p string) (*Route, url.Values) {
+	listener := listenerOf(req)
+	var r *Route
+	var v url.Values
+	if dm.matcher != nil {
+		routes := dm.snapshot()
+		if i, vals := dm.matcher(method, p); i >= 0 && i < len(routes) &&
+			!routes[i].Disabled() && routes[i].listener() == listener {
+			r, v = routes[i], vals
+		}
+	}
+	if r == nil {
+		r, v = dm.match(method, p, listener)
+	}
+	if r != nil && r.schemes != nil {
+		if scheme := dm.scheme(req); !r.allowsScheme(scheme) {
+			r, v = dm.scan(method, p, listener, scheme)
+		}
+	}
+	return r, v
+}
+
+// Returns URL path of the request relative to this mux base path.
+// Reports false if the path is outside of the base path.
+func (dm *defaultMux) relPath(req *http.Request) (string, bool) {
+	if dm.bases != nil {
+		_, rel, ok := dm.splitBase(req.URL.Path, false)
+		return rel, ok
+	}
+	if !strings.HasPrefix(req.URL.Path, dm.base) {
+		return "", false
+	}
+	return req.URL.Path[dm.baseLen:], true
+}
+
+// Responds to a request which didn't match any route.
+func (dm *defaultMux) serveNoMatch(w http.ResponseWriter, req *http.Request, p string) {
+	if dm.serveInvalidParam(w, req, p) {
+		return
+	}
+	var h HandlerFunc
+	// Routes with the method of req could have been skipped, e.g. for
+	// their scheme, which doesn't make the method not allowed.
+	if others := dm.pathRoutes(p, listenerOf(req)); len(others) > 0 && !hasMethod(others, req.Method) {
+		w.Header().Set("Allow", strings.Join(dm.allowedMethods(others), ", "))
+		if req.Method == "OPTIONS" && dm.autoOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		h = others[0].group.methodNotAllowedHandler()
+		if h == nil {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+	}
+	if h == nil {
+		h = dm.groupFor(p, listenerOf(req)).notFoundHandler()
+	}
 	if h == nil {
 		http.NotFound(w, req)
 		return
 	}
-	h(w, req, v)
+	h(w, req, nil)
 }
 
 // Looks up a route by matching this mux'es routes againts
-// HTTP method (e.g. "GET", "PUT") and URL path. 
+// HTTP method (e.g. "GET", "PUT") and URL path.
 // Return Handler of the matched route and parameteres extracted from the URL
-// (if any).
-func (dm *defaultMux) match(method, path string) (HandlerFunc, url.Values) {
-	parts := strings.Split(path, "/")
-	partsLen := len(parts)
-ROUTES_LOOP:
-	for _, r := range dm.routes {
-		if r.Method != method || r.partsLen != partsLen {
+// (if any). Only routes served on listener are matched, see Listener().
+func (dm *defaultMux) match(method, path, listener string) (*Route, url.Values) {
+	c := dm.cache
+	if c == nil {
+		return dm.scan(method, path, listener, "")
+	}
+	key := matchKey(method, path, listener)
+	if r, v := c.get(key); r != nil {
+		return r, v
+	}
+	gen := c.gen.Load()
+	r, v := dm.scan(method, path, listener, "")
+	if r != nil {
+		c.put(gen, key, r, v)
+	}
+	return r, v
+}
+
+// Matches a route looking through all routes, see match(). Routes
+// not allowing scheme are skipped unless it's zero string.
+func (dm *defaultMux) scan(method, path, listener, scheme string) (*Route, url.Values) {
+	n := countSegments(path)
+	for _, r := range dm.snapshot() {
+		if r.Method != method || !r.fits(n) || r.Disabled() ||
+			r.listener() != listener || !r.matchPath(path) ||
+			scheme != "" && !r.allowsScheme(scheme) {
 			continue
 		}
-		for i, rp := range r.parts {
-			if !rp.isVar && rp.name != parts[i] {
-				continue ROUTES_LOOP
-			}
+		return r, r.params(path)
+	}
+	return nil, nil
+}
+
+// Returns params of this route extracted from a matching path.
+// All values share one backing array and names are the interned ones
+// of the pattern, so params cost the map and a single slice.
+func (r *Route) params(path string) url.Values {
+	vals := make(url.Values, r.vars)
+	if r.vars == 0 {
+		return vals
+	}
+	backing := make([]string, r.vars)
+	j := 0
+	segs := NewSegments(path)
+	for _, rp := range r.parts {
+		var seg string
+		if rp.rest {
+			seg = segs.rest()
+		} else {
+			seg, _ = segs.Next()
 		}
-		// Found a match
-		vals := make(url.Values, partsLen)
-		for i, rp := range r.parts {
-			if rp.isVar {
-				vals.Add(rp.name, parts[i])
-			}
+		if !rp.isVar {
+			continue
+		}
+		if prev, ok := vals[rp.name]; ok {
+			// Repeated name, allowed with Permissive strictness.
+			vals[rp.name] = append(prev, seg)
+			continue
 		}
-		return r.Handler, vals
+		backing[j] = seg
+		// Capped, so appending to one value doesn't overwrite the next.
+		vals[rp.name] = backing[j : j+1 : j+1]
+		j++
 	}
-	return nil, nil
+	return vals
+}
+
+// Reports whether one of routes serves method.
+func hasMethod(routes []*Route, method string) bool {
+	for _, r := range routes {
+		if r.Method == method || method == "HEAD" && r.servesHead() {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns sorted methods of routes, for an Allow header.
+func allowedMethods(routes []*Route) []string {
+	var methods []string
+	for _, r := range routes {
+		if !containsString(methods, r.Method) {
+			methods = append(methods, r.Method)
+		}
+		if r.servesHead() && !containsString(methods, "HEAD") {
+			methods = append(methods, "HEAD")
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// Returns all routes served on listener matching URL path regardless
+// of their HTTP method.
+func (dm *defaultMux) pathRoutes(path, listener string) (routes []*Route) {
+	n := countSegments(path)
+	for _, r := range dm.snapshot() {
+		if r.fits(n) && !r.Disabled() && r.listener() == listener && r.matchPath(path) {
+			routes = append(routes, r)
+		}
+	}
+	return
 }
 
 // Function type that knows how to handle HTTP request, supplied with params
@@ -182,42 +692,238 @@ type HandlerFunc func(w http.ResponseWriter, r *http.Request, v url.Values)
 type Route struct {
 	Method  string
 	Pattern string
+	// Wrapped in middleware once the route serves its first request,
+	// changes made later are only picked up along with middleware ones.
 	Handler HandlerFunc
 	Name    string
+	// Free-form labels, e.g. "admin" or "batch". See Tag().
+	Tags []string
+	// Used by load shedding, see Prioritize().
+	Priority Priority
+	// Registrar which added this route, if any. See Mux.Register().
+	Registrar Registrar
 	// Internal
 	mux      Mux
-	parts    []*pathPart
+	group    *Group
+	parts    []pathPart
 	partsLen int
+	// Number of params in parts.
+	vars int
+	// Set with Deprecated()
+	deprecation *deprecation
+	// Set with SetHeader()
+	headers http.Header
+	// Set for routes added with Assets()
+	assets *assetFiles
+	// Set with Use()
+	middleware []namedMiddleware
+	// Set with Skip()
+	skip []string
+	// Set with RunOn()
+	pool *Pool
+	// Set with Policy()
+	policy string
+	// Set with RequireScope()
+	scopes []string
+	// Set with CORS()
+	cors *CORS
+	// Set with AllowHeaders()
+	corsHeaders []string
+	// Set with Deadlines()
+	deadlines *deadlines
+	// Minimum TLS version, set with RequireTLS()
+	tlsVersion uint16
+	// Set with LastModified()
+	lastModified LastModifiedFunc
+	// Set with Scheme()
+	schemes []string
+	// State changed while serving requests.
+	live *routeLive
+	// Set with Mux.TrackStats()
+	stats *routeStats
+	// Set with Sample()
+	sampler *sampler
+	// Set with Request() and Response()
+	request   reflect.Type
+	responses map[int]reflect.Type
+	// Set with Describe()
+	docs []RouteDoc
+	// Set with SLO()
+	slo *SLO
+	// Set with CaptureBodies()
+	capture *bodyCapture
+	// Set with MaxResponseBytes()
+	maxResponse int64
+	// Query param of the callback, set with JSONP()
+	jsonp string
+	// Set with Label()
+	labels map[string]string
+	// Set with Owner()
+	owner string
+	// Set with NoHead()
+	noHead bool
+	// Set with CasePolicy()
+	casePolicy *CasePolicy
+	// Set with Mux.TrackCoverage()
+	hits *atomic.Uint64
+}
+
+// State of a route changed while serving requests.
+type routeLive struct {
+	disabled atomic.Bool
+	config   atomic.Pointer[routeConfig]
+	panics   routePanics
+	inflight atomic.Int64
+	drain    routeDrain
+	examples routeExamples
+	// Built with handler(), see builtHandler().
+	handler atomic.Pointer[builtHandler]
+}
+
+// Reports whether URL path matches this route's pattern.
+func (r *Route) matchPath(path string) bool {
+	segs := NewSegments(path)
+	for _, rp := range r.parts {
+		if rp.rest {
+			return true
+		}
+		seg, ok := segs.Next()
+		if !ok || !rp.matches(seg) {
+			return false
+		}
+	}
+	_, more := segs.Next()
+	return !more
+}
+
+// Reports whether paths of n segments may match this route: as many as
+// its pattern has, or more if it ends with a catch-all param.
+func (r *Route) fits(n int) bool {
+	return r.partsLen == n || n > r.partsLen && r.catchAll()
+}
+
+// Reports whether pattern of this route ends with a catch-all param.
+func (r *Route) catchAll() bool {
+	return r.parts[r.partsLen-1].rest
 }
 
 // Adds a name to this route so that a URL path can be built later on using
-// provided name. See BuildPath().
+// provided name. See BuildPath(). The name is prefixed with the namespace
+// of the route's group, if any, see Group.Namespace(). Another route with
+// the same name is handled according to Mux.NameCollisions().
 func (r *Route) As(name string) *Route {
+	name = r.group.qualify(name)
 	for _, route := range r.mux.Routes() {
-		if route.Name == name {
-			panic(fmt.Sprintf("Route with name '%s' already exists", name))
+		if route != r && route.Name == name {
+			if r.group.mux.nameCollisions != OverrideNames {
+				panic(fmt.Sprintf("Route with name '%s' already exists", name))
+			}
+			route.Name = ""
 		}
 	}
 	r.Name = name
 	return r
 }
 
+// Adds tags to this route. Tags are used to filter routes in reports
+// and by features applied to a subset of routes.
+func (r *Route) Tag(tags ...string) *Route {
+	for _, t := range tags {
+		if !r.HasTag(t) {
+			r.Tags = append(r.Tags, t)
+		}
+	}
+	return r
+}
+
+// Reports whether this route has been tagged with tag.
+func (r *Route) HasTag(tag string) bool {
+	for _, t := range r.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Segment of a route pattern. Parts are stored by value in one slice per
+// route, so matching walks contiguous memory.
 type pathPart struct {
 	isVar bool
-	name  string
+	// Catch-all param, e.g. "{rest...}", matching the remaining segments.
+	rest bool
+	name string
+	// Constraint of a param, e.g. "[0-9]+" of "{id:[0-9]+}".
+	re *regexp.Regexp
+	// Keyword of a built-in constraint, e.g. "int" of "{id:int}".
+	typ string
+}
+
+// Reports whether path segment seg matches this part.
+func (rp *pathPart) matches(seg string) bool {
+	if !rp.isVar {
+		return rp.name == seg
+	}
+	return rp.re == nil || rp.re.MatchString(seg)
 }
 
-func makeParts(pattern string) []*pathPart {
+// Splits pattern into parts. Segment names are interned, so the same
+// segment of many routes, e.g. "api" or "id", is stored once, and param
+// constraints are compiled once. Panics if a constraint isn't a valid
+// regular expression.
+func (dm *defaultMux) makeParts(pattern string) []pathPart {
 	split := strings.Split(pattern, "/")
-	parts := make([]*pathPart, 0, len(split))
-	for _, sp := range split {
-		part := &pathPart{isVar: sp[0] == '{' && sp[len(sp)-1] == '}'}
+	parts := make([]pathPart, len(split))
+	for i, sp := range split {
+		part := &parts[i]
+		part.isVar = len(sp) > 1 && sp[0] == '{' && sp[len(sp)-1] == '}'
 		if part.isVar {
-			part.name = sp[1 : len(sp)-1]
-		} else {
-			part.name = sp
+			sp = sp[1 : len(sp)-1]
+		}
+		if part.isVar && i == len(split)-1 && len(sp) > 3 && strings.HasSuffix(sp, "...") {
+			part.rest = true
+			sp = sp[:len(sp)-3]
+		}
+		if name, expr, ok := strings.Cut(sp, ":"); part.isVar && ok {
+			sp = name
+			if typed, ok := paramTypes[expr]; ok {
+				part.typ = dm.intern(expr)
+				expr = typed
+			}
+			part.re = dm.constraint(pattern, expr)
 		}
-		parts = append(parts, part)
+		part.name = dm.intern(sp)
 	}
 	return parts
 }
+
+// Returns compiled param constraint expr of pattern, matching whole
+// segments.
+func (dm *defaultMux) constraint(pattern, expr string) *regexp.Regexp {
+	if re, ok := dm.constraints[expr]; ok {
+		return re
+	}
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		panic(fmt.Sprintf("Route pattern '%s': invalid constraint '%s': %v", pattern, expr, err))
+	}
+	if dm.constraints == nil {
+		dm.constraints = make(map[string]*regexp.Regexp)
+	}
+	dm.constraints[expr] = re
+	return re
+}
+
+// Returns the interned copy of segment s.
+func (dm *defaultMux) intern(s string) string {
+	if is, ok := dm.segments[s]; ok {
+		return is
+	}
+	if dm.segments == nil {
+		dm.segments = make(map[string]string)
+	}
+	// Doesn't keep the whole pattern s is a substring of.
+	s = strings.Clone(s)
+	dm.segments[s] = s
+	return s
+}
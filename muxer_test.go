@@ -10,6 +10,8 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
+	"unsafe"
 )
 
 var dummy = func(w http.ResponseWriter, r *http.Request, v url.Values) {
@@ -157,7 +159,7 @@ func BenchmarkRouteMatch(b *testing.B) {
 	m := buildMuxForBench(nil).(*defaultMux)
 	b.StartTimer()
 	for i := 0; i < b.N; i++ {
-		m.match("PUT", "/api/products/321/do")
+		m.match("PUT", "/api/products/321/do", "")
 	}
 }
 
@@ -205,3 +207,152 @@ func BenchmarkRouteBuild(b *testing.B) {
 		m.BuildPath("whatever", "somedomain", true, 23.45)
 	}
 }
+
+func TestPartsInterned(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	a := m.Add("GET", "users/{id}", dummy)
+	b := m.Add("GET", "orders/{id}/users", dummy)
+	if unsafe.StringData(a.parts[1].name) != unsafe.StringData(b.parts[1].name) ||
+		unsafe.StringData(a.parts[0].name) != unsafe.StringData(b.parts[2].name) {
+		t.Error("segments aren't interned")
+	}
+	if !a.parts[1].isVar || a.parts[1].name != "id" || b.parts[2].isVar {
+		t.Errorf("parts = %+v, %+v", a.parts, b.parts)
+	}
+}
+
+func BenchmarkAddLargeTable(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		m := NewMux("/", http.NewServeMux())
+		for j := 0; j < 10000; j++ {
+			m.Add("GET", fmt.Sprintf("v1/tenants/{tenant}/resources%d/{id}", j), dummy)
+		}
+	}
+}
+
+func TestServeWhileAdding(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	r := m.Add("GET", "a", dummy).As("a")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			m.Add("GET", fmt.Sprintf("r%d/{id}", i), dummy)
+			r.Disable()
+			r.Enable()
+			m.Configure("a", RouteConfig{Cache: time.Second})
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			if w := serve(m, "GET", "/r199/1"); w.Code != 200 {
+				t.Errorf("code = %d, want 200", w.Code)
+			}
+			return
+		default:
+			serve(m, "GET", "/a")
+			serve(m, "GET", "/r0/1")
+			m.BuildPath("a")
+		}
+	}
+}
+
+func BenchmarkRouteMatchParams(b *testing.B) {
+	m := buildMuxForBench(nil).(*defaultMux)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.match("POST", "orders/cancel/42", "")
+	}
+}
+
+func TestRouteParams(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.SetStrictness(Permissive)
+	r := m.Add("GET", "{a}/x/{b}/{a}", dummy)
+	v := r.params("1/x/2/3")
+	assertEqual(t, v.Encode(), "a=1&a=3&b=2")
+	v["a"] = append(v["a"], "4")
+	v["b"] = append(v["b"], "5")
+	assertEqual(t, v.Encode(), "a=1&a=3&a=4&b=2&b=5")
+	if v := m.Add("GET", "static", dummy).params("static"); len(v) != 0 {
+		t.Errorf("params of a static route = %v", v)
+	}
+}
+
+func TestCatchAll(t *testing.T) {
+	m := NewMux("/api", http.NewServeMux())
+	m.Add("GET", "files/readme", dummy)
+	m.Add("GET", "files/{path...}", dummy).As("file")
+	m.Add("GET", "files/{dir}/index", dummy)
+	assertEqual(t, serve(m, "GET", "/api/files/a/b/c.png").Body.String(), "params:path=a%2Fb%2Fc.png")
+	assertEqual(t, serve(m, "GET", "/api/files/a").Body.String(), "params:path=a")
+	assertEqual(t, serve(m, "GET", "/api/files/").Body.String(), "params:path=")
+	assertEqual(t, serve(m, "GET", "/api/files/readme").Body.String(), "params:")
+	// Routes are matched in order, so the catch-all wins.
+	assertEqual(t, serve(m, "GET", "/api/files/x/index").Body.String(), "params:path=x%2Findex")
+	if code := serve(m, "GET", "/api/files").Code; code != http.StatusNotFound {
+		t.Errorf("GET /api/files = %d, want 404", code)
+	}
+	assertEqual(t, m.BuildPath("file", "a/b.png"), "/api/files/a/b.png")
+
+	// Only the last segment can be a catch-all.
+	m.SetStrictness(Strict)
+	defer func() {
+		if recover() == nil {
+			t.Error("catch-all in the middle of a pattern accepted")
+		}
+	}()
+	m.Add("GET", "{path...}/edit", dummy)
+}
+
+func TestParamConstraints(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "users/{id:[0-9]+}", dummy).As("user")
+	m.Add("GET", "users/{name:[a-z]{2,8}}", tagMiddleware("name")(dummy))
+	m.Add("GET", "posts/{id:[0-9]+}", dummy)
+	assertEqual(t, serve(m, "GET", "/users/42").Body.String(), "params:id=42")
+	assertEqual(t, serve(m, "GET", "/users/bob").Body.String(), "name(params:name=bob)")
+	if code := serve(m, "GET", "/users/Bob42").Code; code != http.StatusNotFound {
+		t.Errorf("GET /users/Bob42 = %d, want 404", code)
+	}
+	// Constraints match whole segments.
+	if code := serve(m, "GET", "/posts/1a").Code; code != http.StatusNotFound {
+		t.Errorf("GET /posts/1a = %d, want 404", code)
+	}
+	assertEqual(t, m.BuildPath("user", 7), "/users/7")
+	// Compiled once per expression.
+	dm := m.(*defaultMux)
+	if len(dm.constraints) != 2 || m.Routes()[0].parts[1].re != m.Routes()[2].parts[1].re {
+		t.Errorf("constraints = %v", dm.constraints)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("invalid constraint accepted")
+		}
+	}()
+	m.SetStrictness(Permissive)
+	m.Add("GET", "bad/{id:[0-9}", dummy)
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("PUT", "users/{id}", dummy)
+	m.Add("GET", "users/{id}", dummy)
+	m.Add("DELETE", "users/{id:[0-9]+}", dummy)
+	m.Add("PATCH", "users/{id}", dummy).Disable()
+	m.Add("GET", "posts", dummy)
+
+	w := serve(m, "POST", "/users/1")
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("POST /users/1 = %d, want 405", w.Code)
+	}
+	assertEqual(t, w.Header().Get("Allow"), "DELETE, GET, HEAD, PUT")
+	assertEqual(t, serve(m, "POST", "/users/me").Header().Get("Allow"), "GET, HEAD, PUT")
+	if w := serve(m, "POST", "/comments"); w.Code != http.StatusNotFound || w.Header().Get("Allow") != "" {
+		t.Errorf("POST /comments = %d, allowing %q, want 404", w.Code, w.Header().Get("Allow"))
+	}
+}
@@ -0,0 +1,291 @@
+/*
+Package muxerbench benchmarks a muxer against an app's actual route table
+with a synthetic corpus of requests, reporting costs per route class, e.g.
+to check a generated matcher before adopting it:
+
+	m := app.Routes()
+	corpus := muxerbench.Corpus(m, 10)
+	before := muxerbench.Run(m, corpus, muxerbench.Options{})
+	after := muxerbench.Run(m, corpus, muxerbench.Options{
+		Matcher: app.MatchRoute, Fingerprint: app.RoutesFingerprint,
+	})
+	muxerbench.WriteReport(os.Stdout, before)
+	muxerbench.WriteReport(os.Stdout, after)
+
+Or from a regular benchmark, so results can be compared with benchstat:
+
+	func BenchmarkRoutes(b *testing.B) {
+		muxerbench.Benchmark(b, app.Routes(), muxerbench.Options{})
+	}
+
+Benchmarks run on a clone of the mux with handlers replaced by ones doing
+nothing, so they measure matching, hooks and middleware only.
+*/
+package muxerbench
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"text/tabwriter"
+
+	muxer "code.google.com/p/go-muxer"
+)
+
+// Class of a route, requests are reported by class.
+type Class string
+
+const (
+	// Routes without params, e.g. "products".
+	Static Class = "static"
+	// Routes with params, e.g. "users/{id}".
+	Param Class = "param"
+	// Requests matching no route.
+	Miss Class = "miss"
+)
+
+// Classes in the order they are reported.
+var classes = []Class{Static, Param, Miss}
+
+// A request of a corpus.
+type Request struct {
+	Method, Path string
+	Class        Class
+	// The route the request was generated for, nil for Miss. Another route
+	// added earlier may match it first.
+	Route *muxer.Route
+}
+
+// Options of a benchmark run.
+type Options struct {
+	// Generated matcher and fingerprint to use, see Mux.UseMatcher().
+	Matcher     muxer.MatcherFunc
+	Fingerprint string
+}
+
+// Result of benchmarking a class of requests.
+type Result struct {
+	Class Class
+	// Number of distinct requests of the class in the corpus.
+	Requests    int
+	NsPerOp     int64
+	AllocsPerOp int64
+	BytesPerOp  int64
+}
+
+// Returns a corpus with n requests for each enabled route of m, with
+// random param values, and n requests matching no route. The corpus is
+// the same for the same route table and n. Values satisfy built-in param
+// constraints, e.g. "{id:int}", and are tried against other ones, routes
+// with constraints no value satisfies are skipped.
+func Corpus(m muxer.Mux, n int) []Request {
+	rnd := rand.New(rand.NewSource(1))
+	base := m.BasePath()
+	var reqs []Request
+	for _, r := range m.Routes() {
+		if r.Disabled() {
+			continue
+		}
+		class := Static
+		if strings.Contains(r.Pattern, "{") {
+			class = Param
+		}
+		for i := 0; i < n; i++ {
+			path, ok := fillParams(r.Pattern, rnd)
+			if !ok {
+				break
+			}
+			reqs = append(reqs, Request{
+				Method: r.Method,
+				Path:   base + path,
+				Class:  class,
+				Route:  r,
+			})
+		}
+	}
+	for i := 0; i < n; i++ {
+		// Deeper than any sane route table.
+		segs := make([]string, 12+rnd.Intn(4))
+		for j := range segs {
+			segs[j] = randomSegment(rnd)
+		}
+		reqs = append(reqs, Request{Method: "GET", Path: base + strings.Join(segs, "/"), Class: Miss})
+	}
+	return reqs
+}
+
+// Returns pattern with params replaced by random values satisfying their
+// constraints, or false if no value satisfies one.
+func fillParams(pattern string, rnd *rand.Rand) (string, bool) {
+	parts := strings.Split(pattern, "/")
+	for i, p := range parts {
+		if len(p) < 2 || p[0] != '{' || p[len(p)-1] != '}' {
+			continue
+		}
+		_, expr, _ := strings.Cut(strings.TrimSuffix(p[1:len(p)-1], "..."), ":")
+		v, ok := paramValue(expr, rnd)
+		if !ok {
+			return "", false
+		}
+		parts[i] = v
+	}
+	return strings.Join(parts, "/"), true
+}
+
+// Generators of values of built-in param constraints.
+var typedValues = map[string]func(*rand.Rand) string{
+	"int":   randomDigits,
+	"uint":  randomDigits,
+	"alpha": randomLetters,
+	"alnum": randomSegment,
+	"slug":  randomSegment,
+	"uuid":  randomUUID,
+}
+
+// Returns a random value satisfying param constraint expr, if one of the
+// values tried does.
+func paramValue(expr string, rnd *rand.Rand) (string, bool) {
+	if expr == "" {
+		return randomSegment(rnd), true
+	}
+	if gen, ok := typedValues[expr]; ok {
+		return gen(rnd), true
+	}
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return "", false
+	}
+	for _, gen := range []func(*rand.Rand) string{randomSegment, randomDigits, randomLetters, randomUUID} {
+		if v := gen(rnd); re.MatchString(v) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+func randomSegment(rnd *rand.Rand) string {
+	return randomString(rnd, "abcdefghijklmnopqrstuvwxyz0123456789", 4+rnd.Intn(8))
+}
+
+func randomDigits(rnd *rand.Rand) string {
+	return randomString(rnd, "0123456789", 1+rnd.Intn(8))
+}
+
+func randomLetters(rnd *rand.Rand) string {
+	return randomString(rnd, "abcdefghijklmnopqrstuvwxyz", 4+rnd.Intn(8))
+}
+
+func randomUUID(rnd *rand.Rand) string {
+	const hex = "0123456789abcdef"
+	return randomString(rnd, hex, 8) + "-" + randomString(rnd, hex, 4) + "-" +
+		randomString(rnd, hex, 4) + "-" + randomString(rnd, hex, 4) + "-" + randomString(rnd, hex, 12)
+}
+
+func randomString(rnd *rand.Rand, chars string, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = chars[rnd.Intn(len(chars))]
+	}
+	return string(b)
+}
+
+// Benchmarks serving requests of corpus by m, one class after another,
+// and returns results of classes present in corpus.
+func Run(m muxer.Mux, corpus []Request, opts Options) []Result {
+	h := prepare(m, opts)
+	var results []Result
+	for _, class := range classes {
+		reqs := requests(corpus, class)
+		if len(reqs) == 0 {
+			continue
+		}
+		br := testing.Benchmark(func(b *testing.B) { serve(b, h, reqs) })
+		results = append(results, Result{
+			Class:       class,
+			Requests:    len(reqs),
+			NsPerOp:     br.NsPerOp(),
+			AllocsPerOp: br.AllocsPerOp(),
+			BytesPerOp:  br.AllocedBytesPerOp(),
+		})
+	}
+	return results
+}
+
+// Runs a sub-benchmark for each route class of a corpus of m with
+// 10 requests per route.
+func Benchmark(b *testing.B, m muxer.Mux, opts Options) {
+	h := prepare(m, opts)
+	corpus := Corpus(m, 10)
+	for _, class := range classes {
+		if reqs := requests(corpus, class); len(reqs) > 0 {
+			b.Run(string(class), func(b *testing.B) { serve(b, h, reqs) })
+		}
+	}
+}
+
+// Writes results as a table to w.
+func WriteReport(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "CLASS\tREQUESTS\tNS/OP\tALLOCS/OP\tB/OP\t")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t\n",
+			r.Class, r.Requests, r.NsPerOp, r.AllocsPerOp, r.BytesPerOp)
+	}
+	return tw.Flush()
+}
+
+// Returns a clone of m with handlers doing nothing.
+func prepare(m muxer.Mux, opts Options) muxer.Mux {
+	c := m.Clone()
+	for _, r := range c.Routes() {
+		r.Handler = noop
+	}
+	if opts.Matcher != nil {
+		c.UseMatcher(opts.Matcher, opts.Fingerprint)
+	}
+	return c
+}
+
+func noop(w http.ResponseWriter, r *http.Request, v url.Values) {}
+
+// Returns requests of class in corpus.
+func requests(corpus []Request, class Class) []*http.Request {
+	var reqs []*http.Request
+	for _, cr := range corpus {
+		if cr.Class != class {
+			continue
+		}
+		req, err := http.NewRequest(cr.Method, cr.Path, nil)
+		if err != nil {
+			panic(fmt.Sprintf("muxerbench: bad request %s %s: %v", cr.Method, cr.Path, err))
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs
+}
+
+func serve(b *testing.B, h http.Handler, reqs []*http.Request) {
+	w := &discardWriter{h: make(http.Header)}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		clear(w.h)
+		h.ServeHTTP(w, reqs[i%len(reqs)])
+	}
+}
+
+// Reusable ResponseWriter dropping the response.
+type discardWriter struct {
+	h http.Header
+}
+
+func (w *discardWriter) Header() http.Header { return w.h }
+
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+
+func (w *discardWriter) WriteHeader(int) {}
@@ -0,0 +1,107 @@
+package muxerbench
+
+import (
+	"bytes"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	muxer "code.google.com/p/go-muxer"
+)
+
+func handler(w http.ResponseWriter, r *http.Request, v url.Values) {
+	panic("handler called")
+}
+
+func newMux() muxer.Mux {
+	m := muxer.NewMux("/api", http.NewServeMux())
+	m.Add("GET", "products", handler)
+	m.Add("GET", "users/{id}", handler)
+	m.Add("PUT", "products/{id}/do", handler)
+	m.Add("GET", "old", handler).Disable()
+	return m
+}
+
+func TestCorpus(t *testing.T) {
+	m := newMux()
+	corpus := Corpus(m, 3)
+	counts := make(map[Class]int)
+	for _, r := range corpus {
+		counts[r.Class]++
+		if !strings.HasPrefix(r.Path, "/api/") {
+			t.Errorf("path %q outside of base path", r.Path)
+		}
+		if r.Class == Param && strings.Contains(r.Path, "{") {
+			t.Errorf("path %q has unfilled params", r.Path)
+		}
+	}
+	if want := map[Class]int{Static: 3, Param: 6, Miss: 3}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("counts = %v, want %v", counts, want)
+	}
+	if !reflect.DeepEqual(Corpus(m, 3), corpus) {
+		t.Error("corpus isn't deterministic")
+	}
+}
+
+func TestCorpusConstraints(t *testing.T) {
+	m := muxer.NewMux("/", http.NewServeMux())
+	ok := func(w http.ResponseWriter, r *http.Request, v url.Values) {}
+	m.Add("GET", "orders/{id:int}", ok)
+	m.Add("GET", "codes/{code:[0-9]+}", ok)
+	m.Add("GET", "items/{id:uuid}/{rest...}", ok)
+	m.Add("GET", "tags/{tag:[a-z]{2,}}", ok)
+	m.Add("GET", "skus/{sku:[A-Z]{3}}", ok)
+	corpus := Corpus(m, 5)
+	for _, r := range corpus {
+		if r.Class != Param {
+			continue
+		}
+		if r.Route.Pattern == "skus/{sku:[A-Z]{3}}" {
+			t.Errorf("route with an unsatisfied constraint in corpus: %s", r.Path)
+		}
+		req, _ := http.NewRequest(r.Method, r.Path, nil)
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, req)
+		if w.Code != 200 {
+			t.Errorf("GET %s = %d, want 200", r.Path, w.Code)
+		}
+	}
+	if n := len(corpus); n != 25 {
+		t.Errorf("got %d requests, want 25", n)
+	}
+}
+
+func TestRun(t *testing.T) {
+	bt := flag.Lookup("test.benchtime")
+	old := bt.Value.String()
+	bt.Value.Set("10x")
+	defer bt.Value.Set(old)
+
+	m := newMux()
+	results := Run(m, Corpus(m, 2), Options{})
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	for i, class := range []Class{Static, Param, Miss} {
+		if r := results[i]; r.Class != class || r.NsPerOp <= 0 {
+			t.Errorf("results[%d] = %+v", i, r)
+		}
+	}
+	var buf bytes.Buffer
+	WriteReport(&buf, results)
+	if lines := strings.Count(buf.String(), "\n"); lines != 4 {
+		t.Errorf("report has %d lines, want 4:\n%s", lines, buf.String())
+	}
+	// Handlers of m itself are kept.
+	if m.Routes()[0].Handler == nil {
+		t.Error("handler of the original mux replaced")
+	}
+}
+
+func BenchmarkExample(b *testing.B) {
+	Benchmark(b, newMux(), Options{})
+}
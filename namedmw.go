@@ -0,0 +1,111 @@
+package muxer
+
+import "fmt"
+
+// Middleware with the name it was registered with, if any.
+type namedMiddleware struct {
+	name string
+	mw   Middleware
+}
+
+// Returns the registered name or the function name of this middleware.
+func (n namedMiddleware) displayName() string {
+	if n.name != "" {
+		return n.name
+	}
+	return funcName(n.mw)
+}
+
+func appendMiddleware(list []namedMiddleware, mw []Middleware) []namedMiddleware {
+	for _, m := range mw {
+		list = append(list, namedMiddleware{mw: m})
+	}
+	return list
+}
+
+// Reports whether this middleware is registered under one of names.
+func (n namedMiddleware) skipped(names []string) bool {
+	return n.name != "" && containsString(names, n.name)
+}
+
+// Wraps h in middleware of list, the first one being the outermost,
+// leaving out ones named in skip.
+func wrapMiddleware(list []namedMiddleware, h HandlerFunc, skip []string) HandlerFunc {
+	for i := len(list) - 1; i >= 0; i-- {
+		if !list[i].skipped(skip) {
+			h = list[i].mw(h)
+		}
+	}
+	return h
+}
+
+// Registers middleware mw under name, so that it can be referenced by name,
+// e.g. from route definitions loaded from a config file:
+//
+//	m.Middleware("auth", requireSession)
+//	m.Group("admin").UseNamed("auth")
+//
+// Panics if name is already taken.
+func (dm *defaultMux) Middleware(name string, mw Middleware) {
+	if _, ok := dm.namedMiddleware[name]; ok {
+		panic(fmt.Sprintf("Middleware '%s' is already registered", name))
+	}
+	if dm.namedMiddleware == nil {
+		dm.namedMiddleware = make(map[string]Middleware)
+	}
+	dm.namedMiddleware[name] = mw
+}
+
+// Returns middleware registered under names. Panics if one isn't.
+func (dm *defaultMux) lookupMiddleware(names []string) []namedMiddleware {
+	list := make([]namedMiddleware, len(names))
+	for i, name := range names {
+		mw, ok := dm.namedMiddleware[name]
+		if !ok {
+			panic(fmt.Sprintf("Middleware '%s' isn't registered", name))
+		}
+		list[i] = namedMiddleware{name, mw}
+	}
+	return list
+}
+
+// Same as Use() with middleware registered under names, see Middleware().
+func (dm *defaultMux) UseNamed(names ...string) {
+	dm.root.UseNamed(names...)
+}
+
+// Same as Use() with middleware registered under names, see Mux.Middleware().
+func (g *Group) UseNamed(names ...string) *Group {
+	g.middleware = append(g.middleware, g.mux.lookupMiddleware(names)...)
+	g.mux.handlersChanged()
+	return g
+}
+
+// Same as Prepend() with middleware registered under names,
+// see Mux.Middleware().
+func (g *Group) PrependNamed(names ...string) *Group {
+	g.prepend = append(g.prepend, g.mux.lookupMiddleware(names)...)
+	g.mux.handlersChanged()
+	return g
+}
+
+// Same as Use() with middleware registered under names, see Mux.Middleware().
+func (r *Route) UseNamed(names ...string) *Route {
+	r.middleware = append(r.middleware, r.group.mux.lookupMiddleware(names)...)
+	r.group.mux.handlersChanged()
+	return r
+}
+
+// Makes this route bypass mux and group middleware registered under names,
+// e.g. a health check skipping authentication and request logging:
+//
+//	m.Add("GET", "healthz", health).Skip("auth", "logging")
+//
+// Middleware added with Use() of the route itself is always applied.
+// Panics if a name isn't registered, see Mux.Middleware().
+func (r *Route) Skip(names ...string) *Route {
+	r.group.mux.lookupMiddleware(names)
+	r.skip = append(r.skip, names...)
+	r.group.mux.handlersChanged()
+	return r
+}
@@ -0,0 +1,74 @@
+package muxer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NameCollision controls what Route.As() does when another route of the
+// mux has the name already.
+type NameCollision int
+
+const (
+	// As() panics. This is the default.
+	RejectNames NameCollision = iota
+	// The name is taken away from the other route, e.g. to replace a route
+	// of a shared Registrar with one of the app.
+	OverrideNames
+)
+
+func (c NameCollision) String() string {
+	switch c {
+	case RejectNames:
+		return "reject"
+	case OverrideNames:
+		return "override"
+	}
+	return fmt.Sprintf("NameCollision(%d)", int(c))
+}
+
+// Sets what Route.As() does with names already taken by other routes.
+func (dm *defaultMux) NameCollisions(c NameCollision) {
+	dm.nameCollisions = c
+}
+
+// Sets namespace of this group, prefixing names of its routes and those
+// of nested groups, which can have namespaces of their own:
+//
+//	admin := m.Group("admin").Namespace("")
+//	admin.Group("users").Namespace("").Add("GET", "{id}", show).As("show")
+//	m.BuildPath("admin.users.show", 1) // "/admin/users/1"
+//
+// An empty ns is generated from the prefix of the group relative to its
+// parent, without params: "admin/{org}/teams" gives "admin.teams". Panics
+// if the namespace is empty.
+func (g *Group) Namespace(ns string) *Group {
+	if ns == "" {
+		prefix := g.prefix
+		if g.parent != nil {
+			prefix = strings.TrimPrefix(prefix, g.parent.prefix)
+		}
+		var segs []string
+		for _, seg := range strings.Split(strings.Trim(prefix, "/"), "/") {
+			if seg != "" && !strings.HasPrefix(seg, "{") {
+				segs = append(segs, seg)
+			}
+		}
+		ns = strings.Join(segs, ".")
+	}
+	if ns = strings.Trim(ns, "."); ns == "" {
+		panic(fmt.Sprintf("Group '%s' has no namespace to generate", g.prefix))
+	}
+	g.namespace = ns
+	return g
+}
+
+// Returns name prefixed with namespaces of this group and its parents.
+func (g *Group) qualify(name string) string {
+	for ; g != nil; g = g.parent {
+		if g.namespace != "" {
+			name = g.namespace + "." + name
+		}
+	}
+	return name
+}
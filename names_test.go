@@ -0,0 +1,53 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestNamespace(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	admin := m.Group("admin").Namespace("")
+	admin.Group("{org}/users").Namespace("").Add("GET", "{id}", dummy).As("show")
+	admin.Group("teams").Add("GET", "{id}", dummy).As("team")
+	m.Group("v2").Namespace("api.v2").Add("GET", "ping", dummy).As("ping")
+	m.Add("GET", "users/{id}", dummy).As("show")
+
+	assertEqual(t, m.BuildPath("admin.users.show", "acme", 1), "/admin/acme/users/1")
+	assertEqual(t, m.BuildPath("admin.team", 2), "/admin/teams/2")
+	assertEqual(t, m.BuildPath("api.v2.ping"), "/v2/ping")
+	assertEqual(t, m.BuildPath("show", 3), "/users/3")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic for a group without a namespace to generate")
+		}
+	}()
+	m.Group("{id}").Namespace("")
+}
+
+func TestNameCollisions(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	a := m.Add("GET", "a", dummy).As("x")
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected a panic for a taken name")
+			}
+		}()
+		m.Add("GET", "b", dummy).As("x")
+	}()
+
+	m.NameCollisions(OverrideNames)
+	c := m.Add("GET", "c", dummy).As("x")
+	if a.Name != "" || c.Name != "x" {
+		t.Errorf("names = %q, %q, want the name moved to the new route", a.Name, c.Name)
+	}
+	assertEqual(t, m.BuildPath("x"), "/c")
+	// Naming a route again keeps it.
+	c.As("x")
+	assertEqual(t, c.Name, "x")
+}
@@ -0,0 +1,135 @@
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	muxer "code.google.com/p/go-muxer"
+)
+
+// Introspector validates opaque access tokens with an RFC 7662 token
+// introspection endpoint. Results are cached, so a revoked token may still
+// be accepted for up to CacheTTL.
+type Introspector struct {
+	// URL of the introspection endpoint.
+	URL string
+	// Credentials of this API, sent with HTTP basic authentication.
+	ClientID     string
+	ClientSecret string
+	// How long results are cached for, but never past expiry of the token.
+	// Defaults to a minute; negative disables caching.
+	CacheTTL time.Duration
+	// Defaults to http.DefaultClient.
+	Client *http.Client
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]introspection
+}
+
+// Maximum number of cached results. The cache is cleared when it's full.
+const maxCachedTokens = 10000
+
+type introspection struct {
+	principal *muxer.Principal // nil if the token is inactive
+	expires   time.Time
+}
+
+type introspectResponse struct {
+	Active    bool     `json:"active"`
+	Scope     string   `json:"scope"`
+	Scp       []string `json:"scp"`
+	Subject   string   `json:"sub"`
+	ExpiresAt *float64 `json:"exp"`
+}
+
+// Validate asks the introspection endpoint whether token is active,
+// unless there is a cached answer.
+func (in *Introspector) Validate(ctx context.Context, token string) (*muxer.Principal, error) {
+	key := sha256.Sum256([]byte(token))
+	now := time.Now()
+	in.mu.Lock()
+	res, ok := in.cache[key]
+	in.mu.Unlock()
+	if !ok || !now.Before(res.expires) {
+		var err error
+		if res, err = in.introspect(ctx, token); err != nil {
+			return nil, err
+		}
+		in.store(key, res)
+	}
+	if res.principal == nil {
+		return nil, invalid("inactive token")
+	}
+	return res.principal, nil
+}
+
+func (in *Introspector) introspect(ctx context.Context, token string) (introspection, error) {
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(ctx, "POST", in.URL,
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return introspection{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if in.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(in.ClientID), url.QueryEscape(in.ClientSecret))
+	}
+	client := in.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return introspection{}, fmt.Errorf("oauth: introspecting token: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return introspection{}, fmt.Errorf("oauth: introspecting token: %s", resp.Status)
+	}
+	var ir introspectResponse
+	if err := json.NewDecoder(resp.Body).Decode(&ir); err != nil {
+		return introspection{}, fmt.Errorf("oauth: decoding introspection: %v", err)
+	}
+
+	ttl := in.CacheTTL
+	if ttl == 0 {
+		ttl = time.Minute
+	}
+	res := introspection{expires: time.Now().Add(ttl)}
+	if ir.ExpiresAt != nil {
+		exp := unixTime(*ir.ExpiresAt)
+		if !exp.After(time.Now()) {
+			ir.Active = false
+		}
+		if exp.Before(res.expires) {
+			res.expires = exp
+		}
+	}
+	if ir.Active {
+		res.principal = &muxer.Principal{
+			Subject: ir.Subject,
+			Scopes:  claimScopes(ir.Scope, ir.Scp),
+		}
+	}
+	return res, nil
+}
+
+func (in *Introspector) store(key [sha256.Size]byte, res introspection) {
+	if in.CacheTTL < 0 {
+		return
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if in.cache == nil || len(in.cache) >= maxCachedTokens {
+		in.cache = make(map[[sha256.Size]byte]introspection)
+	}
+	in.cache[key] = res
+}
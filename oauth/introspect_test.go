@@ -0,0 +1,60 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIntrospector(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if id, secret, ok := r.BasicAuth(); !ok || id != "api" || secret != "s3cret" {
+			http.Error(w, "bad client", http.StatusUnauthorized)
+			return
+		}
+		resp := map[string]interface{}{"active": false}
+		switch r.PostFormValue("token") {
+		case "good":
+			resp = map[string]interface{}{
+				"active": true, "sub": "alice", "scope": "orders:read",
+				"exp": time.Now().Add(time.Hour).Unix(),
+			}
+		case "stale":
+			resp = map[string]interface{}{
+				"active": true, "sub": "bob", "exp": time.Now().Add(-time.Second).Unix(),
+			}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+	in := &Introspector{URL: srv.URL, ClientID: "api", ClientSecret: "s3cret"}
+
+	for i := 0; i < 3; i++ {
+		p, err := in.Validate(context.Background(), "good")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertPrincipal(t, p.Subject, p.Scopes, "alice", []string{"orders:read"})
+	}
+	for _, token := range []string{"revoked", "stale", "revoked"} {
+		if _, err := in.Validate(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: Expected ErrInvalidToken, got %v", token, err)
+		}
+	}
+	// "good" and "revoked" are cached, "stale" expired right away.
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Errorf("Expected 3 introspection calls, got %d", n)
+	}
+
+	in = &Introspector{URL: srv.URL, ClientID: "api", ClientSecret: "wrong"}
+	if _, err := in.Validate(context.Background(), "good"); err == nil || errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected an introspection error, got %v", err)
+	}
+}
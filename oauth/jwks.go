@@ -0,0 +1,371 @@
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	muxer "code.google.com/p/go-muxer"
+)
+
+// JWKS validates JWT access tokens locally with public keys fetched from
+// a JSON Web Key Set endpoint. Supported algorithms are RS256, RS384, RS512,
+// ES256, ES384 and ES512.
+type JWKS struct {
+	// URL of the key set, e.g. "https://auth.example.com/.well-known/jwks.json".
+	URL string
+	// Required "iss" claim, if set.
+	Issuer string
+	// Required value of "aud" claim, if set.
+	Audience string
+	// Allowed clock skew for "exp" and "nbf" claims.
+	Leeway time.Duration
+	// How often keys are fetched again. Defaults to an hour. A token signed
+	// with an unknown key triggers fetching too. Fetching is retried at most
+	// once a minute, keys fetched before are used until it succeeds.
+	RefreshInterval time.Duration
+	// Defaults to http.DefaultClient.
+	Client *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetched   time.Time
+	attempted time.Time
+	err       error // Of the last fetch.
+	refresh   *keysRefresh
+}
+
+// Minimum time between fetches of a key set triggered by unknown keys or
+// retried after failures.
+const minRefreshInterval = time.Minute
+
+// Limit of the time fetching a key set may take.
+const fetchTimeout = 30 * time.Second
+
+// A fetch of the key set in progress, shared by all callers needing it.
+type keysRefresh struct {
+	done chan struct{}
+	err  error
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *float64 `json:"exp"`
+	NotBefore *float64 `json:"nbf"`
+	Scope     string   `json:"scope"`
+	Scp       []string `json:"scp"`
+}
+
+// JWT "aud" claim: either a string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*a = audience{s}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidToken, fmt.Sprintf(format, args...))
+}
+
+// Validate verifies signature and claims of JWT token.
+func (j *JWKS) Validate(ctx context.Context, token string) (*muxer.Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, invalid("not a JWT")
+	}
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, invalid("bad header")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, invalid("bad signature encoding")
+	}
+	key, err := j.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(header.Alg, key, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, err
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, invalid("bad claims")
+	}
+	now := time.Now()
+	if claims.ExpiresAt == nil || now.After(unixTime(*claims.ExpiresAt).Add(j.Leeway)) {
+		return nil, invalid("expired")
+	}
+	if claims.NotBefore != nil && now.Add(j.Leeway).Before(unixTime(*claims.NotBefore)) {
+		return nil, invalid("not valid yet")
+	}
+	if j.Issuer != "" && claims.Issuer != j.Issuer {
+		return nil, invalid("wrong issuer")
+	}
+	if j.Audience != "" && !containsString(claims.Audience, j.Audience) {
+		return nil, invalid("wrong audience")
+	}
+	return &muxer.Principal{
+		Subject: claims.Subject,
+		Scopes:  claimScopes(claims.Scope, claims.Scp),
+	}, nil
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func unixTime(sec float64) time.Time {
+	return time.Unix(0, int64(sec*float64(time.Second)))
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Verifies signature sig of input with key according to alg.
+func verify(alg string, key crypto.PublicKey, input string, sig []byte) error {
+	if len(alg) != 5 {
+		return invalid("unsupported algorithm %q", alg)
+	}
+	var hash crypto.Hash
+	switch alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return invalid("unsupported algorithm %q", alg)
+	}
+	h := hash.New()
+	h.Write([]byte(input))
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return invalid("algorithm %q doesn't match the key", alg)
+		}
+		if rsa.VerifyPKCS1v15(k, hash, digest, sig) != nil {
+			return invalid("bad signature")
+		}
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if !strings.HasPrefix(alg, "ES") || len(sig) != 2*size {
+			return invalid("algorithm %q doesn't match the key", alg)
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return invalid("bad signature")
+		}
+	default:
+		return invalid("unsupported key type %T", key)
+	}
+	return nil
+}
+
+// Returns key with ID kid, fetching the key set if needed. If kid is
+// zero string, the key set must contain exactly one key. Only unknown
+// keys wait for fetching, known ones are refreshed in the background and
+// used if fetching fails.
+func (j *JWKS) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	interval := j.RefreshInterval
+	if interval == 0 {
+		interval = time.Hour
+	}
+	lookup := func() (crypto.PublicKey, bool) {
+		if kid == "" && len(j.keys) == 1 {
+			for _, k := range j.keys {
+				return k, true
+			}
+		}
+		k, ok := j.keys[kid]
+		return k, ok
+	}
+	j.mu.Lock()
+	k, ok := lookup()
+	if ok && time.Since(j.fetched) < interval {
+		j.mu.Unlock()
+		return k, nil
+	}
+	f := j.refresh
+	if f == nil {
+		if time.Since(j.attempted) < minRefreshInterval {
+			err := j.err
+			j.mu.Unlock()
+			switch {
+			case ok:
+				return k, nil
+			case err != nil:
+				return nil, err
+			}
+			return nil, invalid("unknown key %q", kid)
+		}
+		f = &keysRefresh{done: make(chan struct{})}
+		j.refresh, j.attempted = f, time.Now()
+		go j.refreshKeys(context.WithoutCancel(ctx), f)
+	}
+	j.mu.Unlock()
+	if ok {
+		// Known keys are used while they're refreshed.
+		return k, nil
+	}
+
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	j.mu.Lock()
+	k, ok = lookup()
+	j.mu.Unlock()
+	switch {
+	case ok:
+		return k, nil
+	case f.err != nil:
+		return nil, f.err
+	}
+	return nil, invalid("unknown key %q", kid)
+}
+
+// Fetches the key set for refresh f, keeping the keys fetched before if it
+// fails. Doesn't hold j.mu while fetching, so that known keys can be used
+// meanwhile.
+func (j *JWKS) refreshKeys(ctx context.Context, f *keysRefresh) {
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	keys, err := j.fetch(ctx)
+	j.mu.Lock()
+	if err == nil {
+		j.keys, j.fetched = keys, time.Now()
+	}
+	j.err, f.err, j.refresh = err, err, nil
+	j.mu.Unlock()
+	close(f.done)
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// Fetches the key set. Keys of unsupported types are skipped.
+func (j *JWKS) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", j.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := j.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oauth: fetching keys: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oauth: fetching keys: %s", resp.Status)
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("oauth: decoding keys: %v", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if pub := k.publicKey(); pub != nil {
+			keys[k.Kid] = pub
+		}
+	}
+	return keys, nil
+}
+
+// Returns the public key or nil if it's unsupported or malformed.
+func (k jwk) publicKey() crypto.PublicKey {
+	dec := func(s string) *big.Int {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil || len(b) == 0 {
+			return nil
+		}
+		return new(big.Int).SetBytes(b)
+	}
+	switch k.Kty {
+	case "RSA":
+		n, e := dec(k.N), dec(k.E)
+		if n == nil || e == nil || !e.IsInt64() {
+			return nil
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil
+		}
+		x, y := dec(k.X), dec(k.Y)
+		if x == nil || y == nil {
+			return nil
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+	}
+	return nil
+}
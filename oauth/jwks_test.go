@@ -0,0 +1,237 @@
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var b64 = base64.RawURLEncoding
+
+func encodeSegment(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b64.EncodeToString(b)
+}
+
+func signRS256(key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	input := encodeSegment(map[string]string{"alg": "RS256", "kid": kid}) +
+		"." + encodeSegment(claims)
+	digest := sha256.Sum256([]byte(input))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		panic(err)
+	}
+	return input + "." + b64.EncodeToString(sig)
+}
+
+func signES256(key *ecdsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	input := encodeSegment(map[string]string{"alg": "ES256", "kid": kid}) +
+		"." + encodeSegment(claims)
+	digest := sha256.Sum256([]byte(input))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		panic(err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return input + "." + b64.EncodeToString(sig)
+}
+
+func TestJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fetches int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{
+			{"kty": "RSA", "kid": "r1", "use": "sig",
+				"n": b64.EncodeToString(rsaKey.N.Bytes()),
+				"e": b64.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kty": "EC", "kid": "e1", "crv": "P-256",
+				"x": b64.EncodeToString(ecKey.X.Bytes()),
+				"y": b64.EncodeToString(ecKey.Y.Bytes())},
+			{"kty": "oct", "kid": "s1", "k": "c2VjcmV0"},
+		}})
+	}))
+	defer srv.Close()
+	v := &JWKS{URL: srv.URL, Issuer: "https://auth.example.com/", Audience: "orders"}
+
+	exp := time.Now().Add(time.Hour).Unix()
+	claims := func(extra map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"iss": "https://auth.example.com/", "aud": "orders", "sub": "alice",
+			"exp": exp, "scope": "orders:read orders:write",
+		}
+		for k, v := range extra {
+			c[k] = v
+		}
+		return c
+	}
+
+	p, err := v.Validate(context.Background(), signRS256(rsaKey, "r1", claims(nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertPrincipal(t, p.Subject, p.Scopes, "alice", []string{"orders:read", "orders:write"})
+	p, err = v.Validate(context.Background(), signES256(ecKey, "e1", claims(map[string]interface{}{
+		"aud": []string{"billing", "orders"}, "scope": nil, "scp": []string{"orders:read"},
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertPrincipal(t, p.Subject, p.Scopes, "alice", []string{"orders:read"})
+
+	invalidTokens := map[string]string{
+		"garbage":      "not.a.jwt",
+		"expired":      signRS256(rsaKey, "r1", claims(map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()})),
+		"no expiry":    signRS256(rsaKey, "r1", claims(map[string]interface{}{"exp": nil})),
+		"not yet":      signRS256(rsaKey, "r1", claims(map[string]interface{}{"nbf": exp})),
+		"issuer":       signRS256(rsaKey, "r1", claims(map[string]interface{}{"iss": "https://evil.example.com/"})),
+		"audience":     signRS256(rsaKey, "r1", claims(map[string]interface{}{"aud": "billing"})),
+		"signature":    signRS256(otherKey, "r1", claims(nil)),
+		"key mismatch": signRS256(rsaKey, "e1", claims(nil)),
+		"symmetric":    signRS256(rsaKey, "s1", claims(nil)),
+		"unknown key":  signRS256(rsaKey, "r2", claims(nil)),
+	}
+	for name, token := range invalidTokens {
+		if _, err := v.Validate(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: Expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+	// Unknown keys don't trigger fetching again within a minute.
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("Expected 1 fetch of keys, got %d", n)
+	}
+}
+
+func TestJWKSFetchError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	v := &JWKS{URL: srv.URL}
+	_, err := v.Validate(context.Background(), encodeSegment(map[string]string{"alg": "RS256"})+".e30.c2ln")
+	if err == nil || errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected an error fetching keys, got %v", err)
+	}
+}
+
+func TestJWKSRefresh(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fetches, failing, stalling int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		if atomic.LoadInt32(&failing) != 0 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if atomic.LoadInt32(&stalling) != 0 {
+			<-release
+		}
+		time.Sleep(20 * time.Millisecond)
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{
+			{"kty": "RSA", "kid": "r1",
+				"n": b64.EncodeToString(key.N.Bytes()),
+				"e": b64.EncodeToString(big.NewInt(int64(key.E)).Bytes())},
+		}})
+	}))
+	defer srv.Close()
+	v := &JWKS{URL: srv.URL}
+	token := signRS256(key, "r1", map[string]interface{}{"exp": time.Now().Add(time.Hour).Unix()})
+	waitRefresh := func() {
+		for {
+			v.mu.Lock()
+			f := v.refresh
+			v.mu.Unlock()
+			if f == nil {
+				return
+			}
+			<-f.done
+		}
+	}
+
+	// Concurrent validations share one fetch.
+	errs := make(chan error, 10)
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			_, err := v.Validate(context.Background(), token)
+			errs <- err
+		}()
+	}
+	for i := 0; i < cap(errs); i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("Expected 1 fetch of keys, got %d", n)
+	}
+
+	// Known keys don't wait for refreshing.
+	atomic.StoreInt32(&stalling, 1)
+	v.RefreshInterval = time.Nanosecond
+	v.attempted = time.Time{}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	if _, err := v.Validate(ctx, token); err != nil {
+		t.Errorf("Validate() with stalled refresh = %v", err)
+	}
+	cancel()
+	close(release)
+	waitRefresh()
+	if n := atomic.LoadInt32(&fetches); n != 2 {
+		t.Errorf("Expected 2 fetches of keys, got %d", n)
+	}
+
+	// Keys fetched before are used when refreshing fails, which isn't
+	// retried right away.
+	atomic.StoreInt32(&failing, 1)
+	v.mu.Lock()
+	v.attempted = time.Time{}
+	v.mu.Unlock()
+	for i := 0; i < 3; i++ {
+		if _, err := v.Validate(context.Background(), token); err != nil {
+			t.Errorf("Validate() with failing refresh = %v", err)
+		}
+	}
+	waitRefresh()
+	if n := atomic.LoadInt32(&fetches); n != 3 {
+		t.Errorf("Expected 3 fetches of keys, got %d", n)
+	}
+}
+
+func assertPrincipal(t *testing.T, sub string, scopes []string, expSub string, expScopes []string) {
+	t.Helper()
+	if sub != expSub || !reflect.DeepEqual(scopes, expScopes) {
+		t.Errorf("Expected principal %s %v, got %s %v", expSub, expScopes, sub, scopes)
+	}
+}
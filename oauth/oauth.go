@@ -0,0 +1,99 @@
+/*
+Package oauth validates OAuth2/OIDC bearer access tokens of requests served
+by a muxer, either locally as JWTs signed with keys of a JWKS endpoint or
+remotely with an RFC 7662 introspection endpoint:
+
+	v := &oauth.JWKS{
+		URL:      "https://auth.example.com/.well-known/jwks.json",
+		Issuer:   "https://auth.example.com/",
+		Audience: "orders-api",
+	}
+	m.Use(oauth.Middleware(v))
+	m.Add("GET", "orders/{id}", showOrder).RequireScope("orders:read")
+
+The principal of a valid token is attached to the request context,
+see muxer.PrincipalFrom().
+*/
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	muxer "code.google.com/p/go-muxer"
+)
+
+// Validator checks an access token and returns its principal, or an error
+// if the token is invalid.
+type Validator interface {
+	Validate(ctx context.Context, token string) (*muxer.Principal, error)
+}
+
+// ErrInvalidToken is returned by validators for tokens which are malformed,
+// expired, revoked or not meant for this API.
+var ErrInvalidToken = errors.New("oauth: invalid token")
+
+// Returns middleware authenticating requests with a bearer token
+// validated by v. Scopes declared on routes with RequireScope() are
+// enforced. Requests are rejected, with a WWW-Authenticate header
+// as of RFC 6750:
+//
+//   - without a token: 401 Unauthorized;
+//   - with an invalid token: 401 Unauthorized, error="invalid_token";
+//   - with missing scopes: 403 Forbidden, error="insufficient_scope".
+//
+// Errors are passed to muxer.Error() as *muxer.StatusError. Validation
+// errors other than ErrInvalidToken are passed as is, resulting in
+// 500 Internal Server Error by default.
+func Middleware(v Validator) muxer.Middleware {
+	return func(next muxer.HandlerFunc) muxer.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, params url.Values) {
+			token, ok := bearerToken(r)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				muxer.Error(w, r, muxer.NewStatusError(http.StatusUnauthorized, ""))
+				return
+			}
+			p, err := v.Validate(r.Context(), token)
+			if errors.Is(err, ErrInvalidToken) {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				muxer.Error(w, r, muxer.NewStatusError(http.StatusUnauthorized, err.Error()))
+				return
+			}
+			if err != nil {
+				muxer.Error(w, r, err)
+				return
+			}
+			if missing := muxer.MissingScopes(muxer.CurrentRoute(r), p); len(missing) > 0 {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+					`Bearer error="insufficient_scope", scope="%s"`, strings.Join(missing, " ")))
+				muxer.Error(w, r, muxer.NewStatusError(http.StatusForbidden, ""))
+				return
+			}
+			next(w, r.WithContext(muxer.WithPrincipal(r.Context(), p)), params)
+		}
+	}
+}
+
+// Returns the token of "Authorization: Bearer <token>" header.
+func bearerToken(r *http.Request) (string, bool) {
+	auth := r.Header.Get("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "bearer ") {
+		return "", false
+	}
+	token := strings.TrimSpace(auth[7:])
+	return token, token != ""
+}
+
+// Returns scopes of a "scope" claim, a space separated string, or "scp",
+// an array of strings used by some providers.
+func claimScopes(scope string, scp []string) []string {
+	if scope != "" {
+		return strings.Fields(scope)
+	}
+	return scp
+}
@@ -0,0 +1,70 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	muxer "code.google.com/p/go-muxer"
+)
+
+type staticValidator map[string]*muxer.Principal
+
+func (v staticValidator) Validate(ctx context.Context, token string) (*muxer.Principal, error) {
+	if token == "broken" {
+		return nil, errors.New("validator is down")
+	}
+	if p, ok := v[token]; ok {
+		return p, nil
+	}
+	return nil, ErrInvalidToken
+}
+
+func TestMiddleware(t *testing.T) {
+	m := muxer.NewMux("/", http.NewServeMux())
+	m.Use(Middleware(staticValidator{
+		"reader": {Subject: "alice", Scopes: []string{"orders:read"}},
+		"writer": {Subject: "bob", Scopes: []string{"orders:read", "orders:write"}},
+	}))
+	h := func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		w.Write([]byte(muxer.PrincipalFrom(r.Context()).Subject))
+	}
+	m.Add("GET", "orders", h).RequireScope("orders:read")
+	m.Add("POST", "orders", h).RequireScope("orders:read", "orders:write")
+
+	tests := []struct {
+		method, auth string
+		code         int
+		challenge    string
+		body         string
+	}{
+		{"GET", "", 401, "Bearer", "Unauthorized\n"},
+		{"GET", "Basic YWxpY2U6", 401, "Bearer", "Unauthorized\n"},
+		{"GET", "Bearer nope", 401, `Bearer error="invalid_token"`, "oauth: invalid token\n"},
+		{"GET", "Bearer broken", 500, "", "validator is down\n"},
+		{"GET", "bearer reader", 200, "", "alice"},
+		{"POST", "Bearer reader", 403,
+			`Bearer error="insufficient_scope", scope="orders:write"`, "Forbidden\n"},
+		{"POST", "Bearer writer", 200, "", "bob"},
+	}
+	for i, test := range tests {
+		req := httptest.NewRequest(test.method, "/orders", nil)
+		if test.auth != "" {
+			req.Header.Set("Authorization", test.auth)
+		}
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, req)
+		if w.Code != test.code {
+			t.Errorf("%d: Expected %d, got %d", i, test.code, w.Code)
+		}
+		if c := w.Header().Get("WWW-Authenticate"); c != test.challenge {
+			t.Errorf("%d: Expected challenge %q, got %q", i, test.challenge, c)
+		}
+		if body := w.Body.String(); body != test.body {
+			t.Errorf("%d: Expected body %q, got %q", i, test.body, body)
+		}
+	}
+}
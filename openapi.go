@@ -0,0 +1,345 @@
+package muxer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Declares the body of requests of this route as a value like body, e.g.
+// CreateUserReq{}, so that GenerateOpenAPI() describes it by a schema.
+func (r *Route) Request(body interface{}) *Route {
+	if body == nil {
+		panic(fmt.Sprintf("Route '%s %s': nil request body type", r.Method, r.Pattern))
+	}
+	r.request = reflect.TypeOf(body)
+	return r
+}
+
+// Declares a response of this route with status code and the body like
+// body, nil if it has none, so that GenerateOpenAPI() describes it:
+//
+//	m.Add("POST", "users", createUser).As("createUser").
+//		Request(CreateUserReq{}).
+//		Response(201, User{}).
+//		Response(409, nil)
+func (r *Route) Response(code int, body interface{}) *Route {
+	if r.responses == nil {
+		r.responses = make(map[int]reflect.Type)
+	}
+	r.responses[code] = reflect.TypeOf(body)
+	return r
+}
+
+// OpenAPI 3.0 document, only the parts generated from a route table.
+type openAPIDoc struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       openAPIInfo                      `json:"info"`
+	Paths      map[string]map[string]*openAPIOp `json:"paths"`
+	Components *openAPIComponents               `json:"components,omitempty"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]*schema `json:"schemas"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIOp struct {
+	OperationID string                      `json:"operationId,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Deprecated  bool                        `json:"deprecated,omitempty"`
+	Parameters  []openAPIParam              `json:"parameters,omitempty"`
+	RequestBody *openAPIBody                `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+}
+
+type openAPIParam struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *schema `json:"schema"`
+}
+
+type openAPIBody struct {
+	Required bool                         `json:"required"`
+	Content  map[string]*openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema  *schema     `json:"schema,omitempty"`
+	Example interface{} `json:"example,omitempty"`
+}
+
+// JSON schema of a Go type.
+type schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *schema            `json:"additionalProperties,omitempty"`
+}
+
+// GenerateOpenAPI writes to w an OpenAPI 3.0 document in JSON describing
+// routes of m. Request and response bodies declared with Route.Request()
+// and Route.Response() are described by schemas reflected from their Go
+// types following encoding/json rules, named struct types becoming
+// components referenced by name. Route names become operation IDs.
+// Examples recorded with Mux.RecordExamples() are added to request and
+// response bodies. Route docs, see Route.Describe(), are in the first
+// language of each route; DocsHandler() serves them translated.
+func GenerateOpenAPI(w io.Writer, m Mux, title, version string) error {
+	return generateOpenAPI(w, m, title, version, "")
+}
+
+// Same as GenerateOpenAPI() with route docs in language lang.
+func generateOpenAPI(w io.Writer, m Mux, title, version, lang string) error {
+	doc := openAPIDoc{
+		OpenAPI: "3.0.3",
+		Info:    openAPIInfo{title, version},
+		Paths:   make(map[string]map[string]*openAPIOp),
+	}
+	sg := &schemaGen{names: make(map[reflect.Type]string), schemas: make(map[string]*schema)}
+	for _, r := range m.Routes() {
+		p := m.BasePath() + r.template()
+		if doc.Paths[p] == nil {
+			doc.Paths[p] = make(map[string]*openAPIOp)
+		}
+		op := sg.operation(r)
+		if d, ok := r.Doc(lang); ok {
+			op.Summary, op.Description = d.Summary, d.Description
+		}
+		doc.Paths[p][strings.ToLower(r.Method)] = op
+	}
+	if len(sg.schemas) > 0 {
+		doc.Components = &openAPIComponents{sg.schemas}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+func (sg *schemaGen) operation(r *Route) *openAPIOp {
+	op := &openAPIOp{
+		OperationID: r.Name,
+		Tags:        r.Tags,
+		Deprecated:  r.IsDeprecated(),
+		Responses:   make(map[string]*openAPIResponse),
+	}
+	for _, rp := range r.parts {
+		if rp.isVar {
+			s := &schema{Type: "string"}
+			switch {
+			case rp.typ == "int" || rp.typ == "uint":
+				s.Type = "integer"
+			case rp.typ == "uuid":
+				s.Format = "uuid"
+			case rp.re != nil:
+				s.Pattern = rp.re.String()
+			}
+			op.Parameters = append(op.Parameters,
+				openAPIParam{Name: rp.name, In: "path", Required: true, Schema: s})
+		}
+	}
+	if r.request != nil {
+		op.RequestBody = &openAPIBody{Required: true, Content: sg.content(r.request)}
+	}
+	for code, t := range r.responses {
+		resp := &openAPIResponse{Description: http.StatusText(code)}
+		if t != nil {
+			resp.Content = sg.content(t)
+		}
+		op.Responses[strconv.Itoa(code)] = resp
+	}
+	for _, e := range r.Examples() {
+		if ex := exampleValue(e.RequestBody); ex != nil {
+			if op.RequestBody == nil {
+				op.RequestBody = &openAPIBody{Required: true, Content: make(map[string]*openAPIMediaType)}
+			}
+			if mt := jsonMediaType(op.RequestBody.Content); mt.Example == nil {
+				mt.Example = ex
+			}
+		}
+		code := strconv.Itoa(e.Status)
+		resp := op.Responses[code]
+		if resp == nil {
+			resp = &openAPIResponse{Description: http.StatusText(e.Status)}
+			op.Responses[code] = resp
+		}
+		if ex := exampleValue(e.ResponseBody); ex != nil {
+			if resp.Content == nil {
+				resp.Content = make(map[string]*openAPIMediaType)
+			}
+			jsonMediaType(resp.Content).Example = ex
+		}
+	}
+	if len(op.Responses) == 0 {
+		op.Responses["default"] = &openAPIResponse{Description: "Response"}
+	}
+	return op
+}
+
+// Reflects schemas of Go types, collecting those of named structs.
+type schemaGen struct {
+	names   map[reflect.Type]string
+	schemas map[string]*schema
+}
+
+// Returns the JSON media type of content, adding it if missing.
+func jsonMediaType(content map[string]*openAPIMediaType) *openAPIMediaType {
+	mt := content["application/json"]
+	if mt == nil {
+		mt = &openAPIMediaType{}
+		content["application/json"] = mt
+	}
+	return mt
+}
+
+func (sg *schemaGen) content(t reflect.Type) map[string]*openAPIMediaType {
+	return map[string]*openAPIMediaType{"application/json": {Schema: sg.schema(t)}}
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	marshalType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// Returns schema of values of type t.
+func (sg *schemaGen) schema(t reflect.Type) *schema {
+	if t == timeType {
+		return &schema{Type: "string", Format: "date-time"}
+	}
+	if t.Implements(marshalType) {
+		// Marshals to anything.
+		return &schema{}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &schema{Type: "string"}
+	case reflect.Ptr:
+		s := sg.schema(t.Elem())
+		if s.Ref != "" {
+			return s
+		}
+		s.Nullable = true
+		return s
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return &schema{Type: "string", Format: "byte"}
+		}
+		return &schema{Type: "array", Items: sg.schema(t.Elem())}
+	case reflect.Map:
+		return &schema{Type: "object", AdditionalProperties: sg.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return sg.structSchema(t)
+		}
+		name, ok := sg.names[t]
+		if !ok {
+			name = sg.schemaName(t)
+			sg.names[t] = name
+			// Registered first, so recursive types terminate.
+			sg.schemas[name] = nil
+			sg.schemas[name] = sg.structSchema(t)
+		}
+		return &schema{Ref: "#/components/schemas/" + name}
+	}
+	return &schema{}
+}
+
+// Returns a component name of named type t, qualified with its package
+// name if another type has the same name.
+func (sg *schemaGen) schemaName(t reflect.Type) string {
+	name := t.Name()
+	if _, taken := sg.schemas[name]; !taken {
+		return name
+	}
+	pkg := t.PkgPath()
+	return goIdent(pkg[strings.LastIndexByte(pkg, '/')+1:], true) + name
+}
+
+func (sg *schemaGen) structSchema(t reflect.Type) *schema {
+	s := &schema{Type: "object", Properties: make(map[string]*schema)}
+	sg.addFields(s, t)
+	return s
+}
+
+// Adds properties of exported fields of struct type t to s, including
+// those of embedded structs.
+func (sg *schemaGen) addFields(s *schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				sg.addFields(s, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if containsString(strings.Split(opts, ","), "string") {
+			s.Properties[name] = &schema{Type: "string"}
+		} else {
+			s.Properties[name] = sg.schema(ft)
+		}
+		if !containsString(strings.Split(opts, ","), "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// Returns pattern of this route as an OpenAPI path template: params are
+// "{name}", including catch-alls.
+func (r *Route) template() string {
+	segs := make([]string, r.partsLen)
+	for i, rp := range r.parts {
+		segs[i] = rp.name
+		if rp.isVar {
+			segs[i] = "{" + rp.name + "}"
+		}
+	}
+	return strings.Join(segs, "/")
+}
@@ -0,0 +1,81 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+type testAudit struct {
+	Created time.Time `json:"created"`
+}
+
+type testUser struct {
+	testAudit
+	ID      int64     `json:"id"`
+	Name    string    `json:"name"`
+	Email   *string   `json:"email,omitempty"`
+	Tags    []string  `json:"tags,omitempty"`
+	Manager *testUser `json:"manager,omitempty"`
+	Secret  string    `json:"-"`
+	Extra   map[string]float64
+	hidden  bool
+}
+
+type testCreateUser struct {
+	Name string `json:"name"`
+}
+
+func TestGenerateOpenAPI(t *testing.T) {
+	m := NewMux("/api/", http.NewServeMux())
+	m.Add("POST", "users", dummy).As("createUser").Tag("users").
+		Request(testCreateUser{}).Response(201, testUser{}).Response(409, nil)
+	m.Add("GET", "users/{id}", dummy).Response(200, &testUser{})
+	m.Add("GET", "health", dummy)
+
+	var buf bytes.Buffer
+	if err := GenerateOpenAPI(&buf, m, "Test", "1.0"); err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	get := func(path ...string) interface{} {
+		var v interface{} = doc
+		for _, p := range path {
+			v = v.(map[string]interface{})[p]
+		}
+		return v
+	}
+
+	op := get("paths", "/api/users", "post")
+	assertEqual(t, op.(map[string]interface{})["operationId"].(string), "createUser")
+	ref := get("paths", "/api/users", "post", "responses", "201", "content", "application/json", "schema", "$ref")
+	assertEqual(t, ref.(string), "#/components/schemas/testUser")
+	assertEqual(t, get("paths", "/api/users", "post", "responses", "409", "description").(string), "Conflict")
+	assertEqual(t, get("paths", "/api/users/{id}", "get", "parameters").([]interface{})[0].(map[string]interface{})["name"].(string), "id")
+	assertEqual(t, get("paths", "/api/health", "get", "responses", "default", "description").(string), "Response")
+
+	user := get("components", "schemas", "testUser").(map[string]interface{})
+	var props []string
+	for name := range user["properties"].(map[string]interface{}) {
+		props = append(props, name)
+	}
+	want := []string{"Extra", "created", "email", "id", "manager", "name", "tags"}
+	if sort.Strings(props); !reflect.DeepEqual(props, want) {
+		t.Errorf("properties = %v, want %v", props, want)
+	}
+	assertEqual(t, get("components", "schemas", "testUser", "properties", "manager", "$ref").(string), "#/components/schemas/testUser")
+	assertEqual(t, get("components", "schemas", "testUser", "properties", "created", "format").(string), "date-time")
+	if req := user["required"].([]interface{}); len(req) != 4 {
+		t.Errorf("required = %v, want created, id, name and Extra", req)
+	}
+}
@@ -0,0 +1,23 @@
+package muxer
+
+import "sort"
+
+// Makes this mux answer OPTIONS requests to URL paths of its routes with
+// 204 No Content and an Allow header listing methods of those routes,
+// unless there's an OPTIONS route for the path. OPTIONS is then listed in
+// Allow headers of 405 Method Not Allowed responses too. CORS preflight
+// requests are answered this way if the requested route has no CORS
+// policy, see CORS().
+func (dm *defaultMux) AutoOptions() {
+	dm.autoOptions = true
+}
+
+// Returns methods allowed at the URL path of routes, for an Allow header.
+func (dm *defaultMux) allowedMethods(routes []*Route) []string {
+	methods := allowedMethods(routes)
+	if dm.autoOptions && !containsString(methods, "OPTIONS") {
+		methods = append(methods, "OPTIONS")
+		sort.Strings(methods)
+	}
+	return methods
+}
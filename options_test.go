@@ -0,0 +1,35 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestAutoOptions(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "users/{id}", dummy)
+	m.Add("PUT", "users/{id}", dummy)
+	m.Add("GET", "files", dummy)
+	m.Add("OPTIONS", "files", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		w.Write([]byte("custom"))
+	})
+	if code := serve(m, "OPTIONS", "/users/1").Code; code != http.StatusMethodNotAllowed {
+		t.Errorf("OPTIONS without AutoOptions() = %d, want 405", code)
+	}
+
+	m.AutoOptions()
+	w := serve(m, "OPTIONS", "/users/1")
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Errorf("OPTIONS /users/1 = %d, %q", w.Code, w.Body.String())
+	}
+	assertEqual(t, w.Header().Get("Allow"), "GET, HEAD, OPTIONS, PUT")
+	assertEqual(t, serve(m, "DELETE", "/users/1").Header().Get("Allow"), "GET, HEAD, OPTIONS, PUT")
+	assertEqual(t, serve(m, "OPTIONS", "/files").Body.String(), "custom")
+	if code := serve(m, "OPTIONS", "/none").Code; code != http.StatusNotFound {
+		t.Errorf("OPTIONS /none = %d, want 404", code)
+	}
+}
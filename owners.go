@@ -0,0 +1,71 @@
+package muxer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Sets the team owning routes of this group and its nested groups, e.g.
+// "payments-team", so that alerts on them page the right people.
+func (g *Group) Owner(team string) *Group {
+	g.owner = team
+	return g
+}
+
+// Sets the team owning this route, overriding the owner of its group.
+//
+//	m.Group("billing").Owner("payments-team")
+//	m.Add("GET", "search", search).Owner("search-team")
+func (r *Route) Owner(team string) *Route {
+	r.owner = team
+	return r
+}
+
+// Returns the team owning this route, inherited from its groups, or zero
+// string if there's none.
+func (r *Route) OwnedBy() string {
+	if r.owner != "" {
+		return r.owner
+	}
+	for g := r.group; g != nil; g = g.parent {
+		if g.owner != "" {
+			return g.owner
+		}
+	}
+	return ""
+}
+
+// Route of an owner mapping, see ExportOwners().
+type RouteOwner struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Name   string `json:"name,omitempty"`
+	Owner  string `json:"owner"`
+}
+
+// Writes the mapping of routes described by infos to their owners to w,
+// for alert routing systems, as "json" or "csv" with a header row.
+// Routes without an owner have an empty one.
+func ExportOwners(w io.Writer, infos []RouteInfo, format string) error {
+	owners := make([]RouteOwner, 0, len(infos))
+	for _, i := range infos {
+		owners = append(owners, RouteOwner{i.Method, i.Path, i.Name, i.Owner})
+	}
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(owners)
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"method", "path", "name", "owner"})
+		for _, o := range owners {
+			cw.Write([]string{o.Method, o.Path, o.Name, o.Owner})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("muxer: unknown owners format %q", format)
+}
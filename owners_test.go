@@ -0,0 +1,47 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+)
+
+func TestOwners(t *testing.T) {
+	m := NewMux("/api", http.NewServeMux())
+	billing := m.Group("billing").Owner("payments-team")
+	billing.Group("invoices").Add("GET", "{id}", dummy).As("invoice")
+	billing.Add("GET", "search", dummy).Owner("search-team")
+	m.Add("GET", "health", dummy)
+
+	var buf bytes.Buffer
+	if err := ExportOwners(&buf, RouteInfos(m), "csv"); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, buf.String(), "method,path,name,owner\n"+
+		"GET,/api/billing/invoices/{id},invoice,payments-team\n"+
+		"GET,/api/billing/search,,search-team\n"+
+		"GET,/api/health,,\n")
+
+	buf.Reset()
+	if err := ExportOwners(&buf, RouteInfos(m)[:1], "json"); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, buf.String(), `[
+  {
+    "method": "GET",
+    "path": "/api/billing/invoices/{id}",
+    "name": "invoice",
+    "owner": "payments-team"
+  }
+]
+`)
+	if err := ExportOwners(&buf, nil, "xml"); err == nil {
+		t.Error("unknown format accepted")
+	}
+
+	m.TrackStats()
+	assertEqual(t, m.Stats()[1].Owner, "search-team")
+}
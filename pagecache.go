@@ -0,0 +1,217 @@
+package muxer
+
+import (
+	"bytes"
+	"container/list"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PageCache is an in-process cache of rendered responses of GET routes,
+// e.g. server-rendered HTML pages. Use it as a route middleware:
+//
+//	pages := muxer.NewPageCache(time.Minute, 1000)
+//	m.Add("GET", "posts/{id}", showPost).Use(pages.Middleware("Accept-Language"))
+//
+// Entries are keyed by route, URL path params, query string and values of
+// the request headers listed in vary. Only 200 OK responses without
+// Set-Cookie header and without "private" or "no-store" in Cache-Control
+// are cached.
+type PageCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // of *pageEntry, most recently used first
+}
+
+type pageEntry struct {
+	key     string
+	route   *Route
+	params  string
+	expires time.Time
+	header  http.Header
+	body    []byte
+}
+
+// Creates a new page cache. Entries expire after ttl. When more than
+// maxEntries are cached, the least recently used ones are evicted.
+func NewPageCache(ttl time.Duration, maxEntries int) *PageCache {
+	return &PageCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// Returns middleware caching responses of the route it's applied to.
+// Responses get a Vary header with provided header names. X-Cache header is
+// set to "HIT" or "MISS". Responses of routes with a policy or required
+// scopes aren't cached, since they're for particular callers. Only headers
+// the handler sets are cached, those set for each request before it, e.g.
+// CORS ones, are set anew for cached responses.
+func (c *PageCache) Middleware(vary ...string) Middleware {
+	varyValue := strings.Join(vary, ", ")
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+			route := CurrentRoute(r)
+			if r.Method != "GET" || route == nil || route.policy != "" || len(route.scopes) > 0 {
+				next(w, r, v)
+				return
+			}
+			params := v.Encode()
+			key := pageKey(route, params, r, vary)
+			if varyValue != "" {
+				w.Header().Add("Vary", varyValue)
+			}
+			if e := c.get(key); e != nil {
+				for k, vals := range e.header {
+					if _, ok := w.Header()[k]; !ok {
+						w.Header()[k] = vals
+					}
+				}
+				w.Header().Set("X-Cache", "HIT")
+				w.WriteHeader(http.StatusOK)
+				w.Write(e.body)
+				return
+			}
+			w.Header().Set("X-Cache", "MISS")
+			before := w.Header().Clone()
+			rec := &recordingWriter{ResponseWriter: w, code: http.StatusOK}
+			next(rec, r, v)
+			if rec.cacheable() {
+				c.put(&pageEntry{
+					key:     key,
+					route:   route,
+					params:  params,
+					expires: time.Now().Add(c.ttl),
+					header:  handlerHeader(before, w.Header()),
+					body:    rec.body.Bytes(),
+				})
+			}
+		}
+	}
+}
+
+// Removes cached responses of route with URL path params. This is the hook
+// to call when the underlying data changes.
+func (c *PageCache) Invalidate(route *Route, params url.Values) {
+	p := params.Encode()
+	c.remove(func(e *pageEntry) bool { return e.route == route && e.params == p })
+}
+
+// Removes all cached responses of route.
+func (c *PageCache) InvalidateRoute(route *Route) {
+	c.remove(func(e *pageEntry) bool { return e.route == route })
+}
+
+// Removes all cached responses.
+func (c *PageCache) Purge() {
+	c.remove(func(e *pageEntry) bool { return true })
+}
+
+// Returns the number of cached responses, including expired ones
+// not evicted yet.
+func (c *PageCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+func (c *PageCache) get(key string) *pageEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	e := el.Value.(*pageEntry)
+	if time.Now().After(e.expires) {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+		return nil
+	}
+	c.lru.MoveToFront(el)
+	return e
+}
+
+func (c *PageCache) put(e *pageEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[e.key]; ok {
+		c.lru.Remove(el)
+	}
+	c.entries[e.key] = c.lru.PushFront(e)
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		old := c.lru.Remove(c.lru.Back()).(*pageEntry)
+		delete(c.entries, old.key)
+	}
+}
+
+func (c *PageCache) remove(match func(*pageEntry) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for el := c.lru.Front(); el != nil; {
+		next := el.Next()
+		if e := el.Value.(*pageEntry); match(e) {
+			c.lru.Remove(el)
+			delete(c.entries, e.key)
+		}
+		el = next
+	}
+}
+
+// Returns headers of after the handler has set, leaving out those set
+// for each request before it ran, e.g. CORS headers for its origin,
+// which before has.
+func handlerHeader(before, after http.Header) http.Header {
+	h := make(http.Header, len(after))
+	for k, vals := range after {
+		if prev, ok := before[k]; ok && strings.Join(prev, "\n") == strings.Join(vals, "\n") ||
+			strings.HasPrefix(k, "Access-Control-") {
+			continue
+		}
+		h[k] = append([]string(nil), vals...)
+	}
+	return h
+}
+
+func pageKey(route *Route, params string, r *http.Request, vary []string) string {
+	var b strings.Builder
+	b.WriteString(route.Method + " " + route.Pattern + "\n")
+	b.WriteString(params + "\n" + r.URL.RawQuery)
+	for _, h := range vary {
+		b.WriteString("\n" + strings.Join(r.Header.Values(h), ","))
+	}
+	return b.String()
+}
+
+// Passes writes through to the underlying ResponseWriter, keeping a copy
+// of the status code and body.
+type recordingWriter struct {
+	http.ResponseWriter
+	code int
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(code int) {
+	w.code = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) cacheable() bool {
+	h := w.Header()
+	cc := h.Get("Cache-Control")
+	return w.code == http.StatusOK && h.Get("Set-Cookie") == "" &&
+		!strings.Contains(cc, "private") && !strings.Contains(cc, "no-store")
+}
@@ -0,0 +1,120 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestPageCache(t *testing.T) {
+	renders := 0
+	render := func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		renders++
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, "<p>%s %s #%d</p>", v.Get("id"), r.Header.Get("Accept-Language"), renders)
+	}
+	pages := NewPageCache(time.Minute, 2)
+	m := NewMux("/", http.NewServeMux())
+	post := m.Add("GET", "posts/{id}", render).Use(pages.Middleware("Accept-Language"))
+
+	get := func(path, lang string) (string, string) {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Accept-Language", lang)
+		w := serveRequest(m, req)
+		assertEqual(t, w.Header().Get("Vary"), "Accept-Language")
+		assertEqual(t, w.Header().Get("Content-Type"), "text/html")
+		return w.Body.String(), w.Header().Get("X-Cache")
+	}
+	expect := func(path, lang, body, xcache string) {
+		b, x := get(path, lang)
+		assertEqual(t, b, body)
+		assertEqual(t, x, xcache)
+	}
+	expect("/posts/1", "en", "<p>1 en #1</p>", "MISS")
+	expect("/posts/1", "en", "<p>1 en #1</p>", "HIT")
+	expect("/posts/1", "de", "<p>1 de #2</p>", "MISS")
+	expect("/posts/1?page=2", "de", "<p>1 de #3</p>", "MISS")
+	if pages.Len() != 2 {
+		t.Fatalf("Expected 2 entries after eviction, got %d", pages.Len())
+	}
+	expect("/posts/1", "en", "<p>1 en #4</p>", "MISS")
+
+	pages.Invalidate(post, url.Values{"id": {"1"}})
+	if pages.Len() != 0 {
+		t.Fatalf("Expected no entries after invalidation, got %d", pages.Len())
+	}
+	expect("/posts/1", "en", "<p>1 en #5</p>", "MISS")
+	pages.InvalidateRoute(post)
+	expect("/posts/1", "en", "<p>1 en #6</p>", "MISS")
+}
+
+func TestPageCacheSkipsPrivate(t *testing.T) {
+	pages := NewPageCache(time.Minute, 0)
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "me", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		w.Header().Set("Cache-Control", "private")
+	}).Use(pages.Middleware())
+	m.Add("GET", "missing", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		http.NotFound(w, r)
+	}).Use(pages.Middleware())
+	serve(m, "GET", "/me")
+	serve(m, "GET", "/missing")
+	if pages.Len() != 0 {
+		t.Fatalf("Expected no cached pages, got %d", pages.Len())
+	}
+}
+
+func TestPageCachePolicy(t *testing.T) {
+	pages := NewPageCache(time.Minute, 10)
+	m := NewMux("/", http.NewServeMux())
+	m.SetAuthorizer(AuthorizerFunc(func(ctx context.Context, policy string, v url.Values) error {
+		if ctx.Value(userKey{}) == nil {
+			return NewStatusError(http.StatusUnauthorized, "")
+		}
+		return nil
+	}))
+	m.PreRoute(func(r *http.Request) *http.Request {
+		if u := r.Header.Get("X-User"); u != "" {
+			return r.WithContext(context.WithValue(r.Context(), userKey{}, u))
+		}
+		return nil
+	})
+	m.Add("GET", "orders/{id}", dummy).Policy("orders:read").Use(pages.Middleware())
+
+	req, _ := http.NewRequest("GET", "/orders/1", nil)
+	req.Header.Set("X-User", "alex")
+	if w := serveRequest(m, req); w.Code != 200 || w.Header().Get("X-Cache") != "" {
+		t.Errorf("authorized request = %d, X-Cache %q", w.Code, w.Header().Get("X-Cache"))
+	}
+	if w := serve(m, "GET", "/orders/1"); w.Code != http.StatusUnauthorized {
+		t.Errorf("unauthenticated request = %d, want 401", w.Code)
+	}
+}
+
+func TestPageCacheCORS(t *testing.T) {
+	pages := NewPageCache(time.Minute, 0)
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "feed", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[]"))
+	}).CORS(&CORS{Origins: []string{"https://a.example.com", "https://b.example.com"}}).
+		Use(pages.Middleware())
+
+	for _, origin := range []string{"https://a.example.com", "https://b.example.com"} {
+		req, _ := http.NewRequest("GET", "/feed", nil)
+		req.Header.Set("Origin", origin)
+		w := serveRequest(m, req)
+		assertEqual(t, w.Header().Get("Access-Control-Allow-Origin"), origin)
+		assertEqual(t, w.Header().Get("Content-Type"), "application/json")
+		assertEqual(t, w.Body.String(), "[]")
+	}
+	if pages.Len() != 1 {
+		t.Errorf("Expected 1 entry, got %d", pages.Len())
+	}
+}
@@ -0,0 +1,139 @@
+package muxer
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Function type notified when a route handler panics with value p.
+type PanicFunc func(r *http.Request, route *Route, p interface{})
+
+// Makes this mux recover panics of route handlers, responding with
+// 500 Internal Server Error and counting them per route, see
+// Route.Panics(). Each panic is reported to onPanic, or logged with its
+// stack trace if onPanic is nil.
+//
+// If limit is positive, a route panicking limit times within window is
+// tripped: it responds with 503 Service Unavailable without calling its
+// handler for the next window, so a crashing endpoint doesn't flood logs
+// or take down its dependencies. Panics with http.ErrAbortHandler are passed
+// on. Call it before serving requests.
+func (dm *defaultMux) IsolatePanics(limit int, window time.Duration, onPanic PanicFunc) {
+	if limit > 0 && window <= 0 {
+		panic(fmt.Sprintf("Panic window must be positive, not %v", window))
+	}
+	dm.isolation = &panicIsolation{limit: limit, window: window, onPanic: onPanic}
+}
+
+type panicIsolation struct {
+	limit   int
+	window  time.Duration
+	onPanic PanicFunc
+}
+
+// Panics of a route.
+type routePanics struct {
+	mu    sync.Mutex
+	total uint64
+	// Times of the latest panics, at most limit of them.
+	recent  []time.Time
+	tripped time.Time
+}
+
+// Returns number of panics of this route handler recovered since
+// Mux.IsolatePanics() was called.
+func (r *Route) Panics() uint64 {
+	p := &r.live.panics
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.total
+}
+
+// Reports whether this route has panicked too often recently and
+// responds with 503 Service Unavailable, see Mux.IsolatePanics().
+func (r *Route) Tripped() bool {
+	pi := r.group.mux.isolation
+	if pi == nil || pi.limit <= 0 {
+		return false
+	}
+	return pi.retryAfter(&r.live.panics, time.Now()) > 0
+}
+
+// Returns how long a route with panics p stays tripped.
+func (pi *panicIsolation) retryAfter(p *routePanics, now time.Time) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.tripped.IsZero() {
+		return 0
+	}
+	return p.tripped.Add(pi.window).Sub(now)
+}
+
+// Counts a panic of a route at now. Reports whether the route got tripped.
+func (pi *panicIsolation) record(p *routePanics, now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.total++
+	if pi.limit <= 0 {
+		return false
+	}
+	for len(p.recent) > 0 && now.Sub(p.recent[0]) >= pi.window {
+		p.recent = p.recent[1:]
+	}
+	if len(p.recent) == pi.limit {
+		p.recent = p.recent[1:]
+	}
+	p.recent = append(p.recent, now)
+	if len(p.recent) < pi.limit {
+		return false
+	}
+	p.tripped = now
+	p.recent = p.recent[:0]
+	return true
+}
+
+// Returns h serving route r with panics recovered.
+func (pi *panicIsolation) wrap(r *Route, h HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request, v url.Values) {
+		if pi.limit > 0 {
+			if d := pi.retryAfter(&r.live.panics, time.Now()); d > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int((d+time.Second-1)/time.Second)))
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable),
+					http.StatusServiceUnavailable)
+				return
+			}
+		}
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+			tripped := pi.record(&r.live.panics, time.Now())
+			if tripped && pi.onPanic == nil {
+				log.Printf("muxer: route %s %s tripped after %d panics", r.Method, r.Pattern, pi.limit)
+			}
+			if pi.onPanic != nil {
+				pi.onPanic(req, r, p)
+			} else {
+				log.Printf("muxer: panic serving %s %s: %v\n%s", r.Method, r.Pattern, p, debug.Stack())
+			}
+			events := &r.group.mux.events
+			events.emit(PanicRecovered, r, p)
+			if tripped {
+				events.emit(RouteTripped, r, p)
+			}
+			http.Error(w, http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError)
+		}()
+		h(w, req, v)
+	}
+}
@@ -0,0 +1,64 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestIsolatePanics(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	var reported []interface{}
+	m.IsolatePanics(2, time.Minute, func(r *http.Request, route *Route, p interface{}) {
+		reported = append(reported, p)
+	})
+	m.TrackStats()
+	r := m.Add("GET", "crash", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		panic("boom")
+	})
+	m.Add("GET", "ok", dummy)
+
+	if w := serve(m, "GET", "/crash"); w.Code != http.StatusInternalServerError {
+		t.Errorf("code = %d, want 500", w.Code)
+	}
+	if r.Tripped() {
+		t.Error("tripped after one panic")
+	}
+	serve(m, "GET", "/crash")
+	if !r.Tripped() || r.Panics() != 2 {
+		t.Errorf("Tripped() = %v, Panics() = %d", r.Tripped(), r.Panics())
+	}
+	w := serve(m, "GET", "/crash")
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "60" {
+		t.Errorf("tripped response = %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if len(reported) != 2 {
+		t.Errorf("reported %d panics, want 2", len(reported))
+	}
+	assertEqual(t, serve(m, "GET", "/ok").Body.String(), "params:")
+
+	s := m.Stats()[0]
+	if s.Panics != 2 || !s.Tripped || s.Statuses[500] != 2 || s.Statuses[503] != 1 {
+		t.Errorf("stats = %+v", s)
+	}
+}
+
+func TestPanicWindow(t *testing.T) {
+	pi := &panicIsolation{limit: 2, window: time.Second}
+	var p routePanics
+	now := time.Now()
+	pi.record(&p, now)
+	if pi.record(&p, now.Add(2*time.Second)) {
+		t.Error("tripped by panics in different windows")
+	}
+	if !pi.record(&p, now.Add(2500*time.Millisecond)) {
+		t.Error("not tripped by panics within a window")
+	}
+	if d := pi.retryAfter(&p, now.Add(3*time.Second)); d != 500*time.Millisecond {
+		t.Errorf("retryAfter = %v, want 500ms", d)
+	}
+}
@@ -0,0 +1,434 @@
+package muxer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Media types of PATCH request bodies supported by PatchRequest().
+const (
+	JSONPatchType  = "application/json-patch+json"
+	MergePatchType = "application/merge-patch+json"
+)
+
+// Value of Accept-Patch header listing supported patch formats.
+const AcceptPatch = JSONPatchType + ", " + MergePatchType
+
+// Maximum number of operations of a JSON Patch document.
+const maxPatchOps = 1000
+
+// Applies the body of PATCH request r to JSON document doc according to
+// its Content-Type, either a JSON Patch (RFC 6902) or a JSON Merge Patch
+// (RFC 7396), and returns the patched document. Bodies larger than maxBytes
+// are rejected.
+//
+//	func patchUser(w http.ResponseWriter, r *http.Request, v url.Values) {
+//		doc, err := muxer.PatchRequest(r, loadUser(v.Get("id")), 64<<10)
+//		if err != nil {
+//			muxer.Error(w, r, err)
+//			return
+//		}
+//		// validate and store doc
+//	}
+//
+// Errors are *StatusError: 415 Unsupported Media Type for other content
+// types (respond with "Accept-Patch: " + AcceptPatch), 413 Request Entity
+// Too Large, 400 Bad Request for malformed patches, 409 Conflict for
+// a failed "test" operation and 422 Unprocessable Entity when the patch
+// doesn't fit doc.
+func PatchRequest(r *http.Request, doc []byte, maxBytes int64) ([]byte, error) {
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mt != JSONPatchType && mt != MergePatchType {
+		return nil, NewStatusError(http.StatusUnsupportedMediaType,
+			"Supported patch formats: "+AcceptPatch)
+	}
+	patch, err := io.ReadAll(io.LimitReader(r.Body, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(patch)) > maxBytes {
+		return nil, NewStatusError(http.StatusRequestEntityTooLarge, "")
+	}
+	if mt == MergePatchType {
+		return ApplyMergePatch(doc, patch)
+	}
+	return ApplyJSONPatch(doc, patch)
+}
+
+// Applies JSON Merge Patch patch to doc as of RFC 7396.
+func ApplyMergePatch(doc, patch []byte) ([]byte, error) {
+	var target, p interface{}
+	if err := decodeJSON(doc, &target); err != nil {
+		return nil, err
+	}
+	if err := decodeJSON(patch, &p); err != nil {
+		return nil, NewStatusError(http.StatusBadRequest, "Malformed merge patch: "+err.Error())
+	}
+	return json.Marshal(mergePatch(target, p))
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{})
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = mergePatch(t[k], v)
+		}
+	}
+	return t
+}
+
+type patchOp struct {
+	Op    string
+	Path  *string
+	From  *string
+	Value json.RawMessage
+	// Whether the operation has a value, which may be null.
+	HasValue bool
+}
+
+// Decodes an operation keeping track of whether it has a value, since
+// json.RawMessage of null is nil.
+func (op *patchOp) UnmarshalJSON(b []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	for name, dst := range map[string]interface{}{"op": &op.Op, "path": &op.Path, "from": &op.From} {
+		if raw, ok := fields[name]; ok {
+			if err := json.Unmarshal(raw, dst); err != nil {
+				return err
+			}
+		}
+	}
+	op.Value, op.HasValue = fields["value"]
+	return nil
+}
+
+// Applies JSON Patch patch to doc as of RFC 6902. Operations are applied
+// in order and either all of them succeed or doc isn't changed.
+func ApplyJSONPatch(doc, patch []byte) ([]byte, error) {
+	var target interface{}
+	if err := decodeJSON(doc, &target); err != nil {
+		return nil, err
+	}
+	var ops []patchOp
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, NewStatusError(http.StatusBadRequest, "Malformed JSON patch: "+err.Error())
+	}
+	if len(ops) > maxPatchOps {
+		return nil, NewStatusError(http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("JSON patch has more than %d operations", maxPatchOps))
+	}
+	for i, op := range ops {
+		var err error
+		if target, err = op.apply(target); err != nil {
+			if se, ok := err.(*StatusError); ok {
+				se.Message = fmt.Sprintf("Operation %d (%s): %s", i, op.Op, se.Message)
+			}
+			return nil, err
+		}
+	}
+	return json.Marshal(target)
+}
+
+func badPatch(format string, args ...interface{}) error {
+	return NewStatusError(http.StatusBadRequest, fmt.Sprintf(format, args...))
+}
+
+func unfitPatch(format string, args ...interface{}) error {
+	return NewStatusError(http.StatusUnprocessableEntity, fmt.Sprintf(format, args...))
+}
+
+// Returns doc with this operation applied.
+func (op patchOp) apply(doc interface{}) (interface{}, error) {
+	if op.Path == nil {
+		return nil, badPatch("missing path")
+	}
+	path, err := parsePointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	switch op.Op {
+	case "add", "replace", "test":
+		if !op.HasValue {
+			return nil, badPatch("missing value")
+		}
+		if err := decodeJSON(op.Value, &value); err != nil {
+			return nil, badPatch("malformed value")
+		}
+	case "move", "copy":
+		if op.From == nil {
+			return nil, badPatch("missing from")
+		}
+		from, err := parsePointer(*op.From)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" && strings.HasPrefix(*op.Path, *op.From+"/") {
+			return nil, badPatch("can't move a value into itself")
+		}
+		if value, err = pointerValue(doc, from); err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if doc, err = patchAt(doc, from, removeValue); err != nil {
+				return nil, err
+			}
+		} else {
+			value = deepCopyJSON(value)
+		}
+	case "remove":
+	default:
+		return nil, badPatch("unknown operation")
+	}
+
+	switch op.Op {
+	case "add", "move", "copy":
+		return patchAt(doc, path, addValue(value))
+	case "remove":
+		return patchAt(doc, path, removeValue)
+	case "replace":
+		return patchAt(doc, path, replaceValue(value))
+	}
+	// test
+	cur, err := pointerValue(doc, path)
+	if err != nil {
+		return nil, err
+	}
+	if !equalJSON(cur, value) {
+		return nil, NewStatusError(http.StatusConflict, "test failed")
+	}
+	return doc, nil
+}
+
+// Parses a JSON Pointer (RFC 6901) into reference tokens.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if p[0] != '/' {
+		return nil, badPatch("invalid pointer %q", p)
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// Returns the value of doc at pointer tokens.
+func pointerValue(doc interface{}, tokens []string) (interface{}, error) {
+	for _, t := range tokens {
+		switch c := doc.(type) {
+		case map[string]interface{}:
+			v, ok := c[t]
+			if !ok {
+				return nil, unfitPatch("no member %q", t)
+			}
+			doc = v
+		case []interface{}:
+			i, err := arrayIndex(t, len(c)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = c[i]
+		default:
+			return nil, unfitPatch("no member %q of a scalar", t)
+		}
+	}
+	return doc, nil
+}
+
+// Returns index of array element referenced by token t, at most max.
+func arrayIndex(t string, max int) (int, error) {
+	i, err := strconv.Atoi(t)
+	if err != nil || i < 0 || (len(t) > 1 && t[0] == '0') {
+		return 0, badPatch("invalid array index %q", t)
+	}
+	if i > max {
+		return 0, unfitPatch("array index %d out of bounds", i)
+	}
+	return i, nil
+}
+
+// Function type of an operation on member key of container parent.
+// Returns the changed container.
+type leafOp func(parent interface{}, key string) (interface{}, error)
+
+// Parent passed to leafOp for an empty pointer, i.e. the whole document.
+type docRoot struct{}
+
+// Applies op to the member of doc at pointer tokens and returns
+// the changed doc. An empty pointer refers to doc itself.
+func patchAt(doc interface{}, tokens []string, op leafOp) (interface{}, error) {
+	if len(tokens) == 0 {
+		return op(docRoot{}, "")
+	}
+	if len(tokens) == 1 {
+		return op(doc, tokens[0])
+	}
+	child, err := pointerValue(doc, tokens[:1])
+	if err != nil {
+		return nil, err
+	}
+	if child, err = patchAt(child, tokens[1:], op); err != nil {
+		return nil, err
+	}
+	switch c := doc.(type) {
+	case map[string]interface{}:
+		c[tokens[0]] = child
+	case []interface{}:
+		i, _ := strconv.Atoi(tokens[0])
+		c[i] = child
+	}
+	return doc, nil
+}
+
+func addValue(value interface{}) leafOp {
+	return func(parent interface{}, key string) (interface{}, error) {
+		switch c := parent.(type) {
+		case docRoot:
+			return value, nil
+		case map[string]interface{}:
+			c[key] = value
+			return c, nil
+		case []interface{}:
+			if key == "-" {
+				return append(c, value), nil
+			}
+			i, err := arrayIndex(key, len(c))
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = value
+			return c, nil
+		}
+		return nil, unfitPatch("can't add member %q to a scalar", key)
+	}
+}
+
+func removeValue(parent interface{}, key string) (interface{}, error) {
+	switch c := parent.(type) {
+	case docRoot:
+		return nil, unfitPatch("can't remove the whole document")
+	case map[string]interface{}:
+		if _, ok := c[key]; !ok {
+			return nil, unfitPatch("no member %q", key)
+		}
+		delete(c, key)
+		return c, nil
+	case []interface{}:
+		i, err := arrayIndex(key, len(c)-1)
+		if err != nil {
+			return nil, err
+		}
+		return append(c[:i], c[i+1:]...), nil
+	}
+	return nil, unfitPatch("no member %q of a scalar", key)
+}
+
+func replaceValue(value interface{}) leafOp {
+	return func(parent interface{}, key string) (interface{}, error) {
+		if parent == (docRoot{}) {
+			return value, nil
+		}
+		if _, err := pointerValue(parent, []string{key}); err != nil {
+			return nil, err
+		}
+		if c, ok := parent.([]interface{}); ok {
+			i, _ := strconv.Atoi(key)
+			c[i] = value
+			return c, nil
+		}
+		parent.(map[string]interface{})[key] = value
+		return parent, nil
+	}
+}
+
+// Decodes JSON keeping numbers as json.Number, so they aren't changed
+// by a round trip.
+func decodeJSON(b []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return fmt.Errorf("unexpected data after JSON value")
+	}
+	return nil
+}
+
+func deepCopyJSON(v interface{}) interface{} {
+	switch c := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(c))
+		for k, e := range c {
+			m[k] = deepCopyJSON(e)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(c))
+		for i, e := range c {
+			s[i] = deepCopyJSON(e)
+		}
+		return s
+	}
+	return v
+}
+
+// Reports whether JSON values a and b are equal, comparing numbers
+// by value, e.g. 1 and 1.0 are equal.
+func equalJSON(a, b interface{}) bool {
+	switch x := a.(type) {
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			if w, ok := y[k]; !ok || !equalJSON(v, w) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equalJSON(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err1 := x.Float64()
+		g, err2 := y.Float64()
+		return err1 == nil && err2 == nil && f == g
+	}
+	return a == b
+}
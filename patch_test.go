@@ -0,0 +1,90 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+	doc := `{"name":"alex","tags":["a","b"],"address":{"city":"Berlin","zip":"10115"},"n":1}`
+	tests := []struct {
+		patch, result string
+		code          int
+	}{
+		{`[{"op":"replace","path":"/name","value":"sam"}]`,
+			`{"address":{"city":"Berlin","zip":"10115"},"n":1,"name":"sam","tags":["a","b"]}`, 0},
+		{`[{"op":"add","path":"/tags/1","value":"x"},{"op":"add","path":"/tags/-","value":"z"}]`,
+			`{"address":{"city":"Berlin","zip":"10115"},"n":1,"name":"alex","tags":["a","x","b","z"]}`, 0},
+		{`[{"op":"remove","path":"/address/zip"},{"op":"remove","path":"/tags/0"}]`,
+			`{"address":{"city":"Berlin"},"n":1,"name":"alex","tags":["b"]}`, 0},
+		{`[{"op":"move","from":"/address/city","path":"/city"},{"op":"copy","from":"/tags","path":"/labels"}]`,
+			`{"address":{"zip":"10115"},"city":"Berlin","labels":["a","b"],"n":1,"name":"alex","tags":["a","b"]}`, 0},
+		{`[{"op":"test","path":"/n","value":1.0},{"op":"replace","path":"/a~1b","value":1}]`, "", 422},
+		{`[{"op":"test","path":"/name","value":"sam"}]`, "", 409},
+		{`[{"op":"replace","path":"","value":[1]}]`, `[1]`, 0},
+		{`[{"op":"add","path":"/tags/3","value":"x"}]`, "", 422},
+		{`[{"op":"add","path":"/tags/01","value":"x"}]`, "", 400},
+		{`[{"op":"move","from":"/address","path":"/address/old"}]`, "", 400},
+		{`[{"op":"frobnicate","path":"/n"}]`, "", 400},
+		{`[{"op":"add","path":"/n"}]`, "", 400},
+		{`[{"op":"add","path":"/b","value":null}]`,
+			`{"address":{"city":"Berlin","zip":"10115"},"b":null,"n":1,"name":"alex","tags":["a","b"]}`, 0},
+		{`[{"op":"replace","path":"/n","value":null},{"op":"test","path":"/n","value":null}]`,
+			`{"address":{"city":"Berlin","zip":"10115"},"n":null,"name":"alex","tags":["a","b"]}`, 0},
+		{`[{"op":"test","path":"/n","value":null}]`, "", 409},
+		{`{"op":"add"}`, "", 400},
+	}
+	for i, test := range tests {
+		b, err := ApplyJSONPatch([]byte(doc), []byte(test.patch))
+		code := 0
+		if se, ok := err.(*StatusError); ok {
+			code = se.Code
+		} else if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		if code != test.code {
+			t.Errorf("%d: Expected code %d, got %d (%v)", i, test.code, code, err)
+		}
+		assertEqual(t, string(b), test.result)
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	b, err := ApplyMergePatch(
+		[]byte(`{"title":"Hello","author":{"name":"alex","email":"a@example.com"},"price":10.50}`),
+		[]byte(`{"title":"Hi","author":{"email":null},"tags":["x"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, string(b), `{"author":{"name":"alex"},"price":10.50,"tags":["x"],"title":"Hi"}`)
+}
+
+func TestPatchRequest(t *testing.T) {
+	doc := []byte(`{"a":1}`)
+	tests := []struct {
+		contentType, body, result string
+		code                      int
+	}{
+		{JSONPatchType, `[{"op":"add","path":"/b","value":2}]`, `{"a":1,"b":2}`, 0},
+		{MergePatchType + "; charset=utf-8", `{"a":null}`, `{}`, 0},
+		{"application/json", `{"a":2}`, "", 415},
+		{MergePatchType, `{"a":"` + strings.Repeat("x", 64) + `"}`, "", 413},
+	}
+	for i, test := range tests {
+		req, _ := http.NewRequest("PATCH", "/doc", strings.NewReader(test.body))
+		req.Header.Set("Content-Type", test.contentType)
+		b, err := PatchRequest(req, doc, 64)
+		code := 0
+		if se, ok := err.(*StatusError); ok {
+			code = se.Code
+		}
+		if code != test.code {
+			t.Errorf("%d: Expected code %d, got %d (%v)", i, test.code, code, err)
+		}
+		assertEqual(t, string(b), test.result)
+	}
+}
@@ -0,0 +1,144 @@
+package muxer
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+)
+
+// Pool is a named, bounded set of goroutines running handlers of routes
+// assigned to it with Route.RunOn(). It isolates CPU-heavy endpoints from
+// the goroutines serving connections:
+//
+//	heavy := m.Pool("heavy", runtime.NumCPU(), 100)
+//	m.Add("POST", "reports/{id}/render", renderReport).RunOn(heavy)
+//
+// Requests wait in a queue for a free worker. Requests which don't fit in
+// the queue get 503 Service Unavailable; queued requests whose context is
+// done are dropped without running the handler. Route middleware still runs
+// in the serving goroutine, only the handler runs on the pool.
+type Pool struct {
+	name string
+	jobs chan *poolJob
+	quit chan struct{}
+}
+
+type poolJob struct {
+	run  func()
+	done chan struct{}
+	// Value the handler panicked with, re-panicked in the serving goroutine.
+	panicked interface{}
+	// One of jobQueued, jobTaken or jobDropped.
+	state int32
+}
+
+const (
+	jobQueued int32 = iota
+	jobTaken
+	jobDropped
+)
+
+// Creates a new pool of workers goroutines with a queue of up to queueLen
+// requests. Panics if a pool with the same name already exists in this mux.
+func (dm *defaultMux) Pool(name string, workers, queueLen int) *Pool {
+	if _, exists := dm.pools[name]; exists {
+		panic(fmt.Sprintf("Pool '%s' already exists", name))
+	}
+	p := &Pool{
+		name: name,
+		jobs: make(chan *poolJob, queueLen),
+		quit: make(chan struct{}),
+	}
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	if dm.pools == nil {
+		dm.pools = make(map[string]*Pool)
+	}
+	dm.pools[name] = p
+	return p
+}
+
+// Returns name of this pool.
+func (p *Pool) Name() string {
+	return p.name
+}
+
+// Returns the number of requests waiting for a worker.
+func (p *Pool) Queued() int {
+	return len(p.jobs)
+}
+
+// Stops all workers once they finish their current job.
+// Requests to the pool's routes get 503 Service Unavailable afterwards.
+func (p *Pool) Close() {
+	close(p.quit)
+}
+
+func (p *Pool) work() {
+	for {
+		select {
+		case <-p.quit:
+			return
+		case job := <-p.jobs:
+			if atomic.CompareAndSwapInt32(&job.state, jobQueued, jobTaken) {
+				job.runSafely()
+			}
+		}
+	}
+}
+
+// Runs the job, keeping the worker alive if it panics.
+func (job *poolJob) runSafely() {
+	defer close(job.done)
+	defer func() {
+		job.panicked = recover()
+	}()
+	job.run()
+}
+
+// Re-panics in the serving goroutine if the handler of job panicked, so
+// that IsolatePanics() and the server see it.
+func (job *poolJob) finish() {
+	if job.panicked != nil {
+		panic(job.panicked)
+	}
+}
+
+// Returns h which runs on this pool.
+func (p *Pool) wrap(h HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		job := &poolJob{
+			run:  func() { h(w, r, v) },
+			done: make(chan struct{}),
+		}
+		select {
+		case <-p.quit:
+		case p.jobs <- job:
+			select {
+			case <-job.done:
+				job.finish()
+				return
+			case <-p.quit:
+			case <-r.Context().Done():
+			}
+			if !atomic.CompareAndSwapInt32(&job.state, jobQueued, jobDropped) {
+				// A worker is already running the job.
+				<-job.done
+				job.finish()
+				return
+			}
+		default:
+		}
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable),
+			http.StatusServiceUnavailable)
+	}
+}
+
+// Makes the handler of this route run on pool p.
+func (r *Route) RunOn(p *Pool) *Route {
+	r.pool = p
+	r.group.mux.handlersChanged()
+	return r
+}
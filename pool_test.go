@@ -0,0 +1,93 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPool(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	heavy := m.Pool("heavy", 1, 1)
+	defer heavy.Close()
+	assertEqual(t, heavy.Name(), "heavy")
+
+	unblock := make(chan struct{})
+	running := make(chan struct{}, 3)
+	m.Add("GET", "render/{id}", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		running <- struct{}{}
+		<-unblock
+		w.Write([]byte("rendered " + v.Get("id")))
+	}).RunOn(heavy)
+
+	var wg sync.WaitGroup
+	bodies := make([]string, 2)
+	for i, id := range []string{"1", "2"} {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			bodies[i] = serve(m, "GET", "/render/"+id).Body.String()
+		}(i, id)
+		if i == 0 {
+			<-running
+		}
+	}
+	for heavy.Queued() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	// worker is busy and queue is full
+	if w := serve(m, "GET", "/render/3"); w.Code != 503 {
+		t.Fatalf("Expected 503 for a full pool, got %d", w.Code)
+	}
+	close(unblock)
+	wg.Wait()
+	assertEqual(t, bodies[0], "rendered 1")
+	assertEqual(t, bodies[1], "rendered 2")
+
+	defer func() {
+		if err := recover(); err == nil {
+			t.Fatalf("Expected panic, got no error instead")
+		}
+	}()
+	// should panic because of the same pool name
+	m.Pool("heavy", 1, 1)
+}
+
+func TestPoolClose(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	p := m.Pool("p", 1, 1)
+	m.Add("GET", "x", dummy).RunOn(p)
+	assertEqual(t, serve(m, "GET", "/x").Body.String(), "params:")
+	p.Close()
+	if w := serve(m, "GET", "/x"); w.Code != 503 {
+		t.Fatalf("Expected 503 after Close, got %d", w.Code)
+	}
+}
+
+func TestPoolPanic(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	var reported []interface{}
+	m.IsolatePanics(10, time.Minute, func(r *http.Request, route *Route, p interface{}) {
+		reported = append(reported, p)
+	})
+	heavy := m.Pool("heavy", 1, 1)
+	defer heavy.Close()
+	m.Add("GET", "crash", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		panic("boom")
+	}).RunOn(heavy)
+	m.Add("GET", "ok", dummy).RunOn(heavy)
+
+	if w := serve(m, "GET", "/crash"); w.Code != http.StatusInternalServerError {
+		t.Errorf("code = %d, want 500", w.Code)
+	}
+	if len(reported) != 1 || reported[0] != "boom" {
+		t.Errorf("reported %v, want boom", reported)
+	}
+	// The worker survived.
+	assertEqual(t, serve(m, "GET", "/ok").Body.String(), "params:")
+}
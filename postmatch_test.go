@@ -0,0 +1,50 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+type userKey struct{}
+
+func TestPostMatch(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Use(func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+			w.Header().Set("X-Middleware", "ran")
+			next(w, r, v)
+		}
+	})
+	m.PostMatch(func(r *http.Request, route *Route, v url.Values) (*http.Request, int) {
+		if route.HasTag("admin") && r.Header.Get("X-User") != "root" {
+			return nil, http.StatusForbidden
+		}
+		return r.WithContext(context.WithValue(r.Context(), userKey{}, r.Header.Get("X-User"))), 0
+	})
+	h := func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		fmt.Fprintf(w, "%s:%s", r.Context().Value(userKey{}), v.Get("id"))
+	}
+	m.Add("GET", "users/{id}", h)
+	m.Add("DELETE", "users/{id}", h).Tag("admin")
+
+	req, _ := http.NewRequest("GET", "/users/1", nil)
+	req.Header.Set("X-User", "alex")
+	w := serveRequest(m, req)
+	assertEqual(t, w.Body.String(), "alex:1")
+	assertEqual(t, w.Header().Get("X-Middleware"), "ran")
+
+	req, _ = http.NewRequest("DELETE", "/users/1", nil)
+	req.Header.Set("X-User", "alex")
+	if w = serveRequest(m, req); w.Code != http.StatusForbidden {
+		t.Fatalf("Expected 403 Forbidden, got %d", w.Code)
+	}
+	assertEqual(t, w.Body.String(), "Forbidden\n")
+	req.Header.Set("X-User", "root")
+	assertEqual(t, serveRequest(m, req).Body.String(), "root:1")
+}
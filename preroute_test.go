@@ -0,0 +1,38 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPreRoute(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "users/{id}", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		fmt.Fprintf(w, "%s %s %s", v.Get("id"), r.Header.Get("X-Locale"), r.Host)
+	})
+	m.PreRoute(func(r *http.Request) *http.Request {
+		if rest, ok := strings.CutPrefix(r.URL.Path, "/de/"); ok {
+			r = r.Clone(r.Context())
+			r.URL.Path = "/" + rest
+			r.Header.Set("X-Locale", "de")
+		}
+		return r
+	})
+	m.PreRoute(func(r *http.Request) *http.Request {
+		if r.Host == "short.example" {
+			r.Host = "example.com"
+		}
+		return nil
+	})
+
+	req, _ := http.NewRequest("GET", "http://short.example/de/users/1", nil)
+	assertEqual(t, serveRequest(m, req).Body.String(), "1 de example.com")
+	assertEqual(t, req.URL.Path, "/de/users/1")
+	assertEqual(t, serve(m, "GET", "/users/2").Body.String(), "2  ")
+}
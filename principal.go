@@ -0,0 +1,82 @@
+package muxer
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+// Principal is an authenticated client of a request, attached to its
+// context by authentication middleware such as APIKeyAuth.
+type Principal struct {
+	// Who the client is, e.g. a user or service ID.
+	Subject string
+	// Granted scopes or permissions, if any.
+	Scopes []string
+	// Key for rate limiting and quota accounting. Defaults to Subject.
+	QuotaKey string
+}
+
+// Reports whether the principal has been granted scope.
+func (p *Principal) HasScope(scope string) bool {
+	return containsString(p.Scopes, scope)
+}
+
+type principalKey struct{}
+
+// Returns a copy of ctx carrying principal p.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// Returns the principal attached to ctx or nil.
+func PrincipalFrom(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// Returns the key rate limiting and quotas should account request r to:
+// quota key of its principal, if any, or the client IP address otherwise,
+// as seen through proxies trusted by the mux of the route, see ClientIP().
+func QuotaKey(r *http.Request) string {
+	if p := PrincipalFrom(r.Context()); p != nil {
+		if p.QuotaKey != "" {
+			return p.QuotaKey
+		}
+		return p.Subject
+	}
+	if route := CurrentRoute(r); route != nil && route.group != nil {
+		return route.group.mux.ClientIP(r)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Declares scopes a principal must have been granted to access this route.
+// Enforced by authentication middleware which knows about scopes, e.g.
+// the one of package oauth.
+func (r *Route) RequireScope(scopes ...string) *Route {
+	r.scopes = append(r.scopes, scopes...)
+	return r
+}
+
+// Returns scopes declared with RequireScope().
+func (r *Route) RequiredScopes() []string {
+	return r.scopes
+}
+
+// Returns required scopes of route r which principal p hasn't been granted.
+func MissingScopes(r *Route, p *Principal) (missing []string) {
+	if r == nil {
+		return nil
+	}
+	for _, s := range r.scopes {
+		if p == nil || !p.HasScope(s) {
+			missing = append(missing, s)
+		}
+	}
+	return
+}
@@ -0,0 +1,31 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestMissingScopes(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	r := m.Add("GET", "orders", dummy).RequireScope("orders:read", "orders:write")
+	tests := []struct {
+		p       *Principal
+		missing []string
+	}{
+		{nil, []string{"orders:read", "orders:write"}},
+		{&Principal{Scopes: []string{"orders:read"}}, []string{"orders:write"}},
+		{&Principal{Scopes: []string{"orders:write", "orders:read"}}, nil},
+	}
+	for i, test := range tests {
+		if missing := MissingScopes(r, test.p); !reflect.DeepEqual(missing, test.missing) {
+			t.Errorf("%d: Expected %v, got %v", i, test.missing, missing)
+		}
+	}
+	if missing := MissingScopes(nil, nil); missing != nil {
+		t.Errorf("Expected no scopes of nil route, got %v", missing)
+	}
+}
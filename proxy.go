@@ -0,0 +1,122 @@
+package muxer
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"path"
+	"strings"
+)
+
+// Makes this mux trust Forwarded and X-Forwarded-* headers of requests
+// coming from proxies with addresses in cidrs, e.g. "10.0.0.0/8" or
+// "127.0.0.1/32". Requests from other addresses can't fake their scheme,
+// host, prefix or client IP this way.
+// Panics if a CIDR is malformed.
+func (dm *defaultMux) TrustProxies(cidrs ...string) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, c := range cidrs {
+		p, err := netip.ParsePrefix(c)
+		if err != nil {
+			panic(fmt.Sprintf("Bad trusted proxy CIDR '%s': %v", c, err))
+		}
+		prefixes = append(prefixes, p.Masked())
+	}
+	dm.proxies = prefixes
+}
+
+// Reports whether req comes from a trusted proxy.
+func (dm *defaultMux) fromProxy(req *http.Request) bool {
+	if len(dm.proxies) == 0 {
+		return false
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	return dm.trustedProxy(addr.Unmap())
+}
+
+// Reports whether addr is an address of a trusted proxy.
+func (dm *defaultMux) trustedProxy(addr netip.Addr) bool {
+	for _, p := range dm.proxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns scheme of req as seen by the client: "https" or "http".
+// Forwarded or X-Forwarded-Proto is used for requests from trusted proxies.
+func (dm *defaultMux) scheme(req *http.Request) string {
+	if e, ok := dm.forwarded(req); ok && e.Proto != "" {
+		return e.Proto
+	}
+	if dm.fromProxy(req) {
+		if fp := lastForwarded(req.Header, "X-Forwarded-Proto"); fp != "" {
+			return strings.ToLower(fp)
+		}
+	}
+	if req.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// Returns the last value of comma separated header k, the one added by the
+// nearest proxy. Values before it can be sent by the client.
+func lastForwarded(h http.Header, k string) string {
+	vs := h.Values(k)
+	if len(vs) == 0 {
+		return ""
+	}
+	v := vs[len(vs)-1]
+	if i := strings.LastIndexByte(v, ','); i >= 0 {
+		v = v[i+1:]
+	}
+	return strings.TrimSpace(v)
+}
+
+// Sets the path prefix this mux is mounted under by a reverse proxy
+// stripping it, e.g. "/shop" for a proxy forwarding /shop/api/... as
+// /api/..., so that BuildPath() and redirects produce paths clients can
+// use. X-Forwarded-Prefix of requests from trusted proxies, see
+// TrustProxies(), overrides it in redirects and BuildPathFor().
+func (dm *defaultMux) ExternalPrefix(prefix string) {
+	dm.prefix = cleanPrefix(prefix)
+}
+
+// Same as BuildPath() with the external prefix of req, see ExternalPrefix().
+func (dm *defaultMux) BuildPathFor(req *http.Request, name string, params ...interface{}) string {
+	return dm.buildPath(dm.externalPrefix(req), name, params)
+}
+
+// Returns the path prefix req was sent to by the client, stripped by
+// a proxy: X-Forwarded-Prefix set by the nearest trusted proxy or the
+// configured one.
+func (dm *defaultMux) externalPrefix(req *http.Request) string {
+	if dm.fromProxy(req) {
+		if fp := lastForwarded(req.Header, "X-Forwarded-Prefix"); fp != "" {
+			return cleanPrefix(fp)
+		}
+	}
+	return dm.prefix
+}
+
+// Returns prefix starting with "/" without trailing ones, "" for the root.
+func cleanPrefix(prefix string) string {
+	if prefix == "" {
+		return ""
+	}
+	prefix = path.Clean("/" + prefix)
+	if prefix == "/" {
+		return ""
+	}
+	return prefix
+}
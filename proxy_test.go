@@ -0,0 +1,112 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestExternalPrefix(t *testing.T) {
+	m := NewMux("/api", http.NewServeMux())
+	m.TrustProxies("10.0.0.0/8")
+	m.Add("GET", "users/{id}", dummy).As("user")
+	m.Group("pages").CasePolicy(CaseRedirect).Add("GET", "About", dummy)
+	assertEqual(t, m.BuildPath("user", 1), "/api/users/1")
+
+	m.ExternalPrefix("shop/")
+	assertEqual(t, m.BuildPath("user", 1), "/shop/api/users/1")
+
+	req, _ := http.NewRequest("GET", "/api/pages/about", nil)
+	req.RemoteAddr = "10.1.2.3:4567"
+	assertEqual(t, m.BuildPathFor(req, "user", 2), "/shop/api/users/2")
+	assertEqual(t, serveRequest(m, req).Header().Get("Location"), "/shop/api/pages/About")
+
+	req.Header.Set("X-Forwarded-Prefix", "/evil, /store/")
+	assertEqual(t, m.BuildPathFor(req, "user", 3), "/store/api/users/3")
+	assertEqual(t, serveRequest(m, req).Header().Get("Location"), "/store/api/pages/About")
+
+	// Untrusted clients can't change it.
+	req.RemoteAddr = "192.0.2.1:4567"
+	assertEqual(t, m.BuildPathFor(req, "user", 4), "/shop/api/users/4")
+}
+
+func TestParseForwarded(t *testing.T) {
+	elems, err := ParseForwarded([]string{
+		`for=192.0.2.60;proto=HTTP;by=203.0.113.43, For="[2001:db8:cafe::17]:4711"`,
+		`for=10.0.0.1;host="example.com";proto=https`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ForwardedElement{
+		{For: "192.0.2.60", By: "203.0.113.43", Proto: "http"},
+		{For: "[2001:db8:cafe::17]:4711"},
+		{For: "10.0.0.1", Host: "example.com", Proto: "https"},
+	}
+	if len(elems) != len(want) {
+		t.Fatalf("got %+v, want %+v", elems, want)
+	}
+	for i := range want {
+		if elems[i] != want[i] {
+			t.Errorf("elems[%d] = %+v, want %+v", i, elems[i], want[i])
+		}
+	}
+	if addr, ok := elems[1].ForAddr(); !ok || addr.String() != "2001:db8:cafe::17" {
+		t.Errorf("ForAddr = %v, %v", addr, ok)
+	}
+	for _, v := range []string{`for`, `for="192.0.2.1`, `for=1 2`} {
+		if _, err := ParseForwarded([]string{v}); err == nil {
+			t.Errorf("ParseForwarded(%q) returned no error", v)
+		}
+	}
+}
+
+func TestForwarded(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.TrustProxies("10.0.0.0/8")
+	m.Add("GET", "users/{id}", dummy).As("user")
+	req, _ := http.NewRequest("GET", "/users/1", nil)
+	req.Host = "backend:8080"
+	req.RemoteAddr = "10.0.0.2:4567"
+	assertEqual(t, m.ClientIP(req), "10.0.0.2")
+	assertEqual(t, m.BuildURL(req, "user", 1), "http://backend:8080/users/1")
+
+	// The client can't fake the first element, the last untrusted one is used.
+	req.Header.Add("Forwarded", `for=198.51.100.1;host=evil.example;proto=http`)
+	req.Header.Add("Forwarded", `for=192.0.2.60;host=example.com;proto=https, for=10.0.0.1`)
+	assertEqual(t, m.ClientIP(req), "192.0.2.60")
+	assertEqual(t, m.BuildURL(req, "user", 2), "https://example.com/users/2")
+
+	// Forwarded takes precedence over X-Forwarded-*.
+	req.Header.Set("X-Forwarded-For", "192.0.2.99")
+	req.Header.Set("X-Forwarded-Proto", "http")
+	assertEqual(t, m.ClientIP(req), "192.0.2.60")
+	assertEqual(t, m.BuildURL(req, "user", 3), "https://example.com/users/3")
+
+	req.Header.Del("Forwarded")
+	req.Header.Set("X-Forwarded-For", "198.51.100.1, 192.0.2.99, 10.0.0.1")
+	assertEqual(t, m.ClientIP(req), "192.0.2.99")
+	assertEqual(t, m.BuildURL(req, "user", 4), "http://backend:8080/users/4")
+
+	// Headers of untrusted clients are ignored.
+	req.RemoteAddr = "192.0.2.1:4567"
+	req.Header.Set("Forwarded", `for=10.1.1.1;host=example.com;proto=https`)
+	assertEqual(t, m.ClientIP(req), "192.0.2.1")
+	assertEqual(t, m.BuildURL(req, "user", 5), "http://backend:8080/users/5")
+}
+
+func TestForwardedCanonical(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.TrustProxies("10.0.0.0/8")
+	m.Canonicalize(&Canonical{Host: "example.com", HTTPS: true})
+	m.Add("GET", "a", dummy)
+	req, _ := http.NewRequest("GET", "/a", nil)
+	req.Host = "backend"
+	req.RemoteAddr = "10.0.0.2:4567"
+	req.Header.Set("Forwarded", `for=192.0.2.60;host=example.com;proto=https`)
+	assertEqual(t, serveRequest(m, req).Body.String(), "params:")
+	req.Header.Set("Forwarded", `for=192.0.2.60;host=example.com;proto=http`)
+	assertEqual(t, serveRequest(m, req).Header().Get("Location"), "https://example.com/a")
+}
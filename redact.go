@@ -0,0 +1,146 @@
+package muxer
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Replacement of redacted values.
+const redacted = "REDACTED"
+
+// Redaction lists names of sensitive values kept out of requests as
+// recorded by a mux: URIs of samples and captured bodies, and examples,
+// see Mux.Redact(). Names are glob patterns as of path.Match(), matched
+// case-insensitively, e.g. "*token*" or "X-*-Key".
+type Redaction struct {
+	// URL path params, query params and JSON fields of recorded bodies.
+	Params []string
+	// Header names.
+	Headers []string
+}
+
+// Sets the redaction policy of this mux, applied to everything it records
+// about requests. Handlers and hooks, e.g. logging middleware, apply the
+// same one via Route.RedactedURI() and Redaction methods.
+func (dm *defaultMux) Redact(r Redaction) {
+	dm.redaction = &r
+}
+
+// Returns the redaction policy set with Redact(), nil if none.
+func (dm *defaultMux) Redaction() *Redaction {
+	return dm.redaction
+}
+
+// Reports whether param name is sensitive.
+func (r *Redaction) SensitiveParam(name string) bool {
+	return r != nil && globMatch(r.Params, name)
+}
+
+// Reports whether header name is sensitive.
+func (r *Redaction) SensitiveHeader(name string) bool {
+	return r != nil && globMatch(r.Headers, name)
+}
+
+// Returns a copy of header h with sensitive values replaced.
+func (r *Redaction) Header(h http.Header) http.Header {
+	c := h.Clone()
+	for name, vs := range c {
+		if r.SensitiveHeader(name) {
+			c[name] = redactAll(vs)
+		}
+	}
+	return c
+}
+
+// Returns a copy of values v with sensitive params replaced.
+func (r *Redaction) Values(v url.Values) url.Values {
+	c := make(url.Values, len(v))
+	for name, vs := range v {
+		if r.SensitiveParam(name) {
+			c[name] = redactAll(vs)
+		} else {
+			c[name] = append([]string(nil), vs...)
+		}
+	}
+	return c
+}
+
+func redactAll(vs []string) []string {
+	out := make([]string, len(vs))
+	for i := range out {
+		out[i] = redacted
+	}
+	return out
+}
+
+// Reports whether name matches one of patterns.
+func globMatch(patterns []string, name string) bool {
+	name = strings.ToLower(name)
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.ToLower(p), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns path and query of req, a request matched by this route, with
+// values of sensitive path and query params replaced as of the redaction
+// policy of the mux, see Mux.Redact().
+func (r *Route) RedactedURI(req *http.Request) string {
+	rd := r.group.mux.redaction
+	if rd == nil {
+		return req.URL.RequestURI()
+	}
+	u := *req.URL
+	u.RawPath = ""
+	u.Path = r.redactPath(req.URL.Path)
+	if u.RawQuery != "" {
+		u.RawQuery = redactQuery(rd, u.RawQuery)
+	}
+	return u.RequestURI()
+}
+
+// Returns URL path p of a request matched by this route with sensitive
+// path params replaced.
+func (r *Route) redactPath(p string) string {
+	rd := r.group.mux.redaction
+	n := len(r.parts)
+	segs := strings.Split(p, "/")
+	if len(segs) < n {
+		return p
+	}
+	// Params are the trailing segments, after the base path.
+	off := len(segs) - n
+	if r.parts[n-1].rest {
+		_, rel, ok := r.group.mux.splitBase(p, true)
+		if !ok {
+			return p
+		}
+		off = len(segs) - countSegments(rel)
+	}
+	for i, rp := range r.parts {
+		if rp.rest && rd.SensitiveParam(rp.name) {
+			segs = append(segs[:off+i], redacted)
+			break
+		}
+		if rp.isVar && rd.SensitiveParam(rp.name) {
+			segs[off+i] = redacted
+		}
+	}
+	return strings.Join(segs, "/")
+}
+
+// Returns raw query q with sensitive params replaced, keeping the order.
+func redactQuery(rd *Redaction, q string) string {
+	parts := strings.Split(q, "&")
+	for i, part := range parts {
+		key, _, hasValue := strings.Cut(part, "=")
+		if name, err := url.QueryUnescape(key); err == nil && hasValue && rd.SensitiveParam(name) {
+			parts[i] = key + "=" + redacted
+		}
+	}
+	return strings.Join(parts, "&")
+}
@@ -0,0 +1,36 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRedactedURI(t *testing.T) {
+	m := NewMux("/api/", http.NewServeMux())
+	m.Redact(Redaction{Params: []string{"token", "*_KEY"}, Headers: []string{"Authorization", "x-*-secret"}})
+	var uri string
+	r := m.Add("POST", "reset/{token}/{step}", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		uri = CurrentRoute(r).RedactedURI(r)
+		io.ReadAll(r.Body)
+	}).CaptureBodies(CaptureOptions{})
+
+	req, _ := http.NewRequest("POST", "/api/reset/s3cr3t/2?api_key=k&x=1&token", strings.NewReader(`{"token":"t","n":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	serveRequest(m, req)
+	assertEqual(t, uri, "/api/reset/REDACTED/2?api_key=REDACTED&x=1&token")
+	c := r.Captures()[0]
+	assertEqual(t, c.URI, uri)
+	assertEqual(t, string(c.Body), `{"n":1,"token":"REDACTED"}`)
+
+	rd := m.Redaction()
+	h := rd.Header(http.Header{"Authorization": {"Bearer x"}, "X-Api-Secret": {"y"}, "Accept": {"*/*"}})
+	assertEqual(t, h.Get("Authorization")+" "+h.Get("X-Api-Secret")+" "+h.Get("Accept"), "REDACTED REDACTED */*")
+	v := rd.Values(url.Values{"token": {"a", "b"}, "q": {"c"}})
+	assertEqual(t, v.Encode(), "q=c&token=REDACTED&token=REDACTED")
+}
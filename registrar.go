@@ -0,0 +1,31 @@
+package muxer
+
+// Registrar is implemented by feature packages contributing routes,
+// route names and middleware to a mux:
+//
+//	type Blog struct{}
+//
+//	func (Blog) RegisterRoutes(m muxer.Mux) {
+//		m.Add("GET", "blog/{id}", showPost).As("post")
+//	}
+//
+//	m.Register(Blog{}, Shop{})
+type Registrar interface {
+	RegisterRoutes(m Mux)
+}
+
+// Calls RegisterRoutes of each registrar in order. Routes added by
+// a registrar have its Route.Registrar field set.
+func (dm *defaultMux) Register(rs ...Registrar) {
+	for _, r := range rs {
+		dm.registering = r
+		r.RegisterRoutes(dm)
+		dm.registering = nil
+		dm.registrars = append(dm.registrars, r)
+	}
+}
+
+// Returns all registrars in the order they were registered.
+func (dm *defaultMux) Registrars() []Registrar {
+	return dm.registrars
+}
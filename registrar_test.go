@@ -0,0 +1,56 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+type blogRegistrar struct{}
+
+func (blogRegistrar) RegisterRoutes(m Mux) {
+	m.Add("GET", "blog/{id}", dummy).As("post")
+}
+
+func tagMiddleware(tag string) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+			fmt.Fprintf(w, "%s(", tag)
+			next(w, r, v)
+			fmt.Fprint(w, ")")
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "users/{id}", dummy)
+	blog := blogRegistrar{}
+	m.Register(blog)
+
+	routes := m.Routes()
+	if routes[0].Registrar != nil {
+		t.Fatalf("Expected no registrar, got %v", routes[0].Registrar)
+	}
+	if routes[1].Registrar != blog {
+		t.Fatalf("Expected blog registrar, got %v", routes[1].Registrar)
+	}
+	if rs := m.Registrars(); len(rs) != 1 || rs[0] != blog {
+		t.Fatalf("Expected [blog] registrars, got %v", rs)
+	}
+	assertEqual(t, m.BuildPath("post", 1), "/blog/1")
+}
+
+func TestMiddleware(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Use(tagMiddleware("a"), tagMiddleware("b"))
+	m.Group("g").Use(tagMiddleware("c")).Add("GET", "{id}", dummy)
+	m.Add("GET", "x", dummy)
+
+	assertEqual(t, serve(m, "GET", "/g/1").Body.String(), "a(b(c(params:id=1)))")
+	assertEqual(t, serve(m, "GET", "/x").Body.String(), "a(b(params:))")
+}
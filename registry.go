@@ -0,0 +1,63 @@
+package muxer
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Muxes registered with Register() by name.
+var registry = struct {
+	sync.RWMutex
+	muxes map[string]Mux
+}{muxes: make(map[string]Mux)}
+
+// Registers m under name for the process, so that packages of a service
+// composed of several muxes can link to routes of each other with
+// BuildPath() without importing one another:
+//
+//	muxer.Register("billing", billingMux)
+//	// Elsewhere:
+//	link := muxer.BuildPath("billing:invoice", id)
+//
+// Panics if name is empty, contains ":" or is registered already.
+func Register(name string, m Mux) {
+	if name == "" || strings.Contains(name, ":") {
+		panic(fmt.Sprintf("Invalid mux name '%s'", name))
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.muxes[name]; ok {
+		panic(fmt.Sprintf("Mux '%s' is registered already", name))
+	}
+	registry.muxes[name] = m
+}
+
+// Removes the mux registered under name, if any, e.g. in tests.
+func Unregister(name string) {
+	registry.Lock()
+	defer registry.Unlock()
+	delete(registry.muxes, name)
+}
+
+// Returns the mux registered under name, or nil.
+func Registered(name string) Mux {
+	registry.RLock()
+	defer registry.RUnlock()
+	return registry.muxes[name]
+}
+
+// Generates a path of a route of a registered mux, referenced as
+// "mux:route", see Register() and Mux.BuildPath(). Panics if there's no
+// such mux or route.
+func BuildPath(ref string, params ...interface{}) string {
+	name, route, ok := strings.Cut(ref, ":")
+	if !ok {
+		panic(fmt.Sprintf("Route reference '%s' isn't in the form mux:route", ref))
+	}
+	m := Registered(name)
+	if m == nil {
+		panic(fmt.Sprintf("Mux '%s' isn't registered", name))
+	}
+	return m.BuildPath(route, params...)
+}
@@ -0,0 +1,43 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	billing := NewMux("/billing", http.NewServeMux())
+	billing.Add("GET", "invoices/{id}", dummy).As("invoice")
+	shop := NewMux("/", http.NewServeMux())
+	shop.Add("GET", "products/{id}", dummy).As("product")
+	Register("billing", billing)
+	Register("shop", shop)
+	defer Unregister("billing")
+	defer Unregister("shop")
+
+	assertEqual(t, BuildPath("billing:invoice", 7), "/billing/invoices/7")
+	assertEqual(t, BuildPath("shop:product", "x"), "/products/x")
+	if Registered("billing") != billing || Registered("none") != nil {
+		t.Error("Registered() returned a wrong mux")
+	}
+
+	for _, f := range []func(){
+		func() { Register("billing", shop) },
+		func() { Register("a:b", shop) },
+		func() { BuildPath("invoice") },
+		func() { BuildPath("none:invoice") },
+		func() { BuildPath("billing:none") },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("Expected a panic")
+				}
+			}()
+			f()
+		}()
+	}
+}
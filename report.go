@@ -0,0 +1,153 @@
+package muxer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Description of a route suitable for reports and JSON dumps.
+type RouteInfo struct {
+	Method    string   `json:"method"`
+	Path      string   `json:"path"`
+	Name      string   `json:"name,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	Registrar string   `json:"registrar,omitempty"`
+	Policy    string   `json:"policy,omitempty"`
+	// Deprecation details, see Route.Deprecated().
+	Deprecated bool       `json:"deprecated,omitempty"`
+	Sunset     *time.Time `json:"sunset,omitempty"`
+	Successor  string     `json:"successor,omitempty"`
+	// See Route.Labels() and Route.OwnedBy().
+	Labels map[string]string `json:"labels,omitempty"`
+	Owner  string            `json:"owner,omitempty"`
+}
+
+// Returns name column of text and markdown reports.
+func (i RouteInfo) displayName() string {
+	if !i.Deprecated {
+		return i.Name
+	}
+	s := i.Name + " (deprecated"
+	if i.Sunset != nil {
+		s += ", sunset " + i.Sunset.Format("2006-01-02")
+	}
+	if i.Successor != "" {
+		s += ", use " + i.Successor
+	}
+	return strings.TrimPrefix(s+")", " ")
+}
+
+// Returns descriptions of all routes of m. Path is the route pattern
+// prefixed with the mux base path.
+func RouteInfos(m Mux) []RouteInfo {
+	routes := m.Routes()
+	infos := make([]RouteInfo, 0, len(routes))
+	for _, r := range routes {
+		info := RouteInfo{
+			Method: r.Method,
+			Path:   m.BasePath() + r.Pattern,
+			Name:   r.Name,
+			Tags:   r.Tags,
+			Policy: r.policy,
+			Labels: r.Labels(),
+			Owner:  r.OwnedBy(),
+		}
+		if r.Registrar != nil {
+			info.Registrar = fmt.Sprintf("%T", r.Registrar)
+		}
+		if d := r.deprecation; d != nil {
+			info.Deprecated = true
+			info.Successor = d.successor
+			if !d.sunset.IsZero() {
+				sunset := d.sunset
+				info.Sunset = &sunset
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// Options of a route report. See Report().
+type ReportOptions struct {
+	// One of "text" (default), "json" or "markdown".
+	Format string
+	// Sort by "method", "path" or "name". Keeps routes order if empty.
+	SortBy string
+	// Include only routes with this method, if set.
+	Method string
+	// Include only routes tagged with this tag, if set.
+	Tag string
+}
+
+// Report writes the route table described by infos to w.
+func Report(w io.Writer, infos []RouteInfo, opts ReportOptions) error {
+	filtered := make([]RouteInfo, 0, len(infos))
+	for _, info := range infos {
+		if opts.Method != "" && !strings.EqualFold(info.Method, opts.Method) {
+			continue
+		}
+		if opts.Tag != "" && !containsString(info.Tags, opts.Tag) {
+			continue
+		}
+		filtered = append(filtered, info)
+	}
+	var key func(RouteInfo) string
+	switch opts.SortBy {
+	case "":
+	case "method":
+		key = func(i RouteInfo) string { return i.Method + " " + i.Path }
+	case "path":
+		key = func(i RouteInfo) string { return i.Path + " " + i.Method }
+	case "name":
+		key = func(i RouteInfo) string { return i.Name }
+	default:
+		return fmt.Errorf("muxer: unknown sort key %q", opts.SortBy)
+	}
+	if key != nil {
+		sort.SliceStable(filtered, func(i, j int) bool {
+			return key(filtered[i]) < key(filtered[j])
+		})
+	}
+
+	switch opts.Format {
+	case "", "text":
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "METHOD\tPATH\tNAME\tTAGS\tREGISTRAR")
+		for _, i := range filtered {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+				i.Method, i.Path, i.displayName(), strings.Join(i.Tags, ","), i.Registrar)
+		}
+		return tw.Flush()
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(filtered)
+	case "markdown":
+		fmt.Fprintln(w, "| Method | Path | Name | Tags | Registrar |")
+		fmt.Fprintln(w, "|--------|------|------|------|-----------|")
+		for _, i := range filtered {
+			_, err := fmt.Fprintf(w, "| %s | `%s` | %s | %s | %s |\n",
+				i.Method, i.Path, i.displayName(), strings.Join(i.Tags, ", "), i.Registrar)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("muxer: unknown report format %q", opts.Format)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	m := NewMux("/api", http.NewServeMux())
+	m.Add("GET", "users/{id}", dummy).As("profile").Tag("public")
+	m.Add("DELETE", "users/{id}", dummy).Tag("admin")
+	m.Register(blogRegistrar{})
+
+	tests := []struct {
+		opts     ReportOptions
+		expected string
+	}{
+		{ReportOptions{}, `METHOD  PATH             NAME     TAGS    REGISTRAR
+GET     /api/users/{id}  profile  public  
+DELETE  /api/users/{id}           admin   
+GET     /api/blog/{id}   post             muxer.blogRegistrar
+`},
+		{ReportOptions{Format: "markdown", SortBy: "method", Tag: "admin"},
+			"| Method | Path | Name | Tags | Registrar |\n" +
+				"|--------|------|------|------|-----------|\n" +
+				"| DELETE | `/api/users/{id}` |  | admin |  |\n"},
+		{ReportOptions{Format: "json", Method: "get", SortBy: "name"}, `[
+  {
+    "method": "GET",
+    "path": "/api/blog/{id}",
+    "name": "post",
+    "registrar": "muxer.blogRegistrar"
+  },
+  {
+    "method": "GET",
+    "path": "/api/users/{id}",
+    "name": "profile",
+    "tags": [
+      "public"
+    ]
+  }
+]
+`},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := Report(&buf, RouteInfos(m), test.opts); err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, buf.String(), test.expected)
+	}
+	if err := Report(&bytes.Buffer{}, nil, ReportOptions{Format: "xml"}); err == nil {
+		t.Fatalf("Expected unknown format error")
+	}
+}
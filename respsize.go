@@ -0,0 +1,57 @@
+package muxer
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+)
+
+// Limits responses of this route to n bytes of body, catching accidental
+// unbounded ones, e.g. a listing missing a LIMIT clause, before they
+// saturate egress. A handler writing more is aborted: the write panics
+// with http.ErrAbortHandler, so the server drops the connection and the
+// client doesn't mistake the truncated body for a complete one. Each abort
+// is logged. Panics if n isn't positive.
+func (r *Route) MaxResponseBytes(n int64) *Route {
+	if n <= 0 {
+		panic(fmt.Sprintf("Route '%s %s': response limit must be positive", r.Method, r.Pattern))
+	}
+	r.maxResponse = n
+	return r
+}
+
+// Returns h serving route r with the response size limited.
+func limitResponse(r *Route, h HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request, v url.Values) {
+		h(&limitedWriter{ResponseWriter: w, route: r, left: r.maxResponse}, req, v)
+	}
+}
+
+// Aborts the handler writing more than left bytes.
+type limitedWriter struct {
+	http.ResponseWriter
+	route *Route
+	left  int64
+}
+
+func (w *limitedWriter) Write(b []byte) (int, error) {
+	if int64(len(b)) > w.left {
+		r := w.route
+		log.Printf("muxer: response of route %s %s exceeds %d bytes, aborted",
+			r.Method, r.Pattern, r.maxResponse)
+		panic(http.ErrAbortHandler)
+	}
+	w.left -= int64(len(b))
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *limitedWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *limitedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
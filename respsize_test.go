@@ -0,0 +1,29 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestMaxResponseBytes(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "list/{n}", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		for i := 0; i < len(v.Get("n")); i++ {
+			w.Write([]byte("0123456789"))
+		}
+	}).MaxResponseBytes(20)
+
+	assertEqual(t, serve(m, "GET", "/list/xx").Body.String(), strings.Repeat("0123456789", 2))
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler", p)
+		}
+	}()
+	serve(m, "GET", "/list/xxx")
+	t.Error("response over the limit not aborted")
+}
@@ -0,0 +1,101 @@
+package muxer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Rules served by RobotsTxt("") which suit API-only muxers.
+const DisallowAll = "User-agent: *\nDisallow: /\n"
+
+// Panics unless handlers of path can be registered at the host root, which
+// a mux with a base path other than "/" needs an http.ServeMux for.
+func (dm *defaultMux) checkRootAccess(path string) {
+	if dm.httpMux == nil {
+		panic(fmt.Sprintf("Mux at '%s' can't serve %s without an http.ServeMux", dm.base, path))
+	}
+}
+
+// Serves /robots.txt with provided rules, or DisallowAll if rules is zero
+// string. Responses can be cached for a day.
+//
+// If the mux base path is "/", robots.txt is added as a regular route.
+// Otherwise, it is registered directly with the http.ServeMux this mux
+// is hooked up with, since crawlers only look for it at the host root, and
+// panics if there's none.
+func (dm *defaultMux) RobotsTxt(rules string) {
+	if rules == "" {
+		rules = DisallowAll
+	}
+	dm.addRootFile("robots.txt", []byte(rules), "", 24*time.Hour)
+}
+
+// Serves /favicon.ico. The icon is either []byte with its content or
+// an fs.FS containing "favicon.ico" file, which is read once right away.
+// If icon is nil, requests get 204 No Content, which keeps browsers from
+// asking again for icons of API-only hosts. Responses can be cached for
+// a week. See RobotsTxt() for where the route is registered.
+func (dm *defaultMux) Favicon(icon interface{}) {
+	var content []byte
+	switch v := icon.(type) {
+	case nil:
+	case []byte:
+		content = v
+	case fs.FS:
+		b, err := fs.ReadFile(v, "favicon.ico")
+		if err != nil {
+			panic(fmt.Sprintf("Can't read favicon: %v", err))
+		}
+		content = b
+	default:
+		panic(fmt.Sprintf("Favicon must be []byte or fs.FS, got %T", icon))
+	}
+	dm.addRootFile("favicon.ico", content, "", 7*24*time.Hour)
+}
+
+// Registers a handler serving content of a static file at the host root.
+// The content type is detected from name and content if contentType
+// is empty.
+func (dm *defaultMux) addRootFile(name string, content []byte, contentType string, maxAge time.Duration) {
+	sum := sha256.Sum256(content)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	cacheControl := fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
+	h := func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		w.Header().Set("Cache-Control", cacheControl)
+		if content == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		if contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(content))
+	}
+	dm.addRootHandler(name, h)
+}
+
+// Registers h serving GET requests of path name at the host root. Panics
+// if the base path isn't "/" and there's no http.ServeMux to register it
+// with, e.g. in a clone.
+func (dm *defaultMux) addRootHandler(name string, h HandlerFunc) {
+	if dm.base == "/" {
+		dm.Add("GET", name, h)
+		return
+	}
+	dm.checkRootAccess("/" + name)
+	dm.httpMux.HandleFunc("/"+name, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" && r.Method != "HEAD" {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h(w, r, nil)
+	})
+}
@@ -0,0 +1,62 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRobotsTxt(t *testing.T) {
+	h := http.NewServeMux()
+	m := NewMux("/api", h)
+	m.RobotsTxt("")
+	w := serve(h, "GET", "/robots.txt")
+	if w.Code != 200 {
+		t.Fatalf("Expected 200 OK, got %d", w.Code)
+	}
+	assertEqual(t, w.Body.String(), DisallowAll)
+	assertEqual(t, w.Header().Get("Cache-Control"), "public, max-age=86400")
+	assertEqual(t, w.Header().Get("Content-Type"), "text/plain; charset=utf-8")
+	if len(m.Routes()) != 0 {
+		t.Fatalf("Expected robots.txt outside of the route table")
+	}
+	if w = serve(h, "POST", "/robots.txt"); w.Code != 405 {
+		t.Fatalf("Expected 405 Method Not Allowed, got %d", w.Code)
+	}
+
+	root := NewMux("/", http.NewServeMux())
+	root.RobotsTxt("User-agent: *\nAllow: /\n")
+	assertEqual(t, root.Routes()[0].Pattern, "robots.txt")
+	assertEqual(t, serve(root, "GET", "/robots.txt").Body.String(), "User-agent: *\nAllow: /\n")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("RobotsTxt() of a clone at /api didn't panic")
+		}
+	}()
+	m.Clone().RobotsTxt("")
+}
+
+func TestFavicon(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Favicon(fstest.MapFS{"favicon.ico": {Data: []byte("icon")}})
+	w := serve(m, "GET", "/favicon.ico")
+	assertEqual(t, w.Body.String(), "icon")
+	assertEqual(t, w.Header().Get("Content-Type"), "image/vnd.microsoft.icon")
+
+	req, _ := http.NewRequest("GET", "/favicon.ico", nil)
+	req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+	if w = serveRequest(m, req); w.Code != http.StatusNotModified {
+		t.Fatalf("Expected 304 Not Modified, got %d", w.Code)
+	}
+
+	h := http.NewServeMux()
+	NewMux("/api", h).Favicon(nil)
+	w = serve(h, "GET", "/favicon.ico")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204 No Content, got %d", w.Code)
+	}
+}
@@ -0,0 +1,124 @@
+package muxer
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+// Timing of a phase of a sampled request, see StartPhase().
+type Phase struct {
+	Name string
+	// Since the start of the request.
+	Offset   time.Duration
+	Duration time.Duration
+}
+
+// Timings of a sampled request, see Route.Sample().
+type Sample struct {
+	Route *Route
+	// Request URL path and query, redacted, see Mux.Redact().
+	URI   string
+	Start time.Time
+	// Total time spent in middleware and the handler.
+	Duration time.Duration
+	// Phases in the order they ended. The "handler" phase covers the route
+	// handler alone, without middleware.
+	Phases []Phase
+	// Captured request body, if the route captures them, see
+	// Route.CaptureBodies().
+	Body *Capture
+}
+
+// Function type receiving sampled requests, called after the response
+// has been written.
+type SampleFunc func(s *Sample)
+
+type sampler struct {
+	fraction float64
+	report   SampleFunc
+}
+
+type sampleKey struct{}
+
+// Value of sampleKey in a request context.
+type sampleRecorder struct {
+	mu     sync.Mutex
+	sample Sample
+}
+
+// Samples fraction of requests to this route, between 0 and 1, and passes
+// their timings to f. Sampled requests run with the pprof label "route"
+// set to the route name, or its method and pattern, so CPU and goroutine
+// profiles can be broken down by route:
+//
+//	m.Add("GET", "search", search).As("search").Sample(0.01, func(s *muxer.Sample) {
+//		log.Printf("%s %v %+v", s.URI, s.Duration, s.Phases)
+//	})
+//
+// Handlers time their own phases with StartPhase(). Unsampled requests pay
+// only for a random number.
+func (r *Route) Sample(fraction float64, f SampleFunc) *Route {
+	if fraction < 0 || fraction > 1 {
+		panic("Sample fraction must be between 0 and 1")
+	}
+	r.sampler = &sampler{fraction, f}
+	r.group.mux.handlersChanged()
+	return r
+}
+
+// Starts timing phase name of the request with context ctx, e.g. a database
+// query, and returns a function ending it. Does nothing unless the request
+// is sampled, see Route.Sample():
+//
+//	defer muxer.StartPhase(r.Context(), "db")()
+func StartPhase(ctx context.Context, name string) (end func()) {
+	rec, ok := ctx.Value(sampleKey{}).(*sampleRecorder)
+	if !ok {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		rec.sample.Phases = append(rec.sample.Phases, Phase{
+			Name:     name,
+			Offset:   start.Sub(rec.sample.Start),
+			Duration: time.Since(start),
+		})
+	}
+}
+
+// Reports whether to sample a request.
+func (s *sampler) sample() bool {
+	return s.fraction > 0 && rand.Float64() < s.fraction
+}
+
+// Serves req with h as a sampled request of route r.
+func (s *sampler) serve(r *Route, h HandlerFunc, w http.ResponseWriter, req *http.Request, v url.Values) {
+	rec := &sampleRecorder{sample: Sample{Route: r, URI: r.RedactedURI(req), Start: time.Now()}}
+	ctx := context.WithValue(req.Context(), sampleKey{}, rec)
+	label := r.Name
+	if label == "" {
+		label = r.Method + " " + r.Pattern
+	}
+	pprof.Do(ctx, pprof.Labels("route", label), func(ctx context.Context) {
+		h(w, req.WithContext(ctx), v)
+	})
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.sample.Duration = time.Since(rec.sample.Start)
+	s.report(&rec.sample)
+}
+
+// Times handler h as the "handler" phase of sampled requests.
+func handlerPhase(h HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		defer StartPhase(r.Context(), "handler")()
+		h(w, r, v)
+	}
+}
@@ -0,0 +1,71 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"runtime/pprof"
+	"testing"
+	"time"
+)
+
+func TestSample(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	var samples []*Sample
+	var label string
+	r := m.Add("GET", "search", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		label, _ = pprof.Label(r.Context(), "route")
+		end := StartPhase(r.Context(), "db")
+		time.Sleep(time.Millisecond)
+		end()
+	}).As("search").Sample(1, func(s *Sample) {
+		samples = append(samples, s)
+	})
+	serve(m, "GET", "/search?q=go")
+
+	if len(samples) != 1 {
+		t.Fatalf("got %d samples, want 1", len(samples))
+	}
+	s := samples[0]
+	if s.Route != r {
+		t.Error("Sample.Route isn't the matched route")
+	}
+	assertEqual(t, s.URI, "/search?q=go")
+	assertEqual(t, label, "search")
+	if len(s.Phases) != 2 {
+		t.Fatalf("phases = %+v, want db and handler", s.Phases)
+	}
+	assertEqual(t, s.Phases[0].Name, "db")
+	assertEqual(t, s.Phases[1].Name, "handler")
+	if db := s.Phases[0].Duration; db < time.Millisecond || db > s.Phases[1].Duration || s.Phases[1].Duration > s.Duration {
+		t.Errorf("phases don't nest: %+v, total %v", s.Phases, s.Duration)
+	}
+}
+
+func TestSampleNone(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	sampled := false
+	m.Add("GET", "a", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		StartPhase(r.Context(), "db")()
+	}).Sample(0, func(s *Sample) { sampled = true })
+	for i := 0; i < 10; i++ {
+		serve(m, "GET", "/a")
+	}
+	if sampled {
+		t.Error("request sampled with fraction 0")
+	}
+	// Not sampled outside of a request either.
+	StartPhase(context.Background(), "x")()
+}
+
+func TestSamplePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Sample(2) didn't panic")
+		}
+	}()
+	NewMux("/", http.NewServeMux()).Add("GET", "a", dummy).Sample(2, nil)
+}
@@ -0,0 +1,161 @@
+package muxer
+
+import (
+	"container/list"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Scheduler limits the number of concurrently running handlers and queues
+// the rest in per-tag queues, so that interactive routes keep latency while
+// batch routes absorb the queuing during load spikes:
+//
+//	s := muxer.NewScheduler(100, 1000, time.Second).
+//		Class("batch", muxer.PriorityLow, 50, 30*time.Second)
+//	m.Use(s.Middleware())
+//	m.Add("GET", "export", exportHandler).Tag("batch")
+//
+// When a handler finishes, the next request is taken from the non-empty
+// queue of the highest priority, in FIFO order within a queue. Routes without
+// a tag of any class go to the default queue with PriorityNormal.
+// Requests which don't fit in their queue or wait longer than its max wait
+// get 503 Service Unavailable.
+type Scheduler struct {
+	mu      sync.Mutex
+	free    int
+	classes []*queueClass // ordered by priority, highest first
+	def     *queueClass
+}
+
+type queueClass struct {
+	tag      string
+	priority Priority
+	maxLen   int
+	maxWait  time.Duration
+	waiters  list.List // of chan struct{}
+}
+
+// Creates a scheduler running up to concurrency handlers at once.
+// Default queue holds up to maxQueue requests for up to maxWait each.
+func NewScheduler(concurrency, maxQueue int, maxWait time.Duration) *Scheduler {
+	def := &queueClass{priority: PriorityNormal, maxLen: maxQueue, maxWait: maxWait}
+	return &Scheduler{free: concurrency, classes: []*queueClass{def}, def: def}
+}
+
+// Adds a queue for routes tagged with tag.
+func (s *Scheduler) Class(tag string, p Priority, maxQueue int, maxWait time.Duration) *Scheduler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := &queueClass{tag: tag, priority: p, maxLen: maxQueue, maxWait: maxWait}
+	i := len(s.classes)
+	for i > 0 && s.classes[i-1].priority < p {
+		i--
+	}
+	s.classes = append(s.classes, nil)
+	copy(s.classes[i+1:], s.classes[i:])
+	s.classes[i] = c
+	return s
+}
+
+// Returns queue class of route r.
+func (s *Scheduler) classFor(r *Route) *queueClass {
+	if r != nil {
+		for _, c := range s.classes {
+			if c != s.def && r.HasTag(c.tag) {
+				return c
+			}
+		}
+	}
+	return s.def
+}
+
+// Waits for a free slot. Reports false if the request has been rejected.
+func (s *Scheduler) acquire(c *queueClass, done <-chan struct{}) bool {
+	s.mu.Lock()
+	if s.free > 0 && !s.waitingLocked(c.priority) {
+		s.free--
+		s.mu.Unlock()
+		return true
+	}
+	if c.waiters.Len() >= c.maxLen {
+		s.mu.Unlock()
+		return false
+	}
+	ready := make(chan struct{})
+	el := c.waiters.PushBack(ready)
+	s.mu.Unlock()
+
+	timer := time.NewTimer(c.maxWait)
+	defer timer.Stop()
+	select {
+	case <-ready:
+		return true
+	case <-timer.C:
+	case <-done:
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-ready:
+		// Got the slot right before giving up.
+		return true
+	default:
+	}
+	c.waiters.Remove(el)
+	return false
+}
+
+// Reports whether any request of priority p or higher is queued.
+func (s *Scheduler) waitingLocked(p Priority) bool {
+	for _, c := range s.classes {
+		if c.priority >= p && c.waiters.Len() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Hands the slot over to the next queued request, if any.
+func (s *Scheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.classes {
+		if el := c.waiters.Front(); el != nil {
+			c.waiters.Remove(el)
+			close(el.Value.(chan struct{}))
+			return
+		}
+	}
+	s.free++
+}
+
+// Returns the number of queued requests with tag, or of the default queue
+// if tag is zero string.
+func (s *Scheduler) Queued(tag string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.classes {
+		if c.tag == tag {
+			return c.waiters.Len()
+		}
+	}
+	return 0
+}
+
+// Returns middleware scheduling the routes it wraps.
+func (s *Scheduler) Middleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+			if !s.acquire(s.classFor(CurrentRoute(r)), r.Context().Done()) {
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable),
+					http.StatusServiceUnavailable)
+				return
+			}
+			defer s.release()
+			next(w, r, v)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	s := NewScheduler(1, 10, time.Second).
+		Class("batch", PriorityLow, 1, time.Second)
+	var mu sync.Mutex
+	var order []string
+	unblock := make(chan struct{})
+	h := func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		if r.URL.Path == "/block" {
+			<-unblock
+		}
+		mu.Lock()
+		order = append(order, r.URL.Path)
+		mu.Unlock()
+	}
+	m := NewMux("/", http.NewServeMux())
+	m.Use(s.Middleware())
+	m.Add("GET", "block", h)
+	m.Add("GET", "export", h).Tag("batch")
+	m.Add("GET", "users", h)
+
+	var wg sync.WaitGroup
+	codes := make(map[string]int)
+	start := func(path string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			code := serve(m, "GET", path).Code
+			mu.Lock()
+			codes[path] = code
+			mu.Unlock()
+		}()
+	}
+	waitQueued := func(tag string, n int) {
+		for s.Queued(tag) != n {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	start("/block")
+	for freeSlots(s) != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	start("/export")
+	waitQueued("batch", 1)
+	start("/users")
+	waitQueued("", 1)
+
+	// batch queue is full
+	if w := serve(m, "GET", "/export"); w.Code != 503 {
+		t.Fatalf("Expected 503 for a full queue, got %d", w.Code)
+	}
+	close(unblock)
+	wg.Wait()
+
+	expected := []string{"/block", "/users", "/export"}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("Expected %v order, got %v", expected, order)
+		}
+	}
+	if codes["/export"] != 200 || codes["/users"] != 200 {
+		t.Fatalf("Expected queued requests to succeed, got %v", codes)
+	}
+}
+
+func TestSchedulerMaxWait(t *testing.T) {
+	s := NewScheduler(1, 10, 10*time.Millisecond)
+	unblock := make(chan struct{})
+	m := NewMux("/", http.NewServeMux())
+	m.Use(s.Middleware())
+	m.Add("GET", "block", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		<-unblock
+	})
+	done := make(chan struct{})
+	go func() {
+		serve(m, "GET", "/block")
+		close(done)
+	}()
+	for freeSlots(s) != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if w := serve(m, "GET", "/block"); w.Code != 503 {
+		t.Fatalf("Expected 503 after max wait, got %d", w.Code)
+	}
+	close(unblock)
+	<-done
+	if freeSlots(s) != 1 || s.Queued("") != 0 {
+		t.Fatalf("Expected a free slot and empty queue, got %d and %d",
+			freeSlots(s), s.Queued(""))
+	}
+}
+
+func freeSlots(s *Scheduler) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.free
+}
@@ -0,0 +1,28 @@
+package muxer
+
+import "strings"
+
+// Makes this route match only requests with one of schemes, "http" or
+// "https", so that plaintext-only endpoints and TLS-only routes can share
+// a mux, even with overlapping patterns:
+//
+//	m.Add("GET", "upgrade/{token}", upgrade).Scheme("http")
+//	m.Add("GET", "{page}/{id}", page).Scheme("https")
+//
+// Behind a proxy terminating TLS, see Mux.TrustProxies(). Requests with
+// other schemes are served as if the route didn't exist.
+func (r *Route) Scheme(schemes ...string) *Route {
+	if len(schemes) == 0 {
+		panic("Scheme() needs at least one scheme")
+	}
+	r.schemes = make([]string, len(schemes))
+	for i, s := range schemes {
+		r.schemes[i] = strings.ToLower(s)
+	}
+	return r
+}
+
+// Reports whether this route matches requests with scheme.
+func (r *Route) allowsScheme(scheme string) bool {
+	return r.schemes == nil || containsString(r.schemes, scheme)
+}
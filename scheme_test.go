@@ -0,0 +1,57 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestScheme(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "upgrade/{token}", dummy).Scheme("http")
+	m.Add("GET", "{page}/{id}", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		w.Write([]byte("page"))
+	}).Scheme("HTTPS")
+	m.TrustProxies("10.0.0.0/8")
+
+	for _, tc := range []struct {
+		name, remote, proto string
+		tls                 bool
+		path, want          string
+	}{
+		{"plain upgrade", "1.2.3.4:5", "", false, "/upgrade/x", "params:token=x"},
+		{"tls upgrade falls through", "1.2.3.4:5", "", true, "/upgrade/x", "page"},
+		{"plain page", "1.2.3.4:5", "", false, "/a/b", "404 page not found\n"},
+		{"proxied https", "10.1.2.3:5", "https", false, "/a/b", "page"},
+		{"proxied http", "10.1.2.3:5", "http", true, "/upgrade/x", "params:token=x"},
+		{"client claimed https", "10.1.2.3:5", "https, http", false, "/a/b", "404 page not found\n"},
+		{"client claimed http", "10.1.2.3:5", "http, https", false, "/a/b", "page"},
+		{"untrusted proxy", "1.2.3.4:5", "https", false, "/a/b", "404 page not found\n"},
+	} {
+		req := httptest.NewRequest("GET", tc.path, nil)
+		req.RemoteAddr = tc.remote
+		if tc.proto != "" {
+			req.Header.Set("X-Forwarded-Proto", tc.proto)
+		}
+		if tc.tls {
+			req.TLS = &tls.ConnectionState{}
+		}
+		if got := serveRequest(m, req).Body.String(); got != tc.want {
+			t.Errorf("%s: body = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestTrustProxiesPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("TrustProxies(bad) didn't panic")
+		}
+	}()
+	NewMux("/", http.NewServeMux()).TrustProxies("10.0.0.0")
+}
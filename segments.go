@@ -0,0 +1,49 @@
+package muxer
+
+import "strings"
+
+// Segments iterates over segments of a URL path separated by "/", the same
+// ones strings.Split(path, "/") returns, without allocating. Useful in
+// matchers and param constraints looking at a path segment by segment:
+//
+//	segs := muxer.NewSegments(p)
+//	for seg, ok := segs.Next(); ok; seg, ok = segs.Next() {
+//		// ...
+//	}
+type Segments struct {
+	path string
+	// Offset of the next segment, -1 once all have been returned.
+	next int
+}
+
+// Returns an iterator over segments of path.
+func NewSegments(path string) Segments {
+	return Segments{path: path}
+}
+
+// Returns the next segment. Reports false if there are no more.
+func (s *Segments) Next() (string, bool) {
+	if s.next < 0 {
+		return "", false
+	}
+	rest := s.path[s.next:]
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		s.next += i + 1
+		return rest[:i], true
+	}
+	s.next = -1
+	return rest, true
+}
+
+// Returns the rest of the path starting with the next segment.
+func (s *Segments) rest() string {
+	if s.next < 0 {
+		return ""
+	}
+	return s.path[s.next:]
+}
+
+// Returns number of segments of path.
+func countSegments(path string) int {
+	return strings.Count(path, "/") + 1
+}
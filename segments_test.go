@@ -0,0 +1,37 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSegments(t *testing.T) {
+	for _, p := range []string{"", "a", "a/b", "/a/", "a//b", "users/{id}/x"} {
+		var got []string
+		segs := NewSegments(p)
+		for seg, ok := segs.Next(); ok; seg, ok = segs.Next() {
+			got = append(got, seg)
+		}
+		if want := strings.Split(p, "/"); !reflect.DeepEqual(got, want) {
+			t.Errorf("segments of %q = %q, want %q", p, got, want)
+		}
+		if n := countSegments(p); n != len(got) {
+			t.Errorf("countSegments(%q) = %d, want %d", p, n, len(got))
+		}
+	}
+}
+
+func TestSegmentsAllocs(t *testing.T) {
+	n := testing.AllocsPerRun(100, func() {
+		segs := NewSegments("api/v1/users/42/orders")
+		for _, ok := segs.Next(); ok; _, ok = segs.Next() {
+		}
+	})
+	if n != 0 {
+		t.Errorf("allocs = %v, want 0", n)
+	}
+}
@@ -0,0 +1,250 @@
+package muxer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Endpoint is a listener served by Serve().
+type Endpoint struct {
+	// Name of the listener whose routes are served, see Group.Listener().
+	// Zero string serves routes of the mux itself.
+	Name string
+	// Network and address to listen on, e.g. "tcp" and ":8443", or "unix"
+	// and "/run/app.sock". Network defaults to "tcp".
+	Network, Addr string
+	// An already open listener, e.g. from systemd socket activation.
+	// Network and Addr are ignored if set.
+	Listener net.Listener
+	// Serves TLS if set, e.g. with ClientAuth for mTLS.
+	TLSConfig *tls.Config
+	// Serves TLS with certificates obtained and renewed by Certs, based
+	// on TLSConfig if set. HTTP-01 challenges are answered by the mux on
+	// plaintext endpoints, see Mux.ACMEChallenges().
+	Certs CertManager
+	// Wrap the handler of this endpoint only, the first one is
+	// the outermost.
+	Middleware []func(http.Handler) http.Handler
+
+	// Replaces the mux, set for the redirect companion.
+	handler http.Handler
+}
+
+// HSTS is a Strict-Transport-Security policy, see RFC 6797.
+type HSTS struct {
+	MaxAge            time.Duration
+	IncludeSubdomains bool
+	Preload           bool
+}
+
+// Returns the value of Strict-Transport-Security header.
+func (h *HSTS) String() string {
+	v := fmt.Sprintf("max-age=%d", int(h.MaxAge/time.Second))
+	if h.IncludeSubdomains {
+		v += "; includeSubDomains"
+	}
+	if h.Preload {
+		v += "; preload"
+	}
+	return v
+}
+
+// ServeOptions configure Serve().
+type ServeOptions struct {
+	Endpoints []Endpoint
+	// Passed to http.Server of each endpoint.
+	ReadHeaderTimeout, ReadTimeout, WriteTimeout, IdleTimeout time.Duration
+	// How long to wait for active requests on shutdown. Defaults to
+	// 10 seconds.
+	ShutdownTimeout time.Duration
+	// Address of a plaintext companion listener, e.g. ":80", redirecting
+	// all requests to the first TLS endpoint, keeping path and query.
+	// ACME HTTP-01 challenges of that endpoint are answered instead
+	// of redirected.
+	RedirectAddr string
+	// Sent with responses of TLS endpoints if set.
+	HSTS *HSTS
+}
+
+// Serves m on all endpoints of opts concurrently until ctx is done, then
+// shuts them down gracefully together, e.g. public TLS and internal
+// plaintext listeners:
+//
+//	err := muxer.Serve(ctx, m, muxer.ServeOptions{Endpoints: []muxer.Endpoint{
+//		{Addr: ":8443", TLSConfig: tlsConfig, Middleware: []func(http.Handler) http.Handler{requireClientCert}},
+//		{Name: "admin", Network: "unix", Addr: "/run/app/admin.sock"},
+//	}})
+//
+// If an endpoint fails, the others are shut down too and its error is
+// returned. Returns nil after a shutdown caused by ctx. The redirect
+// companion, see ServeOptions.RedirectAddr, shares the lifecycle.
+func Serve(ctx context.Context, m Mux, opts ServeOptions) error {
+	if len(opts.Endpoints) == 0 {
+		return errors.New("muxer: no endpoints to serve")
+	}
+	endpoints := append([]Endpoint(nil), opts.Endpoints...)
+	listeners := make([]net.Listener, 0, len(endpoints))
+	closeAll := func() {
+		for _, ln := range listeners {
+			ln.Close()
+		}
+	}
+	for i, ep := range endpoints {
+		if ep.Certs != nil {
+			endpoints[i].TLSConfig = acmeTLSConfig(ep.TLSConfig, ep.Certs)
+			m.ACMEChallenges(ep.Certs)
+		}
+		ln, err := ep.listen()
+		if err != nil {
+			closeAll()
+			return err
+		}
+		listeners = append(listeners, ln)
+	}
+	if opts.RedirectAddr != "" {
+		ep, err := redirectEndpoint(opts.RedirectAddr, endpoints, listeners)
+		if err != nil {
+			closeAll()
+			return err
+		}
+		endpoints = append(endpoints, ep)
+		listeners = append(listeners, ep.Listener)
+	}
+
+	servers := make([]*http.Server, len(listeners))
+	errs := make(chan error, len(listeners))
+	var wg sync.WaitGroup
+	for i, ep := range endpoints {
+		var h http.Handler = m
+		if ep.Name != "" {
+			h = m.Listener(ep.Name)
+		}
+		if ep.handler != nil {
+			h = ep.handler
+		}
+		if opts.HSTS != nil && ep.TLSConfig != nil {
+			h = hstsHandler(opts.HSTS.String(), h)
+		}
+		for j := len(ep.Middleware) - 1; j >= 0; j-- {
+			h = ep.Middleware[j](h)
+		}
+		srv := &http.Server{
+			Handler:           h,
+			TLSConfig:         ep.TLSConfig,
+			ReadHeaderTimeout: opts.ReadHeaderTimeout,
+			ReadTimeout:       opts.ReadTimeout,
+			WriteTimeout:      opts.WriteTimeout,
+			IdleTimeout:       opts.IdleTimeout,
+			// Keeps values of ctx, but requests in flight when it is done
+			// must be able to finish while draining.
+			BaseContext: func(net.Listener) context.Context { return context.WithoutCancel(ctx) },
+		}
+		servers[i] = srv
+		wg.Add(1)
+		go func(ln net.Listener) {
+			defer wg.Done()
+			var err error
+			if srv.TLSConfig != nil {
+				err = srv.ServeTLS(ln, "", "")
+			} else {
+				err = srv.Serve(ln)
+			}
+			if err != http.ErrServerClosed {
+				errs <- fmt.Errorf("muxer: serving %s: %w", ln.Addr(), err)
+			}
+		}(listeners[i])
+	}
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-errs:
+	}
+	timeout := opts.ShutdownTimeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	// ctx may be done already, so shutdown gets its own deadline.
+	sctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+	for _, srv := range servers {
+		srv.Shutdown(sctx)
+	}
+	wg.Wait()
+	return err
+}
+
+// Returns the listener of this endpoint, opening it if needed.
+func (ep Endpoint) listen() (net.Listener, error) {
+	if ep.Listener != nil {
+		return ep.Listener, nil
+	}
+	network := ep.Network
+	if network == "" {
+		network = "tcp"
+	}
+	if network == "unix" {
+		// A socket file left over from a previous run.
+		if fi, err := os.Lstat(ep.Addr); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			os.Remove(ep.Addr)
+		}
+	}
+	return net.Listen(network, ep.Addr)
+}
+
+// Returns the redirect companion endpoint listening on addr for the first
+// TLS endpoint of endpoints, which are listening on listeners.
+func redirectEndpoint(addr string, endpoints []Endpoint, listeners []net.Listener) (Endpoint, error) {
+	for i, ep := range endpoints {
+		if ep.TLSConfig == nil {
+			continue
+		}
+		port := ""
+		if _, p, err := net.SplitHostPort(listeners[i].Addr().String()); err == nil && p != "443" {
+			port = p
+		}
+		var h http.Handler = httpsRedirect(port)
+		if ep.Certs != nil {
+			h = ep.Certs.HTTPHandler(h)
+		}
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return Endpoint{}, err
+		}
+		return Endpoint{Addr: addr, Listener: ln, handler: h}, nil
+	}
+	return Endpoint{}, errors.New("muxer: no TLS endpoint to redirect to")
+}
+
+// Returns a handler redirecting requests to https on port, the default
+// one if zero string.
+func httpsRedirect(port string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" {
+			host = net.JoinHostPort(host, port)
+		}
+		code := http.StatusMovedPermanently
+		if r.Method != "GET" && r.Method != "HEAD" {
+			code = http.StatusPermanentRedirect
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), code)
+	})
+}
+
+func hstsHandler(value string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Strict-Transport-Security", value)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,194 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestServe(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "hello", dummy)
+	m.Group("ops").Listener("admin").Add("GET", "health", dummy)
+
+	public, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sock := filepath.Join(t.TempDir(), "admin.sock")
+	tagged := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Endpoint", "public")
+			next.ServeHTTP(w, r)
+		})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Serve(ctx, m, ServeOptions{Endpoints: []Endpoint{
+			{Listener: public, Middleware: []func(http.Handler) http.Handler{tagged}},
+			{Name: "admin", Network: "unix", Addr: sock},
+		}})
+	}()
+
+	unixClient := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", sock)
+		},
+	}}
+	get := func(c *http.Client, url string) (int, string) {
+		var resp *http.Response
+		var err error
+		// The unix socket may not be listening yet.
+		for i := 0; i < 50; i++ {
+			if resp, err = c.Get(url); err == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, resp.Header.Get("X-Endpoint")
+	}
+	base := "http://" + public.Addr().String()
+	if code, ep := get(http.DefaultClient, base+"/hello"); code != 200 || ep != "public" {
+		t.Errorf("Expected 200 from public endpoint, got %d %q", code, ep)
+	}
+	if code, _ := get(http.DefaultClient, base+"/ops/health"); code != 404 {
+		t.Errorf("Expected 404 for admin route on public endpoint, got %d", code)
+	}
+	if code, ep := get(unixClient, "http://admin/ops/health"); code != 200 || ep != "" {
+		t.Errorf("Expected 200 from admin endpoint, got %d %q", code, ep)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected clean shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve didn't return after shutdown")
+	}
+	if _, err := net.Dial("tcp", public.Addr().String()); err == nil {
+		t.Error("Expected public endpoint to be closed")
+	}
+}
+
+func TestRedirectCompanion(t *testing.T) {
+	tlsLn, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tlsLn.Close()
+	_, port, _ := net.SplitHostPort(tlsLn.Addr().String())
+	endpoints := []Endpoint{
+		{Name: "admin", Addr: "127.0.0.1:0"},
+		{Addr: tlsLn.Addr().String(), Certs: &fakeCertManager{}, TLSConfig: &tls.Config{}},
+	}
+	ep, err := redirectEndpoint("127.0.0.1:0", endpoints, []net.Listener{nil, tlsLn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ep.Listener.Close()
+
+	w := serve(ep.handler, "GET", "http://example.com:8080/docs/1?v=2")
+	if w.Code != 301 {
+		t.Errorf("Expected 301, got %d", w.Code)
+	}
+	assertEqual(t, w.Header().Get("Location"), "https://example.com:"+port+"/docs/1?v=2")
+	w = serve(ep.handler, "POST", "http://example.com/docs")
+	if w.Code != 308 {
+		t.Errorf("Expected 308, got %d", w.Code)
+	}
+	assertEqual(t, serve(ep.handler, "GET", "/.well-known/acme-challenge/abc").Body.String(), "key-auth")
+	assertEqual(t, serve(httpsRedirect(""), "GET", "http://example.com/x").Header().Get("Location"),
+		"https://example.com/x")
+
+	if _, err := redirectEndpoint("127.0.0.1:0", endpoints[:1], nil); err == nil {
+		t.Error("Expected an error without TLS endpoints")
+	}
+}
+
+func TestServeHSTS(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "hello", dummy)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Serve(ctx, m, ServeOptions{
+			Endpoints:    []Endpoint{{Listener: ln, Certs: &fakeCertManager{selfSignedCert(t)}}},
+			RedirectAddr: "127.0.0.1:0",
+			HSTS:         &HSTS{MaxAge: 365 * 24 * time.Hour, IncludeSubdomains: true},
+		})
+	}()
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}
+	resp, err := client.Get("https://" + ln.Addr().String() + "/hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	client.CloseIdleConnections()
+	assertEqual(t, resp.Header.Get("Strict-Transport-Security"), "max-age=31536000; includeSubDomains")
+	cancel()
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestServeDrain(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	started := make(chan struct{})
+	m.Add("GET", "slow", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		if err := r.Context().Err(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("done"))
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Serve(ctx, m, ServeOptions{Endpoints: []Endpoint{{Listener: ln}}})
+	}()
+	go func() {
+		<-started
+		cancel()
+	}()
+	resp, err := http.Get("http://" + ln.Addr().String() + "/slow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != 200 || string(body) != "done" {
+		t.Errorf("request in flight at shutdown = %d, %q", resp.StatusCode, body)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Expected clean shutdown, got %v", err)
+	}
+}
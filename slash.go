@@ -0,0 +1,58 @@
+package muxer
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Makes this mux redirect requests matching no route to the same path
+// with a trailing slash added or removed, if a route of the request's
+// method matches that one, e.g. "/api/products/" to "/api/products" for
+// "products". Redirects are 301 Moved Permanently for GET and HEAD
+// requests and 308 Permanent Redirect for others.
+func (dm *defaultMux) RedirectTrailingSlash() {
+	dm.slashRedirect = true
+}
+
+// Redirects req to its path with the trailing slash toggled if a route
+// matches it. Reports whether it has.
+func (dm *defaultMux) redirectSlash(w http.ResponseWriter, req *http.Request) bool {
+	if req.URL.Path == "/" {
+		return false
+	}
+	u := *req.URL
+	u.Path = toggleSlash(u.Path)
+	if u.RawPath != "" {
+		u.RawPath = toggleSlash(u.RawPath)
+	}
+	alt := *req
+	alt.URL = &u
+	p, ok := dm.relPath(&alt)
+	if !ok || !hasMethod(dm.pathRoutes(p, listenerOf(req)), req.Method) {
+		return false
+	}
+	code := http.StatusMovedPermanently
+	if req.Method != "GET" && req.Method != "HEAD" {
+		code = http.StatusPermanentRedirect
+	}
+	location := dm.externalPrefix(req) + u.RequestURI()
+	if offsite(location) {
+		return false
+	}
+	http.Redirect(w, req, location, code)
+	return true
+}
+
+// Reports whether clients would take location, a path to redirect to, for
+// a URL of another host, e.g. "//evil.com" of a request for "//evil.com/".
+func offsite(location string) bool {
+	return strings.HasPrefix(location, "//") || strings.HasPrefix(location, "/\\")
+}
+
+// Returns path with a trailing slash removed or added.
+func toggleSlash(path string) string {
+	if strings.HasSuffix(path, "/") {
+		return path[:len(path)-1]
+	}
+	return path + "/"
+}
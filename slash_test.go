@@ -0,0 +1,62 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestRedirectTrailingSlash(t *testing.T) {
+	m := NewMux("/api", http.NewServeMux())
+	m.Add("GET", "products", dummy)
+	m.Add("POST", "orders", dummy)
+	m.SetStrictness(Permissive)
+	m.Add("GET", "docs/", dummy)
+	m.Add("GET", "", dummy)
+	if code := serve(m, "GET", "/api/products/").Code; code != http.StatusNotFound {
+		t.Errorf("GET /api/products/ without RedirectTrailingSlash() = %d, want 404", code)
+	}
+
+	m.RedirectTrailingSlash()
+	tests := []struct {
+		method, path string
+		code         int
+		location     string
+	}{
+		{"GET", "/api/products/?page=2", 301, "/api/products?page=2"},
+		{"GET", "/api/docs", 301, "/api/docs/"},
+		{"GET", "/api", 301, "/api/"},
+		{"POST", "/api/orders/", 308, "/api/orders"},
+		{"PUT", "/api/orders/", 404, ""},
+		{"GET", "/api/products", 200, ""},
+		{"GET", "/api/missing/", 404, ""},
+	}
+	for _, test := range tests {
+		w := serve(m, test.method, test.path)
+		if w.Code != test.code || w.Header().Get("Location") != test.location {
+			t.Errorf("%s %s = %d, %q, want %d, %q", test.method, test.path,
+				w.Code, w.Header().Get("Location"), test.code, test.location)
+		}
+	}
+}
+
+func TestRedirectTrailingSlashOffsite(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "{a}/{b}", dummy)
+	m.RedirectTrailingSlash()
+	for path, location := range map[string]string{
+		"//evil.com/":  "",
+		"/\\evil.com/": "",
+		"/x/evil.com/": "/x/evil.com",
+	} {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.URL = &url.URL{Path: path}
+		w := serveRequest(m, req)
+		if loc := w.Header().Get("Location"); loc != location {
+			t.Errorf("GET %s = %d, %q, want %q", path, w.Code, loc, location)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package muxer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Service level objective of a route, see Route.SLO().
+type SLO struct {
+	// 99th percentile latency target.
+	P99 time.Duration
+	// Percentage of requests to serve without a 5xx response, e.g. 99.9.
+	Availability float64
+}
+
+// Declares the objective of this route: 99% of requests served within p99
+// and availability percent of them without a server error. Monitored by
+// Mux.WatchSLOs(). Panics if availability isn't between 0 and 100.
+func (r *Route) SLO(p99 time.Duration, availability float64) *Route {
+	if availability <= 0 || availability >= 100 {
+		panic(fmt.Sprintf("Route '%s %s': availability %v%% isn't between 0 and 100",
+			r.Method, r.Pattern, availability))
+	}
+	r.slo = &SLO{p99, availability}
+	return r
+}
+
+// Returns the objective declared with SLO(), or nil.
+func (r *Route) Objective() *SLO {
+	return r.slo
+}
+
+// Error budget burn of a route over a window. A rate of 1 spends the
+// budget exactly as fast as the objective allows, higher ones faster.
+type SLOBurn struct {
+	Route    *Route
+	Window   time.Duration
+	Requests uint64
+	// Burn rates of the availability and latency budgets.
+	Availability float64
+	Latency      float64
+}
+
+// Function type notified of a burn rate exceeding the alert threshold.
+type BurnFunc func(b SLOBurn)
+
+// Starts evaluating objectives of routes, see Route.SLO(), every window
+// from stats of the requests served in it, see TrackStats(), which it
+// calls. Burns of routes at a rate of threshold or more in either budget
+// are reported to f, e.g. 14.4 over an hour to page someone as Google's
+// SRE workbook suggests. Returns a function stopping the evaluation.
+func (dm *defaultMux) WatchSLOs(window time.Duration, threshold float64, f BurnFunc) (stop func()) {
+	dm.TrackStats()
+	w := &sloWatcher{window: window, threshold: threshold, f: f, prev: make(map[*Route]sloCounts)}
+	w.evaluate(dm.snapshot())
+	return every(window, func() { w.evaluate(dm.snapshot()) })
+}
+
+// Calls f every interval in a goroutine until the returned func is called.
+func every(interval time.Duration, f func()) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				f()
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+type sloWatcher struct {
+	window    time.Duration
+	threshold float64
+	f         BurnFunc
+	// Counts at the previous evaluation.
+	prev map[*Route]sloCounts
+}
+
+// Counts of a route relevant to its objective.
+type sloCounts struct {
+	hits, errors, slow uint64
+}
+
+// Reports burns of routes since the previous evaluation.
+func (w *sloWatcher) evaluate(routes []*Route) {
+	for _, r := range routes {
+		if r.slo == nil || r.stats == nil {
+			continue
+		}
+		c := r.stats.sloCounts(r.slo.P99)
+		prev, seen := w.prev[r]
+		w.prev[r] = c
+		if !seen || c.hits == prev.hits {
+			continue
+		}
+		b := SLOBurn{Route: r, Window: w.window, Requests: c.hits - prev.hits}
+		n := float64(b.Requests)
+		b.Availability = float64(c.errors-prev.errors) / n / (1 - r.slo.Availability/100)
+		b.Latency = float64(c.slow-prev.slow) / n / 0.01
+		if b.Availability >= w.threshold || b.Latency >= w.threshold {
+			w.f(b)
+		}
+	}
+}
+
+// Returns numbers of requests, 5xx responses and those slower than target.
+func (s *routeStats) sloCounts(target time.Duration) sloCounts {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := sloCounts{hits: s.hits}
+	for code, n := range s.statuses {
+		if code >= 500 {
+			c.errors += n
+		}
+	}
+	for i := latencyBucket(target) + 1; i < statsBuckets; i++ {
+		c.slow += s.latency[i]
+	}
+	return c
+}
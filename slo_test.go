@@ -0,0 +1,68 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"math"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestSLOBurn(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.TrackStats()
+	r := m.Add("GET", "a", dummy).SLO(100*time.Millisecond, 99)
+	m.Add("GET", "b", dummy)
+
+	var burns []SLOBurn
+	w := &sloWatcher{window: time.Minute, threshold: 2, prev: make(map[*Route]sloCounts),
+		f: func(b SLOBurn) { burns = append(burns, b) }}
+	routes := m.Routes()
+	w.evaluate(routes)
+
+	for i := 0; i < 100; i++ {
+		r.stats.record(200, time.Millisecond)
+	}
+	r.stats.record(500, time.Millisecond)
+	w.evaluate(routes)
+	if len(burns) != 0 {
+		t.Fatalf("burns within budget: %+v", burns)
+	}
+
+	for i := 0; i < 95; i++ {
+		r.stats.record(200, time.Millisecond)
+	}
+	for i := 0; i < 5; i++ {
+		r.stats.record(503, time.Second)
+	}
+	w.evaluate(routes)
+	if len(burns) != 1 {
+		t.Fatalf("got %d burns, want 1", len(burns))
+	}
+	if b := burns[0]; b.Route != r || b.Requests != 100 ||
+		math.Abs(b.Availability-5) > 1e-9 || math.Abs(b.Latency-5) > 1e-9 {
+		t.Errorf("burn = %+v", b)
+	}
+}
+
+func TestWatchSLOs(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "a", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		w.WriteHeader(500)
+	}).SLO(time.Second, 99.9)
+	burned := make(chan SLOBurn, 10)
+	stop := m.WatchSLOs(10*time.Millisecond, 1, func(b SLOBurn) { burned <- b })
+	defer stop()
+	serve(m, "GET", "/a")
+	select {
+	case b := <-burned:
+		if b.Requests != 1 {
+			t.Errorf("burn = %+v", b)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no burn reported")
+	}
+}
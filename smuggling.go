@@ -0,0 +1,82 @@
+package muxer
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Headers which must appear at most once. Proxies disagreeing on which
+// one to use is how requests get smuggled.
+var singleHeaders = []string{"Content-Length", "Content-Type", "Host", "Transfer-Encoding"}
+
+// Makes this mux reject requests with header combinations a proxy in front
+// of it may read differently, with 400 Bad Request before PreRoute hooks
+// and matching:
+//
+//   - both Transfer-Encoding and Content-Length;
+//   - Transfer-Encoding other than a single "chunked", or in HTTP/1.0;
+//   - repeated Content-Length, Content-Type, Host or Transfer-Encoding;
+//   - control characters in header values;
+//   - HTTP/1 connection headers in HTTP/2 and later requests.
+//
+// net/http rejects the most blatant cases already; the guard adds the ones
+// it tolerates for compatibility. Rejected requests are reported to
+// OnReject() hook.
+func (dm *defaultMux) GuardHeaders() {
+	dm.guardHeaders = true
+}
+
+// Responds with 400 Bad Request if headers of req are suspicious.
+// Reports whether it has.
+func (dm *defaultMux) rejectHeaders(w http.ResponseWriter, req *http.Request) bool {
+	reason := headerProblem(req)
+	if reason == "" {
+		return false
+	}
+	// Whatever follows the body can't be trusted.
+	w.Header().Set("Connection", "close")
+	dm.reject(w, req, NewStatusError(http.StatusBadRequest, "Invalid headers: "+reason), reason)
+	return true
+}
+
+// Returns what's suspicious about headers of req, or zero string.
+func headerProblem(req *http.Request) string {
+	h := req.Header
+	te := req.TransferEncoding
+	if len(te) == 0 {
+		te = h.Values("Transfer-Encoding")
+	}
+	if len(te) > 0 {
+		if len(h.Values("Content-Length")) > 0 {
+			return "both Transfer-Encoding and Content-Length"
+		}
+		if len(te) != 1 || !strings.EqualFold(strings.TrimSpace(te[0]), "chunked") {
+			return "unsupported Transfer-Encoding"
+		}
+		if req.ProtoMajor == 1 && req.ProtoMinor == 0 {
+			return "Transfer-Encoding in HTTP/1.0"
+		}
+	}
+	for _, k := range singleHeaders {
+		if len(h.Values(k)) > 1 {
+			return "repeated " + k
+		}
+	}
+	if req.ProtoMajor >= 2 {
+		for _, k := range []string{"Connection", "Keep-Alive", "Proxy-Connection", "Transfer-Encoding", "Upgrade"} {
+			if len(h.Values(k)) > 0 {
+				return k + " in HTTP/" + req.Proto[len("HTTP/"):]
+			}
+		}
+	}
+	for k, vs := range h {
+		for _, v := range vs {
+			for i := 0; i < len(v); i++ {
+				if c := v[i]; c < 0x20 && c != '\t' || c == 0x7f {
+					return "control character in " + k
+				}
+			}
+		}
+	}
+	return ""
+}
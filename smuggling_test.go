@@ -0,0 +1,65 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHeaderProblem(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		modify func(r *http.Request)
+		want   string
+	}{
+		{"plain", func(r *http.Request) {}, ""},
+		{"chunked", func(r *http.Request) { r.TransferEncoding = []string{"chunked"} }, ""},
+		{"te and cl", func(r *http.Request) {
+			r.TransferEncoding = []string{"chunked"}
+			r.Header.Set("Content-Length", "5")
+		}, "both Transfer-Encoding and Content-Length"},
+		{"gzip te", func(r *http.Request) { r.Header["Transfer-Encoding"] = []string{"gzip, chunked"} }, "unsupported Transfer-Encoding"},
+		{"http/1.0 te", func(r *http.Request) {
+			r.ProtoMinor = 0
+			r.Header.Set("Transfer-Encoding", "chunked")
+		}, "Transfer-Encoding in HTTP/1.0"},
+		{"two cl", func(r *http.Request) { r.Header["Content-Length"] = []string{"5", "6"} }, "repeated Content-Length"},
+		{"h2 connection", func(r *http.Request) {
+			r.Proto, r.ProtoMajor, r.ProtoMinor = "HTTP/2.0", 2, 0
+			r.Header.Set("Connection", "keep-alive")
+		}, "Connection in HTTP/2.0"},
+		{"control", func(r *http.Request) { r.Header["X-Id"] = []string{"a\x00b"} }, "control character in X-Id"},
+		{"tab", func(r *http.Request) { r.Header.Set("X-Id", "a\tb") }, ""},
+	} {
+		r := httptest.NewRequest("POST", "/", nil)
+		tc.modify(r)
+		if got := headerProblem(r); got != tc.want {
+			t.Errorf("%s: headerProblem() = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestGuardHeaders(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("POST", "a", dummy)
+	var reasons []string
+	m.OnReject(func(r *http.Request, reason string) { reasons = append(reasons, reason) })
+	req := httptest.NewRequest("POST", "/a", nil)
+	req.Header["Content-Length"] = []string{"0", "10"}
+
+	if w := serveRequest(m, req); w.Code != 200 {
+		t.Errorf("unguarded: code = %d, want 200", w.Code)
+	}
+	m.GuardHeaders()
+	w := serveRequest(m, req)
+	if w.Code != 400 {
+		t.Errorf("code = %d, want 400", w.Code)
+	}
+	assertEqual(t, w.Header().Get("Connection"), "close")
+	if len(reasons) != 1 || reasons[0] != "repeated Content-Length" {
+		t.Errorf("reasons = %q", reasons)
+	}
+}
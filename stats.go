@@ -0,0 +1,195 @@
+package muxer
+
+import (
+	"encoding/json"
+	"math/bits"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Statistics of a route collected since Mux.TrackStats() was called.
+type RouteStats struct {
+	Method  string `json:"method"`
+	Pattern string `json:"pattern"`
+	Name    string `json:"name,omitempty"`
+	// Number of served requests.
+	Hits uint64 `json:"hits"`
+	// Number of responses by status code.
+	Statuses map[int]uint64 `json:"statuses,omitempty"`
+	// Latency quantiles of the handler, accurate within about 6%.
+	P50 time.Duration `json:"p50"`
+	P90 time.Duration `json:"p90"`
+	P99 time.Duration `json:"p99"`
+	Max time.Duration `json:"max"`
+	// Recovered handler panics and whether the route is tripped,
+	// see Mux.IsolatePanics().
+	Panics  uint64 `json:"panics,omitempty"`
+	Tripped bool   `json:"tripped,omitempty"`
+	// Labels and owner of the route, see Route.Label() and Route.Owner().
+	Labels map[string]string `json:"labels,omitempty"`
+	Owner  string            `json:"owner,omitempty"`
+}
+
+// Makes this mux count requests, response status codes and handler
+// latencies of every route, see Stats(). Memory used is fixed per route,
+// about 2KiB regardless of traffic. Call it before serving requests.
+func (dm *defaultMux) TrackStats() {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	dm.trackStats = true
+	for _, r := range dm.routes {
+		if r.stats == nil {
+			r.stats = &routeStats{}
+		}
+	}
+}
+
+// Returns statistics of all routes in the order they were added, or nil
+// if TrackStats() wasn't called.
+func (dm *defaultMux) Stats() []RouteStats {
+	if !dm.trackStats {
+		return nil
+	}
+	routes := dm.snapshot()
+	stats := make([]RouteStats, 0, len(routes))
+	for _, r := range routes {
+		s := r.stats.snapshot()
+		s.Method, s.Pattern, s.Name = r.Method, r.Pattern, r.Name
+		s.Panics, s.Tripped = r.Panics(), r.Tripped()
+		s.Labels, s.Owner = r.Labels(), r.OwnedBy()
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+// Returns a handler responding with Stats() of m as JSON, e.g. to add
+// to a group bound to an internal listener:
+//
+//	m.TrackStats()
+//	ops.Add("GET", "debug/routes", muxer.StatsHandler(m))
+func StatsHandler(m Mux) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(m.Stats())
+	}
+}
+
+// Latency histogram buckets: the first 8 hold 0-7µs, then each power
+// of two is split into 8 linear buckets, covering more than an hour.
+const statsBuckets = 8 + 29*8
+
+type routeStats struct {
+	mu       sync.Mutex
+	hits     uint64
+	statuses map[int]uint64
+	latency  [statsBuckets]uint64
+	max      time.Duration
+}
+
+// Returns index of the histogram bucket of d.
+func latencyBucket(d time.Duration) int {
+	n := uint64(d / time.Microsecond)
+	if n < 8 {
+		return int(n)
+	}
+	e := bits.Len64(n) - 4
+	i := 8 + e*8 + int(n>>e) - 8
+	if i >= statsBuckets {
+		return statsBuckets - 1
+	}
+	return i
+}
+
+// Returns the middle of histogram bucket i.
+func bucketLatency(i int) time.Duration {
+	if i < 8 {
+		return time.Duration(i) * time.Microsecond
+	}
+	e := (i - 8) / 8
+	lo := uint64((i-8)%8+8) << e
+	return time.Duration(lo+(uint64(1)<<e)/2) * time.Microsecond
+}
+
+func (s *routeStats) record(code int, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hits++
+	if s.statuses == nil {
+		s.statuses = make(map[int]uint64)
+	}
+	s.statuses[code]++
+	s.latency[latencyBucket(d)]++
+	if d > s.max {
+		s.max = d
+	}
+}
+
+func (s *routeStats) snapshot() RouteStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rs := RouteStats{Hits: s.hits, Max: s.max}
+	if len(s.statuses) > 0 {
+		rs.Statuses = make(map[int]uint64, len(s.statuses))
+		for code, n := range s.statuses {
+			rs.Statuses[code] = n
+		}
+	}
+	rs.P50, rs.P90, rs.P99 = s.quantile(0.5), s.quantile(0.9), s.quantile(0.99)
+	return rs
+}
+
+// Returns latency quantile q, capped by the maximum latency.
+func (s *routeStats) quantile(q float64) time.Duration {
+	if s.hits == 0 {
+		return 0
+	}
+	rank := uint64(q*float64(s.hits-1)) + 1
+	var n uint64
+	for i, c := range s.latency {
+		if n += c; n >= rank {
+			if d := bucketLatency(i); d < s.max {
+				return d
+			}
+			return s.max
+		}
+	}
+	return s.max
+}
+
+// Keeps the status code of a response for route statistics.
+type statsWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *statsWriter) WriteHeader(code int) {
+	// Skips informational responses, e.g. 103 Early Hints.
+	if w.code == 0 && code >= 200 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statsWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statsWriter) Flush() {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Lets http.ResponseController reach the underlying writer.
+func (w *statsWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
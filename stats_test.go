@@ -0,0 +1,101 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	if m.Stats() != nil {
+		t.Error("Stats() before TrackStats() != nil")
+	}
+	m.Add("GET", "users/{id}", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		if v.Get("id") == "0" {
+			http.NotFound(w, r)
+		}
+	}).As("user")
+	m.TrackStats()
+	m.Add("GET", "health", dummy)
+
+	serve(m, "GET", "/users/1")
+	serve(m, "GET", "/users/2")
+	serve(m, "GET", "/users/0")
+	serve(m, "GET", "/health")
+	serve(m, "GET", "/missing")
+
+	stats := m.Stats()
+	if len(stats) != 2 {
+		t.Fatalf("len(Stats()) = %d, want 2", len(stats))
+	}
+	s := stats[0]
+	assertEqual(t, s.Method+" "+s.Pattern+" "+s.Name, "GET users/{id} user")
+	if s.Hits != 3 || s.Statuses[200] != 2 || s.Statuses[404] != 1 {
+		t.Errorf("hits = %d, statuses = %v; want 3, map[200:2 404:1]", s.Hits, s.Statuses)
+	}
+	if s.P50 > s.P99 || s.P99 > s.Max {
+		t.Errorf("quantiles aren't ordered: %v %v %v", s.P50, s.P99, s.Max)
+	}
+	if stats[1].Hits != 1 {
+		t.Errorf("health hits = %d, want 1", stats[1].Hits)
+	}
+
+	if c := m.Clone().Stats(); c[0].Hits != 0 {
+		t.Errorf("clone hits = %d, want 0", c[0].Hits)
+	}
+}
+
+func TestStatsQuantiles(t *testing.T) {
+	s := &routeStats{}
+	for i := 1; i <= 100; i++ {
+		s.record(200, time.Duration(i)*time.Millisecond)
+	}
+	for _, tc := range []struct {
+		q    float64
+		want time.Duration
+	}{{0.5, 50 * time.Millisecond}, {0.9, 90 * time.Millisecond}, {0.99, 99 * time.Millisecond}} {
+		got := s.quantile(tc.q)
+		if diff := got - tc.want; diff < -tc.want/16 || diff > tc.want/16 {
+			t.Errorf("quantile(%v) = %v, want %v within 6%%", tc.q, got, tc.want)
+		}
+	}
+	if s.max != 100*time.Millisecond {
+		t.Errorf("max = %v, want 100ms", s.max)
+	}
+}
+
+func TestLatencyBucket(t *testing.T) {
+	for _, d := range []time.Duration{0, 5 * time.Microsecond, time.Millisecond, 3 * time.Second, time.Hour} {
+		got := bucketLatency(latencyBucket(d))
+		if diff := got - d; diff < -d/16 || diff > d/16 {
+			t.Errorf("bucketLatency(latencyBucket(%v)) = %v", d, got)
+		}
+	}
+	if i := latencyBucket(1000 * time.Hour); i != statsBuckets-1 {
+		t.Errorf("latencyBucket(1000h) = %d, want the last bucket", i)
+	}
+}
+
+func TestStatsHandler(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.TrackStats()
+	m.Add("GET", "a", dummy)
+	m.Add("GET", "debug/routes", StatsHandler(m))
+	serve(m, "GET", "/a")
+
+	w := serve(m, "GET", "/debug/routes")
+	assertEqual(t, w.Header().Get("Content-Type"), "application/json")
+	var stats []RouteStats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 || stats[0].Hits != 1 || stats[0].Statuses[200] != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
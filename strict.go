@@ -0,0 +1,127 @@
+package muxer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Strictness controls what a mux does with suspicious route patterns:
+// empty segments, unbalanced braces, empty param names or characters
+// not allowed in a URL path.
+type Strictness int
+
+const (
+	// Permissive accepts anything silently. This is the default.
+	Permissive Strictness = iota
+	// Warn accepts suspicious patterns but reports every problem found
+	// to the hook set with OnWarning().
+	Warn
+	// Strict makes Add() panic on suspicious patterns.
+	Strict
+)
+
+func (s Strictness) String() string {
+	switch s {
+	case Permissive:
+		return "permissive"
+	case Warn:
+		return "warn"
+	case Strict:
+		return "strict"
+	}
+	return fmt.Sprintf("Strictness(%d)", int(s))
+}
+
+// Function type that gets notified about suspicious patterns when a mux
+// is in Warn mode.
+type WarningFunc func(method, pattern, problem string)
+
+// Sets how this mux treats suspicious patterns of the routes added
+// afterwards.
+func (dm *defaultMux) SetStrictness(s Strictness) {
+	dm.strictness = s
+}
+
+// Sets a hook called for every problem found in a pattern in Warn mode.
+func (dm *defaultMux) OnWarning(f WarningFunc) {
+	dm.warn = f
+}
+
+// Checks pattern p according to the mux strictness. Panics in Strict mode.
+func (dm *defaultMux) checkPattern(m, p string) {
+	if dm.strictness == Permissive {
+		return
+	}
+	for _, problem := range patternProblems(p) {
+		if dm.strictness == Strict {
+			panic(fmt.Sprintf("Route '%s %s': %s", m, p, problem))
+		}
+		if dm.warn != nil {
+			dm.warn(m, p, problem)
+		}
+	}
+}
+
+// Returns a list of human readable problems found in pattern p, if any.
+// Empty pattern is fine: it matches the base path of a mux.
+func patternProblems(p string) (problems []string) {
+	if p == "" {
+		return nil
+	}
+	split := strings.Split(p, "/")
+	for i, sp := range split {
+		if sp == "" {
+			problems = append(problems, fmt.Sprintf("empty segment #%d", i+1))
+			continue
+		}
+		isVar := sp[0] == '{' && sp[len(sp)-1] == '}'
+		if name, expr, ok := strings.Cut(sp, ":"); isVar && ok {
+			// Constrained param, braces of the expression are fine.
+			if _, err := regexp.Compile(expr[:len(expr)-1]); err != nil {
+				problems = append(problems, fmt.Sprintf("invalid constraint in '%s'", sp))
+				continue
+			}
+			sp = name + "}"
+		}
+		open := strings.Count(sp, "{")
+		closed := strings.Count(sp, "}")
+		switch {
+		case isVar && open == 1 && closed == 1 && (len(sp) == 2 || sp == "{...}"):
+			problems = append(problems, fmt.Sprintf("empty param name in '%s'", sp))
+		case isVar && open == 1 && closed == 1 && strings.HasSuffix(sp, "...}") && i < len(split)-1:
+			problems = append(problems, fmt.Sprintf("catch-all '%s' isn't the last segment", sp))
+		case isVar && open == 1 && closed == 1:
+			// Well-formed param
+		case open > 0 && open == closed:
+			problems = append(problems, fmt.Sprintf("param must be a whole segment in '%s'", sp))
+		case open > 0 || closed > 0:
+			problems = append(problems, fmt.Sprintf("unbalanced braces in '%s'", sp))
+		default:
+			if c, ok := badPathChar(sp); ok {
+				problems = append(problems,
+					fmt.Sprintf("unusual character %q in '%s'", c, sp))
+			}
+		}
+	}
+	return
+}
+
+// Reports the first character of a static segment which is not a "pchar"
+// as defined by RFC 3986, section 3.3.
+func badPathChar(s string) (rune, bool) {
+	for i, c := range s {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case strings.ContainsRune("-._~!$&'()*+,;=:@", c):
+		case c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+		default:
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
@@ -0,0 +1,59 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestStrictPatterns(t *testing.T) {
+	m := NewMux("/api", http.NewServeMux())
+	m.SetStrictness(Strict)
+	m.Add("GET", "", dummy)
+	m.Add("GET", "users/{id}/a:b@c~d", dummy)
+	m.Add("GET", "files/%20x", dummy)
+
+	for _, p := range []string{"users//x", "users/{id", "{}", "a b", "x/{a}b"} {
+		func() {
+			defer func() {
+				if err := recover(); err == nil {
+					t.Fatalf("Expected panic for '%s', got no error instead", p)
+				}
+			}()
+			m.Add("POST", p, dummy)
+		}()
+	}
+}
+
+func TestWarnPatterns(t *testing.T) {
+	m := NewMux("/api", http.NewServeMux())
+	m.SetStrictness(Warn)
+	var problems []string
+	m.OnWarning(func(method, pattern, problem string) {
+		problems = append(problems, method+" "+pattern+": "+problem)
+	})
+	m.Add("GET", "users//{id", dummy)
+	if len(problems) != 2 {
+		t.Fatalf("Expected 2 problems, got %v", problems)
+	}
+	assertEqual(t, problems[0], "GET users//{id: empty segment #2")
+	assertEqual(t, problems[1], "GET users//{id: unbalanced braces in '{id'")
+	m.Add("GET", "x/{a}b", dummy)
+	if len(problems) != 3 {
+		t.Fatalf("Expected 3 problems, got %v", problems)
+	}
+	assertEqual(t, problems[2], "GET x/{a}b: param must be a whole segment in '{a}b'")
+
+	m.SetStrictness(Permissive)
+	m.Add("PUT", "a b/{", dummy)
+	if len(problems) != 3 {
+		t.Fatalf("Expected no new problems in permissive mode, got %v", problems)
+	}
+}
+
+func TestDefaultStrictness(t *testing.T) {
+	m := NewMux("/api", http.NewServeMux())
+	m.Add("GET", "users//{id", dummy)
+}
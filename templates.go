@@ -0,0 +1,84 @@
+package muxer
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// Version of the format written by ExportTemplates(). Incremented only
+// for changes breaking readers.
+const TemplatesVersion = 1
+
+// Named routes of a mux as written by ExportTemplates().
+type RouteTemplates struct {
+	Version  int             `json:"version"`
+	BasePath string          `json:"basePath"`
+	Routes   []RouteTemplate `json:"routes"`
+}
+
+// A named route as written by ExportTemplates().
+type RouteTemplate struct {
+	Name string `json:"name"`
+	// Pattern as added, e.g. "users/{id:int}".
+	Pattern string `json:"pattern"`
+	// Absolute path with params in braces and without constraints,
+	// e.g. "/api/users/{id}".
+	Template string `json:"template"`
+	// Methods of routes with the same pattern, sorted.
+	Methods   []string      `json:"methods"`
+	Variables []TemplateVar `json:"variables"`
+}
+
+// A param of a RouteTemplate.
+type TemplateVar struct {
+	Name string `json:"name"`
+	// Keyword of a built-in constraint, e.g. "int".
+	Type string `json:"type,omitempty"`
+	// Regular expression a value must match as a whole, if constrained.
+	Regexp string `json:"regexp,omitempty"`
+	// Whether the param is a catch-all one, whose value can contain "/".
+	CatchAll bool `json:"catchAll,omitempty"`
+}
+
+// Writes named routes of this mux to w as JSON, sorted by name, see
+// RouteTemplates. The format is stable, so that client generators and
+// documentation pipelines in any language can build paths of routes
+// from it the way BuildPath() does.
+func (dm *defaultMux) ExportTemplates(w io.Writer) error {
+	routes := dm.snapshot()
+	t := RouteTemplates{Version: TemplatesVersion, BasePath: dm.prefix + dm.base, Routes: []RouteTemplate{}}
+	for _, r := range routes {
+		if r.Name == "" {
+			continue
+		}
+		var same []*Route
+		for _, o := range routes {
+			if o.Pattern == r.Pattern {
+				same = append(same, o)
+			}
+		}
+		rt := RouteTemplate{
+			Name:      r.Name,
+			Pattern:   r.Pattern,
+			Template:  t.BasePath + r.template(),
+			Methods:   allowedMethods(same),
+			Variables: []TemplateVar{},
+		}
+		for _, rp := range r.parts {
+			if !rp.isVar {
+				continue
+			}
+			v := TemplateVar{Name: rp.name, Type: rp.typ, CatchAll: rp.rest}
+			if rp.re != nil {
+				v.Regexp = rp.re.String()
+			}
+			rt.Variables = append(rt.Variables, v)
+		}
+		t.Routes = append(t.Routes, rt)
+	}
+	sort.Slice(t.Routes, func(i, j int) bool { return t.Routes[i].Name < t.Routes[j].Name })
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(t)
+}
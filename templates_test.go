@@ -0,0 +1,43 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestExportTemplates(t *testing.T) {
+	m := NewMux("/api", http.NewServeMux())
+	m.Add("GET", "users/{id:int}", dummy).As("user")
+	m.Add("PUT", "users/{id:int}", dummy)
+	m.Add("GET", "files/{dir:[a-z]+}/{path...}", dummy).NoHead().As("file")
+	m.Add("POST", "users", dummy)
+
+	var buf bytes.Buffer
+	if err := m.ExportTemplates(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var got RouteTemplates
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("%v:\n%s", err, buf.String())
+	}
+	want := RouteTemplates{Version: 1, BasePath: "/api/", Routes: []RouteTemplate{
+		{Name: "file", Pattern: "files/{dir:[a-z]+}/{path...}", Template: "/api/files/{dir}/{path}",
+			Methods: []string{"GET"}, Variables: []TemplateVar{
+				{Name: "dir", Regexp: "^(?:[a-z]+)$"},
+				{Name: "path", CatchAll: true},
+			}},
+		{Name: "user", Pattern: "users/{id:int}", Template: "/api/users/{id}",
+			Methods: []string{"GET", "HEAD", "PUT"}, Variables: []TemplateVar{
+				{Name: "id", Type: "int", Regexp: "^(?:" + paramTypes["int"] + ")$"},
+			}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("templates = %+v\nwant %+v", got, want)
+	}
+}
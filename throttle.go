@@ -0,0 +1,146 @@
+package muxer
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Policy of Mux.AutoThrottle().
+type Throttle struct {
+	// Burn rate of either budget, see SLOBurn, at which a route is
+	// throttled.
+	Threshold float64
+	// Factor its RateLimit is multiplied by on each window it burns at
+	// Threshold or more, e.g. 0.5. Routes without a RateLimit are limited
+	// to their request rate in the window times Factor. Defaults to 0.5.
+	Factor float64
+	// Burn rate at which the route is disabled instead, responding with
+	// 503 Service Unavailable like a tripped circuit breaker. Zero never
+	// disables routes.
+	Trip float64
+	// Number of consecutive windows under Threshold before the route gets
+	// back its previous RouteConfig. Defaults to 3.
+	Recover int
+}
+
+// Change of a route's RouteConfig made by Mux.AutoThrottle().
+type ThrottleEvent struct {
+	Route *Route
+	// Burn which caused the change, zero if the route has recovered.
+	Burn SLOBurn
+	// Config applied to the route.
+	Config   RouteConfig
+	Restored bool
+}
+
+// Function type notified of changes made by Mux.AutoThrottle(), e.g. to
+// tell operators.
+type ThrottleFunc func(e ThrottleEvent)
+
+// Starts evaluating objectives of routes, see Route.SLO(), every window
+// like WatchSLOs() does, tightening the rate limit of routes burning their
+// error budget at t.Threshold or more, or disabling them at t.Trip. Once
+// a route has been under the threshold for t.Recover windows, it gets back
+// the RouteConfig it had, unless it has been configured with Configure()
+// meanwhile. Changes are reported to notify, if not nil. Returns a function
+// stopping the evaluation, which leaves the current configs in place.
+//
+//	m.AutoThrottle(time.Minute, muxer.Throttle{Threshold: 10, Trip: 50}, alert)
+func (dm *defaultMux) AutoThrottle(window time.Duration, t Throttle, notify ThrottleFunc) (stop func()) {
+	if t.Factor <= 0 {
+		t.Factor = 0.5
+	}
+	if t.Recover <= 0 {
+		t.Recover = 3
+	}
+	if notify == nil {
+		notify = func(ThrottleEvent) {}
+	}
+	th := &throttler{policy: t, notify: notify, routes: make(map[*Route]*throttled)}
+	dm.TrackStats()
+	// Every burn is reported, so that recovering routes are known too.
+	w := &sloWatcher{window: window, prev: make(map[*Route]sloCounts)}
+	w.evaluate(dm.snapshot())
+	return every(window, func() {
+		burns := make(map[*Route]SLOBurn)
+		w.f = func(b SLOBurn) { burns[b.Route] = b }
+		w.evaluate(dm.snapshot())
+		th.apply(burns)
+	})
+}
+
+type throttler struct {
+	policy Throttle
+	notify ThrottleFunc
+	mu     sync.Mutex
+	routes map[*Route]*throttled
+}
+
+// State of a throttled route.
+type throttled struct {
+	// Config the route had before it was throttled.
+	prev *routeConfig
+	// Config applied by the throttler.
+	applied *routeConfig
+	// Consecutive windows under the threshold.
+	calm int
+}
+
+// Throttles or restores routes according to burns of a window.
+func (th *throttler) apply(burns map[*Route]SLOBurn) {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+	p := th.policy
+	burning := make(map[*Route]bool)
+	for r, b := range burns {
+		if math.Max(b.Availability, b.Latency) < p.Threshold {
+			continue
+		}
+		// Errors of a disabled route are its own 503 responses.
+		if st := th.routes[r]; st != nil && st.applied == r.live.config.Load() && st.applied.Disabled {
+			continue
+		}
+		burning[r] = true
+	}
+	for r := range burning {
+		b := burns[r]
+		burn := math.Max(b.Availability, b.Latency)
+		current := r.live.config.Load()
+		st := th.routes[r]
+		if st == nil || current != st.applied {
+			st = &throttled{prev: current}
+			th.routes[r] = st
+		}
+		st.calm = 0
+		c := r.Config()
+		switch {
+		case p.Trip > 0 && burn >= p.Trip:
+			c.Disabled = true
+		case c.RateLimit > 0:
+			c.RateLimit *= p.Factor
+		default:
+			c.RateLimit = float64(b.Requests) / b.Window.Seconds() * p.Factor
+		}
+		c.Burst = 0
+		st.applied = newRouteConfig(c)
+		r.setConfig(st.applied)
+		th.notify(ThrottleEvent{Route: r, Burn: b, Config: c})
+	}
+	for r, st := range th.routes {
+		if burning[r] {
+			continue
+		}
+		if r.live.config.Load() != st.applied {
+			// Configured by someone else meanwhile.
+			delete(th.routes, r)
+			continue
+		}
+		if st.calm++; st.calm < p.Recover {
+			continue
+		}
+		delete(th.routes, r)
+		r.setConfig(st.prev)
+		th.notify(ThrottleEvent{Route: r, Config: r.Config(), Restored: true})
+	}
+}
@@ -0,0 +1,67 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestThrottler(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	a := m.Add("GET", "a", dummy).As("a")
+	b := m.Add("GET", "b", dummy)
+	m.Configure("a", RouteConfig{RateLimit: 100, Timeout: time.Second})
+	var events []ThrottleEvent
+	th := &throttler{
+		policy: Throttle{Threshold: 10, Factor: 0.5, Trip: 50, Recover: 2},
+		notify: func(e ThrottleEvent) { events = append(events, e) },
+		routes: make(map[*Route]*throttled),
+	}
+	burn := func(r *Route, rate float64) SLOBurn {
+		return SLOBurn{Route: r, Window: time.Second, Requests: 40, Availability: rate}
+	}
+
+	th.apply(map[*Route]SLOBurn{a: burn(a, 20), b: burn(b, 20)})
+	if c := a.Config(); c.RateLimit != 50 || c.Timeout != time.Second {
+		t.Errorf("config of a = %+v", c)
+	}
+	if c := b.Config(); c.RateLimit != 20 {
+		t.Errorf("config of b = %+v", c)
+	}
+	th.apply(map[*Route]SLOBurn{a: burn(a, 20), b: burn(b, 60)})
+	if c := a.Config(); c.RateLimit != 25 {
+		t.Errorf("config of a after another burn = %+v", c)
+	}
+	if !b.Config().Disabled {
+		t.Error("b not disabled")
+	}
+	assertEqual(t, serve(m, "GET", "/b").Body.String(), "Service Unavailable\n")
+	if len(events) != 4 {
+		t.Fatalf("got %d events, want 4", len(events))
+	}
+
+	// 503 responses of disabled b don't keep it disabled.
+	th.apply(map[*Route]SLOBurn{b: burn(b, 100)})
+	th.apply(nil)
+	if c := a.Config(); c.RateLimit != 100 || c.Timeout != time.Second {
+		t.Errorf("config of a not restored: %+v", c)
+	}
+	if c := b.Config(); c != (RouteConfig{}) {
+		t.Errorf("config of b not restored: %+v", c)
+	}
+	if e := events[len(events)-1]; !e.Restored {
+		t.Errorf("last event = %+v", e)
+	}
+
+	// Configs changed meanwhile are kept.
+	th.apply(map[*Route]SLOBurn{a: burn(a, 20)})
+	m.Configure("a", RouteConfig{RateLimit: 1})
+	th.apply(nil)
+	th.apply(nil)
+	if c := a.Config(); c.RateLimit != 1 {
+		t.Errorf("config of a = %+v, want the configured one", c)
+	}
+}
@@ -0,0 +1,52 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	var timedOut []string
+	lateWrite := make(chan error, 1)
+	onTimeout := func(r *http.Request, route *Route, d time.Duration) {
+		timedOut = append(timedOut, fmt.Sprintf("%s %s", route.Pattern, d))
+	}
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "fast", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		w.Header().Set("X-Fast", "1")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, "done")
+	}).Use(Timeout(time.Second, onTimeout))
+	m.Add("GET", "slow", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		fmt.Fprint(w, "partial")
+		<-r.Context().Done()
+		time.Sleep(10 * time.Millisecond)
+		_, err := fmt.Fprint(w, "late")
+		lateWrite <- err
+	}).Use(Timeout(20*time.Millisecond, onTimeout))
+
+	w := serve(m, "GET", "/fast")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 Created, got %d", w.Code)
+	}
+	assertEqual(t, w.Body.String(), "done")
+	assertEqual(t, w.Header().Get("X-Fast"), "1")
+
+	w = serve(m, "GET", "/slow")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503, got %d", w.Code)
+	}
+	assertEqual(t, w.Body.String(), "Service Unavailable\n")
+	if err := <-lateWrite; err != http.ErrHandlerTimeout {
+		t.Fatalf("Expected ErrHandlerTimeout on late write, got %v", err)
+	}
+	if len(timedOut) != 1 || timedOut[0] != "slow 20ms" {
+		t.Fatalf("Expected one timeout of slow route, got %v", timedOut)
+	}
+}
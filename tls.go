@@ -0,0 +1,61 @@
+package muxer
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// Requires requests of this route to be served over TLS. Plaintext GET
+// and HEAD requests are redirected to https with 301 Moved Permanently,
+// other ones are rejected with 403 Forbidden passed to Error() as
+// *StatusError, since their body has been sent in plaintext already.
+//
+// A request is plaintext if it was sent to the server or, for requests
+// from proxies trusted with TrustProxies(), to the proxy over http
+// according to Forwarded or X-Forwarded-Proto. Redirects go to the host
+// the client sent the request to.
+func (r *Route) RequireTLS() *Route {
+	if r.tlsVersion == 0 {
+		r.RequireTLSVersion(tls.VersionTLS10)
+	}
+	return r
+}
+
+// Same as RequireTLS() and rejects requests over TLS older than version,
+// e.g. tls.VersionTLS13, with 403 Forbidden. The version of TLS terminated
+// by a proxy is unknown and not checked.
+func (r *Route) RequireTLSVersion(version uint16) *Route {
+	r.tlsVersion = version
+	r.group.mux.handlersChanged()
+	return r
+}
+
+// Wraps next rejecting or redirecting requests over plaintext or TLS older
+// than version.
+func requireTLSHandler(dm *defaultMux, version uint16, next HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		secure := dm.scheme(r) == "https"
+		if !secure && (r.Method == "GET" || r.Method == "HEAD") {
+			host := dm.host(r)
+			if h, _, err := net.SplitHostPort(host); err == nil {
+				host = h
+			}
+			http.Redirect(w, r, "https://"+host+dm.externalPrefix(r)+r.URL.RequestURI(),
+				http.StatusMovedPermanently)
+			return
+		}
+		if !secure {
+			Error(w, r, NewStatusError(http.StatusForbidden, "TLS required"))
+			return
+		}
+		if r.TLS != nil && r.TLS.Version < version {
+			Error(w, r, NewStatusError(http.StatusForbidden,
+				fmt.Sprintf("%s or later required", tls.VersionName(version))))
+			return
+		}
+		next(w, r, v)
+	}
+}
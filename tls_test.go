@@ -0,0 +1,75 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+)
+
+func TestRequireTLS(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "account", dummy).RequireTLS()
+	m.Add("POST", "account", dummy).RequireTLS()
+	m.Add("GET", "admin", dummy).RequireTLSVersion(tls.VersionTLS13)
+	m.Add("GET", "public", dummy)
+
+	tests := []struct {
+		method, url string
+		tlsVersion  uint16
+		code        int
+		location    string
+	}{
+		{"GET", "http://example.com:8080/account?tab=1", 0, 301, "https://example.com/account?tab=1"},
+		{"POST", "http://example.com/account", 0, 403, ""},
+		{"GET", "https://example.com/account", tls.VersionTLS12, 200, ""},
+		{"GET", "https://example.com/admin", tls.VersionTLS12, 403, ""},
+		{"GET", "https://example.com/admin", tls.VersionTLS13, 200, ""},
+		{"GET", "http://example.com/public", 0, 200, ""},
+	}
+	for i, test := range tests {
+		req, _ := http.NewRequest(test.method, test.url, nil)
+		if test.tlsVersion != 0 {
+			req.TLS = &tls.ConnectionState{Version: test.tlsVersion}
+		}
+		w := serveRequest(m, req)
+		if w.Code != test.code {
+			t.Errorf("%d: Expected %d, got %d", i, test.code, w.Code)
+		}
+		assertEqual(t, w.Header().Get("Location"), test.location)
+	}
+	req, _ := http.NewRequest("GET", "https://example.com/admin", nil)
+	req.TLS = &tls.ConnectionState{Version: tls.VersionTLS11}
+	w := serveRequest(m, req)
+	assertEqual(t, w.Body.String(), "TLS 1.3 or later required\n")
+}
+
+func TestRequireTLSProxy(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.TrustProxies("10.0.0.0/8")
+	m.Add("GET", "account", dummy).RequireTLS()
+
+	tests := []struct {
+		remoteAddr string
+		header     http.Header
+		code       int
+		location   string
+	}{
+		{"10.0.0.1:1234", http.Header{"X-Forwarded-Proto": {"https"}}, 200, ""},
+		{"10.0.0.1:1234", http.Header{"Forwarded": {"proto=https;host=shop.example.com"}}, 200, ""},
+		{"10.0.0.1:1234", http.Header{"Forwarded": {"proto=http;host=shop.example.com"}}, 301, "https://shop.example.com/account"},
+		{"10.0.0.1:1234", http.Header{"X-Forwarded-Proto": {"http"}}, 301, "https://internal/account"},
+		{"203.0.113.7:1234", http.Header{"X-Forwarded-Proto": {"https"}}, 301, "https://internal/account"},
+	}
+	for i, test := range tests {
+		req, _ := http.NewRequest("GET", "http://internal:8080/account", nil)
+		req.RemoteAddr, req.Header = test.remoteAddr, test.header
+		w := serveRequest(m, req)
+		if w.Code != test.code {
+			t.Errorf("%d: Expected %d, got %d", i, test.code, w.Code)
+		}
+		assertEqual(t, w.Header().Get("Location"), test.location)
+	}
+}
@@ -0,0 +1,75 @@
+package muxer
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Function type changing response resp to request r before it's sent.
+// StatusCode defaults to 200 OK and changes to Header take effect.
+// An error is sent instead of the response, see Error().
+type TransformFunc func(r *http.Request, resp *Response) error
+
+type transform struct {
+	tag string
+	f   TransformFunc
+}
+
+// Adds a transform of responses of routes tagged tag, or of all routes if
+// tag is empty. Handlers of such routes write to a buffer, which
+// transforms change in the order they were added before the response is
+// sent, e.g. to wrap JSON in an envelope:
+//
+//	m.Transform("api", func(r *http.Request, resp *muxer.Response) error {
+//		resp.Body = append(append([]byte(`{"data":`), resp.Body...), '}')
+//		return nil
+//	})
+//
+// Content-Length is set from the final body. Flushes by the handler don't
+// reach the client, so streaming routes shouldn't be transformed.
+func (dm *defaultMux) Transform(tag string, f TransformFunc) {
+	dm.transforms = append(dm.transforms, transform{tag, f})
+}
+
+// Returns transforms of route r, including JSONP, see Route.JSONP().
+func (dm *defaultMux) transformsOf(r *Route) []TransformFunc {
+	var fs []TransformFunc
+	for _, t := range dm.transforms {
+		if t.tag == "" || r.HasTag(t.tag) {
+			fs = append(fs, t.f)
+		}
+	}
+	if r.jsonp != "" {
+		fs = append(fs, jsonpTransform(r.jsonp))
+	}
+	return fs
+}
+
+// Returns h with its response buffered and changed by transforms fs.
+func transformResponse(fs []TransformFunc, h HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		bw := &bufferedWriter{ResponseWriter: w}
+		h(bw, r, v)
+		resp := &Response{StatusCode: bw.code, Header: w.Header(), Body: bw.body.Bytes()}
+		if resp.StatusCode == 0 {
+			resp.StatusCode = http.StatusOK
+		}
+		for _, f := range fs {
+			if err := f(r, resp); err != nil {
+				Error(w, r, err)
+				return
+			}
+		}
+		if bodyAllowed(resp.StatusCode) {
+			resp.Header.Set("Content-Length", strconv.Itoa(len(resp.Body)))
+		}
+		w.WriteHeader(resp.StatusCode)
+		w.Write(resp.Body)
+	}
+}
+
+// Reports whether responses with status code may have a body.
+func bodyAllowed(code int) bool {
+	return code >= 200 && code != http.StatusNoContent && code != http.StatusNotModified
+}
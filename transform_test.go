@@ -0,0 +1,43 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestTransform(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Transform("api", func(r *http.Request, resp *Response) error {
+		resp.Body = append(append([]byte(`{"data":`), resp.Body...), '}')
+		return nil
+	})
+	m.Transform("", func(r *http.Request, resp *Response) error {
+		if r.URL.Query().Get("fail") != "" {
+			return NewStatusError(http.StatusBadRequest, "")
+		}
+		resp.Header.Set("X-Transformed", "1")
+		return nil
+	})
+	m.Add("GET", "users", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		w.Header().Set("Content-Length", "2")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("[]"))
+	}).Tag("api")
+	m.Add("GET", "plain", dummy)
+
+	w := serve(m, "GET", "/users")
+	assertEqual(t, w.Body.String(), `{"data":[]}`)
+	assertEqual(t, w.Header().Get("Content-Length"), "11")
+	if w.Code != http.StatusCreated {
+		t.Errorf("code = %d, want 201", w.Code)
+	}
+	w = serve(m, "GET", "/plain")
+	assertEqual(t, w.Body.String()+" "+w.Header().Get("X-Transformed"), "params: 1")
+	if w := serve(m, "GET", "/plain?fail=1"); w.Code != http.StatusBadRequest {
+		t.Errorf("failed transform: code %d, want 400", w.Code)
+	}
+}
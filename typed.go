@@ -0,0 +1,78 @@
+package muxer
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Built-in param constraints by keyword, e.g. "{id:int}". Unlike other
+// constraints, a request of which no route matches only since such a param
+// is of the wrong shape gets InvalidParamStatus().
+var paramTypes = map[string]string{
+	"int":   `-?[0-9]+`,
+	"uint":  `[0-9]+`,
+	"alpha": `[a-zA-Z]+`,
+	"alnum": `[a-zA-Z0-9]+`,
+	"slug":  `[a-z0-9]+(?:-[a-z0-9]+)*`,
+	"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+}
+
+// Sets status code of responses to requests of which no route matches
+// only since a param with a built-in constraint, e.g. "users/{id:int}" of
+// "/users/abc", is of the wrong shape. Defaults to 400 Bad Request.
+// Zero treats them as any other request matching no route, usually with
+// 404 Not Found. Built-in constraints are:
+//
+//	int    optionally negative integer
+//	uint   non-negative integer
+//	alpha  ASCII letters
+//	alnum  ASCII letters and digits
+//	slug   lower case letters and digits separated by single dashes
+//	uuid   UUID in the canonical form, in either case
+func (dm *defaultMux) InvalidParamStatus(code int) {
+	dm.invalidParam = code
+}
+
+// Serves req with InvalidParamStatus() if some route would match URL path p
+// but for a param with a built-in constraint. Reports whether it has.
+func (dm *defaultMux) serveInvalidParam(w http.ResponseWriter, req *http.Request, p string) bool {
+	if dm.invalidParam == 0 {
+		return false
+	}
+	n := countSegments(p)
+	listener := listenerOf(req)
+	for _, r := range dm.snapshot() {
+		if r.Method != req.Method || !r.fits(n) || r.Disabled() || r.listener() != listener {
+			continue
+		}
+		if name, ok := r.invalidParam(p); ok {
+			Error(w, req, NewStatusError(dm.invalidParam, fmt.Sprintf("Invalid param '%s'", name)))
+			return true
+		}
+	}
+	return false
+}
+
+// Returns name of the first param with a built-in constraint which path
+// doesn't satisfy. Reports false unless path would match this route
+// otherwise.
+func (r *Route) invalidParam(path string) (string, bool) {
+	invalid := ""
+	segs := NewSegments(path)
+	for _, rp := range r.parts {
+		if rp.rest {
+			break
+		}
+		seg, _ := segs.Next()
+		if rp.matches(seg) {
+			continue
+		}
+		if rp.typ == "" {
+			return "", false
+		}
+		if invalid == "" {
+			invalid = rp.name
+		}
+	}
+	return invalid, invalid != ""
+}
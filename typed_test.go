@@ -0,0 +1,50 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestTypedParams(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "users/{id:int}", dummy)
+	m.Add("GET", "users/me", dummy)
+	m.Add("GET", "orders/{id:uuid}/items/{n:uint}", dummy)
+	m.Add("GET", "tags/{tag:slug}", dummy)
+	m.Add("GET", "names/{name:alpha}", dummy)
+
+	assertEqual(t, serve(m, "GET", "/users/-42").Body.String(), "params:id=-42")
+	assertEqual(t, serve(m, "GET", "/users/me").Body.String(), "params:")
+	assertEqual(t, serve(m, "GET", "/orders/0b5b0a8e-2b4a-4d8e-9c1e-5f6a7b8c9d0e/items/3").Body.String(),
+		"params:id=0b5b0a8e-2b4a-4d8e-9c1e-5f6a7b8c9d0e&n=3")
+	assertEqual(t, serve(m, "GET", "/tags/go-http").Body.String(), "params:tag=go-http")
+
+	for path, want := range map[string]int{
+		"/users/abc":          http.StatusBadRequest,
+		"/orders/123/items/1": http.StatusBadRequest,
+		"/tags/Go--http":      http.StatusBadRequest,
+		"/names/bob1":         http.StatusBadRequest,
+		"/users/abc/extra":    http.StatusNotFound,
+		"/orders/x/parts/1":   http.StatusNotFound,
+		"/unknown/abc":        http.StatusNotFound,
+	} {
+		if code := serve(m, "GET", path).Code; code != want {
+			t.Errorf("GET %s = %d, want %d", path, code, want)
+		}
+	}
+	if code := serve(m, "POST", "/users/abc").Code; code == http.StatusBadRequest {
+		t.Error("POST /users/abc = 400, want no route")
+	}
+
+	m.InvalidParamStatus(http.StatusUnprocessableEntity)
+	if code := serve(m, "GET", "/users/abc").Code; code != http.StatusUnprocessableEntity {
+		t.Errorf("GET /users/abc = %d, want 422", code)
+	}
+	m.InvalidParamStatus(0)
+	if code := serve(m, "GET", "/users/abc").Code; code != http.StatusNotFound {
+		t.Errorf("GET /users/abc = %d, want 404", code)
+	}
+}
@@ -0,0 +1,109 @@
+package muxer
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Function type checking the route table of a mux, see Mux.AddValidator().
+type ValidatorFunc func(m Mux) error
+
+// Adds a check run by Validate(), e.g. that every route of an app has
+// an owner or that handler names of a route config file all exist.
+func (dm *defaultMux) AddValidator(f ValidatorFunc) {
+	dm.validators = append(dm.validators, f)
+}
+
+// Checks the route table and returns all problems found, joined with
+// errors.Join(), or nil. Meant to fail fast at boot, after all routes
+// are added:
+//
+//	if err := m.Validate(); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Built-in checks report suspicious patterns accepted in Warn or
+// Permissive mode, routes without a handler, routes which can't match any
+// request since an earlier route matches all their paths, and routes
+// partly overlapping earlier ones, so the order they are added in
+// matters. Validators added with AddValidator() run afterwards.
+func (dm *defaultMux) Validate() error {
+	var errs []error
+	routes := dm.snapshot()
+	unreachable := make(map[*Route]bool)
+	for i, r := range routes {
+		for _, problem := range patternProblems(r.Pattern) {
+			errs = append(errs, fmt.Errorf("Route '%s %s': %s", r.Method, r.Pattern, problem))
+		}
+		if r.Handler == nil {
+			errs = append(errs, fmt.Errorf("Route '%s %s' has no handler", r.Method, r.Pattern))
+		}
+		for _, prev := range routes[:i] {
+			if unreachable[prev] || !prev.competes(r) {
+				continue
+			}
+			if prev.subsumes(r) {
+				errs = append(errs, fmt.Errorf("Route '%s %s' is unreachable, '%s' matches all its paths",
+					r.Method, r.Pattern, prev.Pattern))
+				unreachable[r] = true
+				break
+			}
+			if !r.subsumes(prev) && prev.overlaps(r) {
+				errs = append(errs, fmt.Errorf("Route '%s %s' overlaps '%s', which takes precedence",
+					r.Method, r.Pattern, prev.Pattern))
+			}
+		}
+	}
+	for _, f := range dm.validators {
+		if err := f(dm); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Reports whether enabled route r is matched before b for the same
+// requests, besides their paths.
+func (r *Route) competes(b *Route) bool {
+	return !r.Disabled() && r.schemes == nil && r.Method == b.Method &&
+		r.listener() == b.listener() && (r.fits(b.partsLen) || b.fits(r.partsLen))
+}
+
+// Reports whether every path of route b matches r.
+func (r *Route) subsumes(b *Route) bool {
+	if b.catchAll() && !r.catchAll() || !r.fits(b.partsLen) {
+		return false
+	}
+	for i, rp := range r.parts {
+		if rp.rest {
+			break
+		}
+		if bp := b.parts[i]; bp.rest || !rp.covers(bp) {
+			return false
+		}
+	}
+	return true
+}
+
+// Reports whether every segment part b matches, matches rp too.
+// Constraints are assumed to overlap partly unless they are the same.
+func (rp *pathPart) covers(b pathPart) bool {
+	if !b.isVar {
+		return rp.matches(b.name)
+	}
+	return rp.isVar && (rp.re == nil || rp.re == b.re)
+}
+
+// Reports whether some path of route b matches r.
+func (r *Route) overlaps(b *Route) bool {
+	if !r.fits(b.partsLen) && !b.fits(r.partsLen) {
+		return false
+	}
+	for i := 0; i < r.partsLen && i < b.partsLen; i++ {
+		rp, bp := r.parts[i], b.parts[i]
+		if !bp.isVar && !rp.matches(bp.name) || !rp.isVar && !bp.matches(rp.name) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "users/me", dummy)
+	m.Add("GET", "users/{id}", dummy)
+	m.Add("GET", "users/{name}", dummy)
+	m.Add("PUT", "users/{name}", dummy)
+	m.Add("GET", "{kind}/all", dummy)
+	m.Add("POST", "jobs", nil)
+	if err := m.Validate(); err == nil {
+		t.Fatal("Validate() = nil")
+	}
+	m.SetStrictness(Permissive)
+	m.Add("GET", "a//b", dummy)
+	m.AddValidator(func(m Mux) error {
+		return errors.New("custom")
+	})
+
+	got := strings.Split(m.Validate().Error(), "\n")
+	want := []string{
+		"Route 'GET users/{name}' is unreachable, 'users/{id}' matches all its paths",
+		"Route 'GET {kind}/all' overlaps 'users/{id}', which takes precedence",
+		"Route 'POST jobs' has no handler",
+		"Route 'GET a//b': empty segment #2",
+		"custom",
+	}
+	assertEqual(t, strings.Join(got, "\n"), strings.Join(want, "\n"))
+}
+
+func TestValidateClean(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "users/me", dummy)
+	m.Add("GET", "users/{id}", dummy)
+	m.Add("GET", "users/{id}/posts", dummy)
+	m.Add("GET", "old/{id}", dummy).Disable()
+	m.Add("GET", "old/{name}", dummy)
+	if err := m.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+}
+
+func TestValidateCatchAll(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "files/readme", dummy)
+	m.Add("GET", "files/{path...}", dummy)
+	m.Add("GET", "files/{dir}/index", dummy)
+	m.Add("GET", "{kind}/{rest...}", dummy)
+	m.Add("GET", "static/{file...}", dummy)
+	m.Add("GET", "files", dummy)
+
+	got := strings.Split(m.Validate().Error(), "\n")
+	want := []string{
+		"Route 'GET files/{dir}/index' is unreachable, 'files/{path...}' matches all its paths",
+		"Route 'GET static/{file...}' is unreachable, '{kind}/{rest...}' matches all its paths",
+	}
+	assertEqual(t, strings.Join(got, "\n"), strings.Join(want, "\n"))
+}
+
+func TestValidateConstraints(t *testing.T) {
+	m := NewMux("/", http.NewServeMux())
+	m.Add("GET", "users/{id:[0-9]+}", dummy)
+	m.Add("GET", "users/me", dummy)
+	m.Add("GET", "users/42", dummy)
+	m.Add("GET", "users/{n:[0-9]+}", dummy)
+	m.Add("GET", "users/{name}", dummy)
+
+	got := strings.Split(m.Validate().Error(), "\n")
+	want := []string{
+		"Route 'GET users/42' is unreachable, 'users/{id:[0-9]+}' matches all its paths",
+		"Route 'GET users/{n:[0-9]+}' is unreachable, 'users/{id:[0-9]+}' matches all its paths",
+	}
+	assertEqual(t, strings.Join(got, "\n"), strings.Join(want, "\n"))
+}
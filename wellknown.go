@@ -0,0 +1,49 @@
+package muxer
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// Path prefix of well-known URIs, see RFC 8615.
+const wellKnownPrefix = ".well-known/"
+
+// Serves content at /.well-known/name, e.g. "security.txt" as of RFC 9116:
+//
+//	m.WellKnown("security.txt", "Contact: mailto:security@example.com\n"+
+//		"Expires: 2027-01-01T00:00:00Z\n")
+//
+// The content type is detected from the name, or content if it has no
+// extension, JSON documents such as "openid-configuration" being sent as
+// application/json. Responses can be cached for a day. Like robots.txt,
+// see RobotsTxt(), the route is registered with the http.ServeMux of this
+// mux unless its base path is "/", since well-known URIs are at the host
+// root. Panics if name isn't a relative path.
+func (dm *defaultMux) WellKnown(name, content string) {
+	checkWellKnown(name)
+	contentType := ""
+	if path.Ext(name) == "" {
+		contentType = "text/plain; charset=utf-8"
+		if json.Valid([]byte(content)) {
+			contentType = "application/json"
+		}
+	}
+	dm.addRootFile(wellKnownPrefix+name, []byte(content), contentType, 24*time.Hour)
+}
+
+// Same as WellKnown() with h serving GET requests of /.well-known/name,
+// e.g. to build a document from config at request time. The handler gets
+// no params.
+func (dm *defaultMux) WellKnownHandler(name string, h HandlerFunc) {
+	checkWellKnown(name)
+	dm.addRootHandler(wellKnownPrefix+name, h)
+}
+
+func checkWellKnown(name string) {
+	if name == "" || strings.HasPrefix(name, "/") || path.Clean(name) != name || strings.HasPrefix(name, "..") {
+		panic(fmt.Sprintf("Bad well-known URI name '%s'", name))
+	}
+}
@@ -0,0 +1,45 @@
+//go:build !appengine
+// +build !appengine
+
+package muxer
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestWellKnown(t *testing.T) {
+	hm := http.NewServeMux()
+	m := NewMux("/api", hm)
+	m.WellKnown("security.txt", "Contact: mailto:security@example.com\n")
+	m.WellKnown("openid-configuration", `{"issuer":"https://example.com"}`)
+	m.WellKnownHandler("change-password", func(w http.ResponseWriter, r *http.Request, v url.Values) {
+		http.Redirect(w, r, "/account/password", http.StatusFound)
+	})
+
+	w := serve(hm, "GET", "/.well-known/security.txt")
+	assertEqual(t, w.Body.String(), "Contact: mailto:security@example.com\n")
+	assertEqual(t, w.Header().Get("Content-Type"), "text/plain; charset=utf-8")
+	w = serve(hm, "GET", "/.well-known/openid-configuration")
+	assertEqual(t, w.Header().Get("Content-Type"), "application/json")
+	assertEqual(t, serve(hm, "GET", "/.well-known/change-password").Header().Get("Location"), "/account/password")
+	if w := serve(hm, "POST", "/.well-known/change-password"); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST code = %d, want 405", w.Code)
+	}
+
+	root := NewMux("/", http.NewServeMux())
+	root.WellKnown("security.txt", "x")
+	if len(root.Routes()) != 1 || root.Routes()[0].Pattern != ".well-known/security.txt" {
+		t.Error("well-known URI of a root mux not added as a route")
+	}
+}
+
+func TestWellKnownBadName(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("WellKnown(../x) didn't panic")
+		}
+	}()
+	NewMux("/", http.NewServeMux()).WellKnown("../x", "")
+}